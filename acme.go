@@ -0,0 +1,166 @@
+package indigo
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net"
+	stdhttp "net/http"
+	"net/url"
+	"strings"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/codec"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/router"
+	"github.com/indigo-web/indigo/transport"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const acmeChallengePrefix = "/.well-known/acme-challenge/"
+
+type AutocertParams struct {
+	// Domains restricts the domains certificates are issued for. If empty, certificates are
+	// issued for any server name clients indicate, which is discouraged in production.
+	Domains []string
+	// Cache is the directory issued certificates are stored at. Defaults to the OS-dependent
+	// cache directory.
+	Cache string
+	// Email is the contact address of the ACME account. Optional.
+	Email string
+	// DirectoryURL is the ACME directory endpoint of the certificate authority. Defaults to
+	// Let's Encrypt production.
+	DirectoryURL string
+	// HTTPAddr is the address of a companion plain HTTP listener (normally :80), answering the
+	// HTTP-01 challenges and redirecting all the other requests to HTTPS. Without it, only
+	// the TLS-ALPN-01 challenge is possible.
+	HTTPAddr string
+}
+
+// AutocertWith issues certificates automatically, as AutocertWithCache does, additionally
+// allowing to set up the HTTP-01 challenge listener and the certificate authority:
+//
+//	app.Listen(":443", indigo.AutocertWith(indigo.AutocertParams{
+//		Domains:  []string{"example.com"},
+//		HTTPAddr: ":80",
+//	}))
+func AutocertWith(params AutocertParams) Transport {
+	if len(params.Cache) == 0 {
+		params.Cache = tlsCacheDir()
+	}
+
+	m := &autocert.Manager{
+		Prompt: autocert.AcceptTOS,
+		Cache:  autocert.DirCache(params.Cache),
+		Email:  params.Email,
+	}
+
+	if len(params.Domains) > 0 {
+		m.HostPolicy = autocert.HostWhitelist(params.Domains...)
+	}
+
+	if len(params.DirectoryURL) > 0 {
+		m.Client = &acme.Client{DirectoryURL: params.DirectoryURL}
+	}
+
+	// autocert.Manager.TLSConfig isn't used, as it advertises HTTP/2, which isn't supported
+	t := newTLSTransport(&tls.Config{
+		GetCertificate: m.GetCertificate,
+		NextProtos:     []string{"http/1.1", acme.ALPNProto},
+	})
+	if len(params.HTTPAddr) > 0 {
+		t.companions = func(httpsAddr string) []Transport {
+			return []Transport{acmeCompanion(params.HTTPAddr, httpsAddr, m.HTTPHandler(nil))}
+		}
+	}
+
+	return t
+}
+
+// acmeCompanion returns a plain HTTP transport, serving the ACME challenges by the handler
+// (normally autocert.Manager.HTTPHandler) instead of the application router.
+func acmeCompanion(addr, httpsAddr string, challenge stdhttp.Handler) Transport {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	if port == "443" {
+		port = ""
+	}
+
+	r := acmeRouter{challenge: challenge, httpsPort: port}
+	t := newPlainTransport(transport.NewTCP())
+	spawn := t.spawnCallback
+	t.addr = addr
+	t.spawnCallback = func(cfg *config.Config, _ router.Router, c []codec.Codec) func(net.Conn) {
+		return spawn(cfg, r, c)
+	}
+
+	return t
+}
+
+type acmeRouter struct {
+	challenge stdhttp.Handler
+	httpsPort string
+}
+
+func (a acmeRouter) OnRequest(request *http.Request) *http.Response {
+	host, _, err := net.SplitHostPort(request.Host())
+	if err != nil {
+		host = request.Host()
+	}
+
+	if len(host) == 0 {
+		return request.Respond().
+			Code(status.BadRequest).
+			String("no Host header")
+	}
+
+	if strings.HasPrefix(request.Path, acmeChallengePrefix) {
+		return a.serveChallenge(request, host)
+	}
+
+	if len(a.httpsPort) > 0 {
+		host = net.JoinHostPort(host, a.httpsPort)
+	}
+
+	return request.Respond().
+		Code(status.PermanentRedirect).
+		Header("Location", "https://"+host+request.RequestURI())
+}
+
+func (a acmeRouter) OnError(request *http.Request, err error) *http.Response {
+	return http.Error(request, err)
+}
+
+// serveChallenge passes the request to the autocert handler, as the token lookup isn't
+// exposed otherwise.
+func (a acmeRouter) serveChallenge(request *http.Request, host string) *http.Response {
+	w := &challengeWriter{header: make(stdhttp.Header), code: int(status.OK)}
+	a.challenge.ServeHTTP(w, &stdhttp.Request{
+		Method: stdhttp.MethodGet,
+		Host:   host,
+		URL:    &url.URL{Path: request.Path},
+		Header: make(stdhttp.Header),
+	})
+
+	return request.Respond().
+		Code(status.Code(w.code)).
+		Bytes(w.body.Bytes())
+}
+
+type challengeWriter struct {
+	header stdhttp.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (c *challengeWriter) Header() stdhttp.Header {
+	return c.header
+}
+
+func (c *challengeWriter) Write(b []byte) (int, error) {
+	return c.body.Write(b)
+}
+
+func (c *challengeWriter) WriteHeader(code int) {
+	c.code = code
+}
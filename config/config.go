@@ -1,6 +1,7 @@
 package config
 
 import (
+	"net"
 	"time"
 
 	"github.com/indigo-web/indigo/http/mime"
@@ -35,6 +36,32 @@ type (
 	URIRequestLineSize struct {
 		Default, Maximal int
 	}
+
+	NETConnections struct {
+		// Max limits the number of simultaneously served connections per transport. Zero
+		// disables the limit.
+		Max int `test:"nullable"`
+		// PerIP limits the number of simultaneously served connections from a single remote IP
+		// address. Zero disables the limit.
+		PerIP int `test:"nullable"`
+		// QueueTimeout is the maximal duration a connection exceeding the Max limit waits for
+		// a free slot. If no slot was freed in time, or the value is zero, the connection is
+		// rejected. Rejected connections are answered with 503 Service Unavailable.
+		QueueTimeout time.Duration `test:"nullable"`
+		// MemoryBudget limits the total size of connection buffers (read, request line, headers
+		// and response buffers) held across all the transports, in bytes. While exceeded, new
+		// connections wait for up to QueueTimeout for the memory to be freed, and are rejected
+		// if it wasn't. Zero disables the limit.
+		MemoryBudget int64 `test:"nullable"`
+		// Filter decides whether a connection from the remote address must be served at all.
+		// Connections it refuses are closed immediately without any response. See
+		// transport.AllowList and transport.DenyList for CIDR-based filters.
+		Filter func(remote net.Addr) bool `test:"nullable"`
+		// OnReject is called every time a connection is refused, along with the reason, being
+		// one of transport.ErrTooManyConnections, transport.ErrTooManyConnectionsPerIP,
+		// transport.ErrMemoryBudgetExceeded or transport.ErrFiltered.
+		OnReject func(remote net.Addr, reason error) `test:"nullable"`
+	}
 )
 
 type (
@@ -100,6 +127,9 @@ type (
 		// auto compression option is enabled. This setting doesn't affect enforced compression
 		// options and unsized streams.
 		SmallBody int64
+		// Connections controls admission of freshly accepted connections. By default, nothing
+		// is limited.
+		Connections NETConnections
 	}
 )
 
@@ -107,7 +137,9 @@ type (
 // and pre-allocations.
 //
 // You must ALWAYS modify defaults (returned via Default()) and NEVER try to initialize the
-// config manually, because most likely this will result in ambiguous errors.
+// config manually, because most likely this will result in ambiguous errors. Alternatively,
+// the defaults can be overridden from a file via Load and from the environment via LoadEnv.
+// Validate reports the fields holding inconsistent values.
 type Config struct {
 	URI     URI
 	Headers Headers
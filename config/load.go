@@ -0,0 +1,332 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	json "github.com/json-iterator/go"
+	"gopkg.in/yaml.v3"
+)
+
+// FieldError describes a problem with a specific config field. The field is referred by its
+// path, e.g. NET.WriteBufferSize.Maximal.
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (f FieldError) Error() string {
+	return f.Field + ": " + f.Reason
+}
+
+// Load returns the default config, overridden by the values from the file. The format is
+// determined by the file extension, being one of .json, .yaml, .yml or .toml. See LoadFile
+// for details.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	return cfg, cfg.LoadFile(path)
+}
+
+// LoadFile overrides the fields present in the file, leaving all the others intact. Keys
+// are matched against the field names case-insensitively, ignoring dashes and underscores,
+// so read_timeout, read-timeout and ReadTimeout are all equal. Unknown keys are errors.
+//
+// Durations are either strings in the time.ParseDuration format (e.g. "1m30s") or plain
+// numbers of seconds. Integer fields additionally accept sizes with a unit suffix (e.g. "16kb"
+// or "2 MiB"), where kb, mb and gb are powers of 1024. Callbacks cannot be set this way.
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	raw := make(map[string]any)
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		// don't lose precision on big numbers
+		decoder.UseNumber()
+		err = decoder.Decode(&raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return fmt.Errorf("config: unsupported file format %q", ext)
+	}
+
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+
+	return assign(reflect.ValueOf(c).Elem(), raw, "")
+}
+
+// LoadEnv overrides the fields by the environment variables named after the field paths in
+// upper snake case, separated by underscores and prefixed by the prefix. For example, with
+// the prefix INDIGO:
+//
+//	INDIGO_NET_READ_TIMEOUT=30s
+//	INDIGO_URI_REQUEST_LINE_SIZE_MAXIMAL=32kb
+//	INDIGO_HEADERS_DEFAULT="Server=indigo,X-Frame-Options=DENY"
+//
+// Values are parsed the same way as by LoadFile. Maps are represented as comma-separated
+// key=value pairs.
+func (c *Config) LoadEnv(prefix string) error {
+	return assignEnv(reflect.ValueOf(c).Elem(), prefix, "")
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func assign(v reflect.Value, raw any, path string) error {
+	if v.Type() == durationType {
+		d, err := parseDuration(raw)
+		if err != nil {
+			return FieldError{path, err.Error()}
+		}
+
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		m, ok := raw.(map[string]any)
+		if !ok {
+			return FieldError{path, fmt.Sprintf("expected a table, got %T", raw)}
+		}
+
+		for key, value := range m {
+			field, found := lookupField(v.Type(), key)
+			if !found {
+				return FieldError{join(path, key), "unknown field"}
+			}
+
+			if err := assign(v.FieldByIndex(field.Index), value, join(path, field.Name)); err != nil {
+				return err
+			}
+		}
+	case reflect.Int, reflect.Int64:
+		n, err := parseSize(raw)
+		if err != nil {
+			return FieldError{path, err.Error()}
+		}
+
+		if n > 1<<63-1 || v.OverflowInt(int64(n)) {
+			return FieldError{path, "value is out of range"}
+		}
+
+		v.SetInt(int64(n))
+	case reflect.Uint64:
+		n, err := parseSize(raw)
+		if err != nil {
+			return FieldError{path, err.Error()}
+		}
+
+		v.SetUint(n)
+	case reflect.String:
+		s, ok := raw.(string)
+		if !ok {
+			return FieldError{path, fmt.Sprintf("expected a string, got %T", raw)}
+		}
+
+		v.SetString(s)
+	case reflect.Map:
+		m, err := parseMap(raw)
+		if err != nil {
+			return FieldError{path, err.Error()}
+		}
+
+		v.Set(reflect.ValueOf(m))
+	default:
+		return FieldError{path, "cannot be set from configuration"}
+	}
+
+	return nil
+}
+
+func assignEnv(v reflect.Value, name, path string) error {
+	if v.Kind() == reflect.Struct && v.Type() != durationType {
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			err := assignEnv(v.Field(i), name+"_"+snakeCase(field.Name), join(path, field.Name))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if v.Kind() == reflect.Func {
+		return nil
+	}
+
+	value, found := os.LookupEnv(name)
+	if !found {
+		return nil
+	}
+
+	return assign(v, value, path)
+}
+
+// lookupField finds the field by the key, ignoring case, dashes and underscores.
+func lookupField(typ reflect.Type, key string) (reflect.StructField, bool) {
+	key = normalizeKey(key)
+	for i := range typ.NumField() {
+		if field := typ.Field(i); normalizeKey(field.Name) == key {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+}
+
+// snakeCase converts the field name into upper snake case, keeping acronyms together,
+// e.g. PerIP becomes PER_IP and NET stays NET.
+func snakeCase(name string) string {
+	var b strings.Builder
+
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(rune(name[i-1]))
+			nextLower := i+1 < len(name) && unicode.IsLower(rune(name[i+1]))
+			if prevLower || (nextLower && unicode.IsUpper(rune(name[i-1]))) {
+				b.WriteByte('_')
+			}
+		}
+
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	return b.String()
+}
+
+func join(path, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+
+	return path + "." + name
+}
+
+func scalar(raw any) (string, error) {
+	switch value := raw.(type) {
+	case string:
+		return strings.TrimSpace(value), nil
+	case int:
+		return strconv.Itoa(value), nil
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	case uint64:
+		return strconv.FormatUint(value, 10), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case fmt.Stringer:
+		return value.String(), nil
+	default:
+		return "", fmt.Errorf("expected a scalar value, got %T", raw)
+	}
+}
+
+var sizeUnits = []struct {
+	suffix string
+	factor uint64
+}{
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30},
+	{"kb", 1 << 10}, {"mb", 1 << 20}, {"gb", 1 << 30},
+	{"k", 1 << 10}, {"m", 1 << 20}, {"g", 1 << 30},
+	{"b", 1},
+}
+
+// parseSize parses a non-negative integer with an optional size unit suffix.
+func parseSize(raw any) (uint64, error) {
+	str, err := scalar(raw)
+	if err != nil {
+		return 0, err
+	}
+
+	number, factor := strings.ToLower(str), uint64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(number, unit.suffix) {
+			number, factor = strings.TrimSpace(strings.TrimSuffix(number, unit.suffix)), unit.factor
+			break
+		}
+	}
+
+	n, err := strconv.ParseUint(number, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", str)
+	}
+
+	if n > (1<<64-1)/factor {
+		return 0, errors.New("value is out of range")
+	}
+
+	return n * factor, nil
+}
+
+func parseDuration(raw any) (time.Duration, error) {
+	str, err := scalar(raw)
+	if err != nil {
+		return 0, err
+	}
+
+	if seconds, err := strconv.ParseFloat(str, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", str)
+	}
+
+	return d, nil
+}
+
+func parseMap(raw any) (map[string]string, error) {
+	m := make(map[string]string)
+
+	switch value := raw.(type) {
+	case map[string]any:
+		for key, v := range value {
+			str, err := scalar(v)
+			if err != nil {
+				return nil, err
+			}
+
+			m[key] = str
+		}
+	case string:
+		for _, pair := range strings.Split(value, ",") {
+			if len(strings.TrimSpace(pair)) == 0 {
+				continue
+			}
+
+			key, v, found := strings.Cut(pair, "=")
+			if !found {
+				return nil, fmt.Errorf("expected key=value, got %q", pair)
+			}
+
+			m[strings.TrimSpace(key)] = strings.TrimSpace(v)
+		}
+	default:
+		return nil, fmt.Errorf("expected a table, got %T", raw)
+	}
+
+	return m, nil
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	files := map[string]string{
+		"config.json": `{
+			"net": {"read_timeout": "30s", "readBufferSize": "8kb", "connections": {"per-ip": 10}},
+			"uri": {"RequestLineSize": {"maximal": "32 KiB"}},
+			"headers": {"default": {"Server": "indigo"}},
+			"body": {"max_size": 18446744073709551615}
+		}`,
+		"config.yaml": `
+net:
+  read_timeout: 30s
+  read_buffer_size: 8kb
+  connections:
+    per_ip: 10
+uri:
+  request_line_size:
+    maximal: 32 KiB
+headers:
+  default:
+    Server: indigo
+body:
+  max_size: 18446744073709551615
+`,
+		"config.toml": `
+[net]
+read_timeout = "30s"
+read_buffer_size = "8kb"
+connections = { per_ip = 10 }
+
+[uri.request_line_size]
+maximal = "32 KiB"
+
+[headers.default]
+Server = "indigo"
+
+[body]
+max_size = "16gb"
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+			cfg, err := Load(path)
+			require.NoError(t, err)
+			require.Equal(t, 30*time.Second, cfg.NET.ReadTimeout)
+			require.Equal(t, 8*1024, cfg.NET.ReadBufferSize)
+			require.Equal(t, 10, cfg.NET.Connections.PerIP)
+			require.Equal(t, 32*1024, cfg.URI.RequestLineSize.Maximal)
+			require.Equal(t, map[string]string{"Server": "indigo"}, cfg.Headers.Default)
+			require.NotZero(t, cfg.Body.MaxSize)
+			// untouched fields keep their defaults
+			require.Equal(t, Default().URI.RequestLineSize.Default, cfg.URI.RequestLineSize.Default)
+			require.NoError(t, cfg.Validate())
+		})
+	}
+
+	t.Run("unknown field", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"net": {"read_timeuot": 5}}`), 0600))
+		_, err := Load(path)
+		require.EqualError(t, err, "NET.read_timeuot: unknown field")
+	})
+
+	t.Run("malformed value", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"net": {"read_buffer_size": "lots"}}`), 0600))
+		_, err := Load(path)
+		require.EqualError(t, err, `NET.ReadBufferSize: invalid size "lots"`)
+	})
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("APP_NET_READ_TIMEOUT", "1.5")
+	t.Setenv("APP_NET_CONNECTIONS_PER_IP", "4")
+	t.Setenv("APP_NET_WRITE_BUFFER_SIZE_MAXIMAL", "1mb")
+	t.Setenv("APP_HEADERS_DEFAULT", "Server=indigo, X-Frame-Options=DENY")
+
+	cfg := Default()
+	require.NoError(t, cfg.LoadEnv("APP"))
+	require.Equal(t, 1500*time.Millisecond, cfg.NET.ReadTimeout)
+	require.Equal(t, 4, cfg.NET.Connections.PerIP)
+	require.Equal(t, 1024*1024, cfg.NET.WriteBufferSize.Maximal)
+	require.Equal(t, map[string]string{"Server": "indigo", "X-Frame-Options": "DENY"}, cfg.Headers.Default)
+
+	t.Setenv("APP_NET_READ_BUFFER_SIZE", "-1")
+	require.EqualError(t, cfg.LoadEnv("APP"), `NET.ReadBufferSize: invalid size "-1"`)
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Default().Validate())
+
+	cfg := Default()
+	cfg.URI.RequestLineSize.Maximal = cfg.URI.RequestLineSize.Default - 1
+	cfg.NET.ReadBufferSize = 0
+	cfg.NET.ReadTimeout = -time.Second
+	cfg.NET.Connections.Max = 10
+	cfg.NET.Connections.PerIP = 20
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	require.Equal(t, "URI.RequestLineSize.Maximal: must not be less than URI.RequestLineSize.Default (2048), got 2047\n"+
+		"NET.ReadBufferSize: must be positive, got 0\n"+
+		"NET.ReadTimeout: must be positive, got -1s\n"+
+		"NET.Connections.PerIP: must not exceed NET.Connections.Max (10), got 20", err.Error())
+
+	var fieldErr FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	require.Equal(t, "URI.RequestLineSize.Maximal", fieldErr.Field)
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate reports every field holding an out-of-range value or contradicting other fields.
+// The returned error joins a FieldError per such field, or is nil if the config is consistent.
+func (c *Config) Validate() error {
+	var v validator
+
+	v.bounds("URI.RequestLineSize", c.URI.RequestLineSize.Default, c.URI.RequestLineSize.Maximal)
+	nonNegative(&v, "URI.ParamsPrealloc", c.URI.ParamsPrealloc)
+
+	v.bounds("Headers.Number", c.Headers.Number.Default, c.Headers.Number.Maximal)
+	v.bounds("Headers.Space", c.Headers.Space.Default, c.Headers.Space.Maximal)
+	positive(&v, "Headers.MaxEncodingTokens", c.Headers.MaxEncodingTokens)
+	positive(&v, "Headers.MaxAcceptEncodingTokens", c.Headers.MaxAcceptEncodingTokens)
+	nonNegative(&v, "Headers.CookiesPrealloc", c.Headers.CookiesPrealloc)
+
+	v.nonEmpty("Body.Form.DefaultCoding", c.Body.Form.DefaultCoding)
+	v.nonEmpty("Body.Form.DefaultContentType", c.Body.Form.DefaultContentType)
+
+	positive(&v, "NET.ReadBufferSize", c.NET.ReadBufferSize)
+	positive(&v, "NET.ReadTimeout", c.NET.ReadTimeout)
+	positive(&v, "NET.AcceptLoopInterruptPeriod", c.NET.AcceptLoopInterruptPeriod)
+	v.bounds("NET.WriteBufferSize", c.NET.WriteBufferSize.Default, c.NET.WriteBufferSize.Maximal)
+	nonNegative(&v, "NET.SmallBody", c.NET.SmallBody)
+
+	conns := c.NET.Connections
+	nonNegative(&v, "NET.Connections.Max", conns.Max)
+	nonNegative(&v, "NET.Connections.PerIP", conns.PerIP)
+	nonNegative(&v, "NET.Connections.QueueTimeout", conns.QueueTimeout)
+	nonNegative(&v, "NET.Connections.MemoryBudget", conns.MemoryBudget)
+	if conns.Max > 0 && conns.PerIP > conns.Max {
+		v.fail("NET.Connections.PerIP", "must not exceed NET.Connections.Max (%d), got %d", conns.Max, conns.PerIP)
+	}
+
+	return errors.Join(v.errs...)
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) fail(field, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{Field: field, Reason: fmt.Sprintf(format, args...)})
+}
+
+type number interface {
+	~int | ~int64
+}
+
+func positive[T number](v *validator, field string, value T) {
+	if value <= 0 {
+		v.fail(field, "must be positive, got %v", value)
+	}
+}
+
+func nonNegative[T number](v *validator, field string, value T) {
+	if value < 0 {
+		v.fail(field, "must not be negative, got %v", value)
+	}
+}
+
+// bounds validates a pair of the initial size and the upper limit.
+func (v *validator) bounds(field string, def, maximal int) {
+	switch {
+	case def <= 0:
+		v.fail(field+".Default", "must be positive, got %d", def)
+	case maximal < def:
+		v.fail(field+".Maximal", "must not be less than %s.Default (%d), got %d", field, def, maximal)
+	}
+}
+
+func (v *validator) nonEmpty(field, value string) {
+	if len(value) == 0 {
+		v.fail(field, "must not be empty")
+	}
+}
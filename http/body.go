@@ -82,7 +82,7 @@ func (b *Body) Bytes() ([]byte, error) {
 
 	newSize := int(b.request.cfg.Body.Form.BufferPrealloc)
 	if !b.request.Chunked {
-		newSize = min(b.request.ContentLength, int(b.request.cfg.Body.MaxSize))
+		newSize = int(min(uint64(b.request.ContentLength), b.request.cfg.Body.MaxSize))
 	}
 
 	b.buff = slices.Grow(b.buff[:0], newSize)
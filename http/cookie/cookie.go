@@ -1,6 +1,9 @@
 package cookie
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 type Cookie struct {
 	Name    string
@@ -82,3 +85,60 @@ const (
 	SameSiteStrict SameSite = "Strict"
 	SameSiteNone   SameSite = "None"
 )
+
+var zoneGMT = time.FixedZone("GMT", 0)
+
+// Append renders the cookie as a Set-Cookie header value.
+func Append(buf []byte, c Cookie) []byte {
+	buf = append(buf, c.Name...)
+	buf = append(buf, '=')
+	buf = append(buf, c.Value...)
+	buf = append(buf, ';', ' ')
+
+	if len(c.Path) > 0 {
+		buf = append(buf, "Path="...)
+		buf = append(buf, c.Path...)
+		buf = append(buf, ';', ' ')
+	}
+
+	if len(c.Domain) > 0 {
+		buf = append(buf, "Domain="...)
+		buf = append(buf, c.Domain...)
+		buf = append(buf, ';', ' ')
+	}
+
+	if !c.Expires.IsZero() {
+		buf = append(buf, "Expires="...)
+		// TODO: this _may_ be slow. We could write it manually instead
+		buf = c.Expires.In(zoneGMT).AppendFormat(buf, time.RFC1123)
+		buf = append(buf, ';', ' ')
+	}
+
+	if c.MaxAge != 0 {
+		maxage := "0"
+		if c.MaxAge > 0 {
+			maxage = strconv.Itoa(c.MaxAge)
+		}
+
+		buf = append(buf, "MaxAge="...)
+		buf = append(buf, maxage...)
+		buf = append(buf, ';', ' ')
+	}
+
+	if len(c.SameSite) > 0 {
+		buf = append(buf, "SameSite="...)
+		buf = append(buf, c.SameSite...)
+		buf = append(buf, ';', ' ')
+	}
+
+	if c.Secure {
+		buf = append(buf, "Secure; "...)
+	}
+
+	if c.HttpOnly {
+		buf = append(buf, "HttpOnly; "...)
+	}
+
+	// strip last 2 bytes, which are always a semicolon and a space
+	return buf[:len(buf)-2]
+}
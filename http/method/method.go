@@ -1,6 +1,11 @@
 package method
 
-//go:generate stringer -type=Method
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 type Method uint8
 
 const (
@@ -22,15 +27,68 @@ const (
 	PROPFIND
 	PROPPATCH
 
-	// Count represents the maximal value an integer representation of a method can have.
-	Count = iota - 1
+	// builtinCount is the number of methods known out of the box.
+	builtinCount = iota - 1
+	// MaxExtensions is the maximal number of extension methods which can be registered.
+	MaxExtensions = 32
+	// Count represents the maximal value an integer representation of a method can have,
+	// including ones reserved for extension methods.
+	Count = builtinCount + MaxExtensions
 )
 
-// List enlists all known request methods, excluding Unknown.
+// List enlists all known request methods, excluding Unknown. Registered extension methods
+// are appended to it.
 var List = []Method{
 	GET, HEAD, POST, PUT, DELETE, CONNECT, OPTIONS, TRACE, PATCH, MKCOL, MOVE, COPY, LOCK, UNLOCK, PROPFIND, PROPPATCH,
 }
 
+var (
+	names = [Count + 1]string{
+		Unknown: "Unknown", GET: "GET", HEAD: "HEAD", POST: "POST", PUT: "PUT", DELETE: "DELETE",
+		CONNECT: "CONNECT", OPTIONS: "OPTIONS", TRACE: "TRACE", PATCH: "PATCH", MKCOL: "MKCOL",
+		MOVE: "MOVE", COPY: "COPY", LOCK: "LOCK", UNLOCK: "UNLOCK", PROPFIND: "PROPFIND",
+		PROPPATCH: "PROPPATCH",
+	}
+	extensions []Method
+)
+
+// Register adds an extension method (e.g. PURGE, REPORT or QUERY), so it's recognized by the
+// parser and can be routed. Registering an already known method just returns it. The registry
+// isn't synchronized, therefore methods must be registered before the server starts, normally
+// as package-level variables:
+//
+//	var PURGE = method.Register("PURGE")
+//
+// Panics if the name isn't a valid token or if more than MaxExtensions methods are registered.
+func Register(name string) Method {
+	if m := Parse(name); m != Unknown {
+		return m
+	}
+
+	if !isToken(name) {
+		panic(fmt.Sprintf("method: invalid method name %q", name))
+	}
+
+	if len(extensions) >= MaxExtensions {
+		panic(fmt.Sprintf("method: cannot register %s: too many extension methods", name))
+	}
+
+	m := Method(builtinCount + 1 + len(extensions))
+	names[m] = name
+	extensions = append(extensions, m)
+	List = append(List, m)
+
+	return m
+}
+
+func (m Method) String() string {
+	if int(m) < len(names) && len(names[m]) > 0 {
+		return names[m]
+	}
+
+	return "Method(" + strconv.Itoa(int(m)) + ")"
+}
+
 func Parse(str string) Method {
 	switch len(str) {
 	case 3:
@@ -81,5 +139,30 @@ func Parse(str string) Method {
 		}
 	}
 
+	for _, m := range extensions {
+		if names[m] == str {
+			return m
+		}
+	}
+
 	return Unknown
 }
+
+// isToken reports whether the name is a valid token (RFC 9110, section 5.6.2). Methods are
+// case-sensitive, so lowercase characters are allowed as well.
+func isToken(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) != -1:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -1,9 +1,11 @@
 package method
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func BenchmarkMethod(b *testing.B) {
@@ -31,3 +33,23 @@ func TestMethod(t *testing.T) {
 		assert.Equal(t, method.String(), Parse(method.String()).String())
 	}
 }
+
+func TestRegister(t *testing.T) {
+	purge := Register("PURGE")
+	require.Equal(t, purge, Parse("PURGE"))
+	require.Equal(t, "PURGE", purge.String())
+	require.Contains(t, List, purge)
+	require.Greater(t, purge, PROPPATCH)
+	require.Equal(t, purge, Register("PURGE"))
+	require.Equal(t, GET, Register("GET"))
+	require.Equal(t, Unknown, Parse("purge"), "methods are case-sensitive")
+
+	require.Panics(t, func() {
+		Register("BAD METHOD")
+	})
+	require.Panics(t, func() {
+		for i := 0; i <= MaxExtensions; i++ {
+			Register("X-EXT-" + strconv.Itoa(i))
+		}
+	})
+}
@@ -9,12 +9,13 @@ const (
 	HTTP10  Protocol = 1 << iota
 	HTTP11
 	HTTP2
+	HTTP3
 
 	HTTP1 = HTTP10 | HTTP11
 )
 
 func (p Protocol) String() string {
-	lut := [...]string{HTTP10: "HTTP/1.0", HTTP11: "HTTP/1.1", HTTP2: "HTTP/2"}
+	lut := [...]string{HTTP10: "HTTP/1.0", HTTP11: "HTTP/1.1", HTTP2: "HTTP/2", HTTP3: "HTTP/3"}
 	if int(p) >= len(lut) {
 		return ""
 	}
@@ -2,7 +2,9 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
+	"strings"
 
 	"github.com/indigo-web/indigo/config"
 	"github.com/indigo-web/indigo/http/cookie"
@@ -27,7 +29,16 @@ type Request struct {
 	// Method is an enum representing the request method.
 	Method method.Method
 	// Path is a decoded and validated string, guaranteed to hold ASCII-printable characters only.
+	// For requests in absolute-form, it holds the path component only.
 	Path string
+	// Target is the raw request-target exactly as it was received, neither decoded nor normalized.
+	Target string
+	// Query is the raw query string without the leading question mark.
+	Query string
+	// Authority is the authority component of the request-target in absolute-form (e.g. for
+	// GET http://example.com/ it's example.com) or authority-form (used by CONNECT). Empty
+	// for the most common origin-form.
+	Authority string
 	// Params are request URI parameters.
 	Params Params
 	// Vars are dynamic routing segments.
@@ -41,6 +52,10 @@ type Request struct {
 	// Remote holds the remote address. Please note that this is generally not a good parameter to identify
 	// a user, because there might be proxies in the middle.
 	Remote net.Addr
+	// TLS holds the state of the TLS connection the request arrived on, including the client
+	// certificate chain (verified, if the client authentication policy requires so), the indicated
+	// server name and the negotiated protocol and cipher suite. Nil for plain connections.
+	TLS *tls.ConnectionState
 	// Ctx is user-managed context which lives as long as the connection does and is never automatically
 	// cleared.
 	Ctx context.Context
@@ -75,6 +90,56 @@ func NewRequest(
 	}
 }
 
+// Host returns the host the request is directed to. It's the authority of the request-target,
+// if presented, as RFC 9112 requires ignoring the Host header in this case. Otherwise, the Host
+// header value is returned.
+func (r *Request) Host() string {
+	if len(r.Authority) > 0 {
+		return r.Authority
+	}
+
+	return r.Headers.Value("host")
+}
+
+// Scheme returns https, if the request arrived over an encrypted connection, and http otherwise.
+func (r *Request) Scheme() string {
+	if r.Env.Encryption != 0 {
+		return "https"
+	}
+
+	return "http"
+}
+
+// RequestURI returns the raw request-target in origin-form, i.e. the path along with the query,
+// as opposed to Target, which might be in absolute-form. If the target is unknown, e.g. the
+// request is constructed manually, Path is returned.
+func (r *Request) RequestURI() string {
+	target := r.Target
+	switch {
+	case len(target) == 0:
+		return r.Path
+	case len(r.Authority) == 0 || r.Method == method.CONNECT:
+		return target
+	}
+
+	_, target, _ = strings.Cut(target, "://")
+	end := strings.IndexAny(target, "/?")
+	if end == -1 {
+		return "/"
+	}
+
+	if target[end] == '?' {
+		return "/" + target[end:]
+	}
+
+	return target[end:]
+}
+
+// URL reconstructs the absolute URL of the request, e.g. https://example.com/index.html?lang=en.
+func (r *Request) URL() string {
+	return r.Scheme() + "://" + r.Host() + r.RequestURI()
+}
+
 // Cookies returns a cookie jar with parsed cookies key-value pairs, and an error
 // if the syntax is malformed. The returned jar should be re-used, as this method
 // doesn't cache the parsed result across calls and may be pretty expensive
@@ -126,9 +191,12 @@ func (r *Request) Reset() {
 	r.Params.Clear()
 	r.Vars.Clear()
 	r.Headers.Clear()
+	// they refer to the parser's buffers, which are reused by the next request
+	r.Target, r.Query, r.Authority = "", "", ""
 	r.commonHeaders = commonHeaders{}
 	r.Ctx = zeroContext
-	r.Env = Environment{}
+	// encryption belongs to the connection rather than to a single request
+	r.Env = Environment{Encryption: r.Env.Encryption}
 }
 
 type Environment struct {
@@ -143,6 +211,24 @@ type Environment struct {
 	// AliasFrom contains the original request path, in case it was replaced via alias
 	// aka implicit redirect
 	AliasFrom string
+	// MountedFrom contains the original request path, in case the request is served by a router
+	// mounted under a prefix, which is stripped from the path then
+	MountedFrom string
+	// Route is the registered pattern of the matched endpoint (e.g. /user/:id), as opposed
+	// to the actual request path. Set by the inbuilt router.
+	Route string
+	// Nonce is a per-request random value, generated by the SecureHeaders middleware in order
+	// to be used in Content-Security-Policy nonce-sources. Empty if the middleware isn't used.
+	Nonce string
+	// CSRFToken holds the token which must be submitted along with any state-changing request.
+	// Populated by the CSRF middleware.
+	CSRFToken string
+	// Principal is the authenticated identity of the client, as returned by the credentials
+	// validator of one of the auth middlewares. Nil if the request isn't authenticated.
+	Principal any
+	// Session holds the session loaded by the sessions middleware. Use sessions.Get in order
+	// to access it in a type-safe manner.
+	Session any
 }
 
 type commonHeaders struct {
@@ -1,6 +1,7 @@
 package serve
 
 import (
+	"crypto/tls"
 	"net"
 
 	"github.com/indigo-web/indigo/config"
@@ -9,21 +10,48 @@ import (
 	"github.com/indigo-web/indigo/internal/construct"
 	"github.com/indigo-web/indigo/internal/protocol/http1"
 	"github.com/indigo-web/indigo/router"
+	"github.com/indigo-web/indigo/transport"
 )
 
-// HTTP1 setups and serves an HTTP/1.1 server until it stops. Note that the connection isn't
-// automatically closed on server stop
+// HTTP1 setups and serves an HTTP/1.1 server until it stops. The TLS state is nil for plain
+// connections. Note that the connection isn't automatically closed on server stop
 func HTTP1(
 	cfg *config.Config,
 	conn net.Conn,
-	enc uint16,
+	state *tls.ConnectionState,
 	r router.Router,
 	codecs codecutil.Cache,
 ) {
 	client := construct.Client(cfg.NET, conn)
 	request := construct.Request(cfg, client)
-	request.Env.Encryption = enc
+	setTLS(request, state)
 	suit := http1.New(cfg, r, client, request, codecs)
 	request.Body = http.NewBody(suit)
 	suit.Serve()
+	suit.Release()
+}
+
+// HTTP1Session sets up an HTTP/1.1 session, which is served incrementally as the data arrives
+// instead of blocking on the connection.
+func HTTP1Session(
+	cfg *config.Config,
+	conn net.Conn,
+	state *tls.ConnectionState,
+	r router.Router,
+	codecs codecutil.Cache,
+) transport.Session {
+	client := construct.Client(cfg.NET, conn)
+	request := construct.Request(cfg, client)
+	setTLS(request, state)
+	suit := http1.New(cfg, r, client, request, codecs)
+	request.Body = http.NewBody(suit)
+
+	return suit
+}
+
+func setTLS(request *http.Request, state *tls.ConnectionState) {
+	if state != nil {
+		request.TLS = state
+		request.Env.Encryption = state.Version
+	}
 }
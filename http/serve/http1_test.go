@@ -0,0 +1,109 @@
+package serve
+
+import (
+	"bufio"
+	"io"
+	"net"
+	stdhttp "net/http"
+	"testing"
+	"time"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/internal/bufpool"
+	"github.com/indigo-web/indigo/internal/codecutil"
+	"github.com/indigo-web/indigo/router/simple"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP1Release(t *testing.T) {
+	r := simple.New(
+		func(request *http.Request) *http.Response {
+			return request.Respond().String("ok")
+		},
+		http.Respond,
+	).Build()
+
+	serve := func(data string) {
+		server, client := net.Pipe()
+		go func() {
+			_, _ = client.Write([]byte(data))
+			// the server's responses must be read, as the pipe isn't buffered
+			go func() { _, _ = io.Copy(io.Discard, client) }()
+			_ = client.Close()
+		}()
+
+		HTTP1(config.Default(), server, nil, r, codecutil.NewCache(nil, ""))
+		_ = server.Close()
+	}
+
+	for name, data := range map[string]string{
+		"complete":             "GET / HTTP/1.1\r\nHost: a\r\n\r\n",
+		"unterminated headers": "GET / HTTP/1.1\r\nHost: a\r\n",
+		"bare request line":    "GET /foo",
+		"pipelined after close": "GET / HTTP/1.1\r\nConnection: close\r\n\r\n" +
+			"GET / HTTP/1.1\r\nHost: a\r\n\r\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			before := bufpool.InUse()
+			serve(data)
+			require.Equal(t, before, bufpool.InUse())
+		})
+	}
+}
+
+func TestHTTP1IdleRelease(t *testing.T) {
+	r := simple.New(
+		func(request *http.Request) *http.Response {
+			return request.Respond().String("ok")
+		},
+		http.Respond,
+	).Build()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	cfg := config.Default()
+	before := bufpool.InUse()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		HTTP1(cfg, conn, nil, r, codecutil.NewCache(nil, ""))
+		_ = conn.Close()
+	}()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	reader := bufio.NewReader(conn)
+
+	roundtrip := func() {
+		_, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: a\r\n\r\n"))
+		require.NoError(t, err)
+		resp, err := stdhttp.ReadResponse(reader, nil)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "ok", string(body))
+	}
+
+	// the buffers are released right after the response is written, so it might take a moment.
+	// Only the read buffer must be held, as the blocking read can't go without it
+	idle := func() bool {
+		return bufpool.InUse() == before+int64(cfg.NET.ReadBufferSize)
+	}
+
+	roundtrip()
+	require.Eventually(t, idle, time.Second, time.Millisecond)
+	roundtrip()
+	require.Eventually(t, idle, time.Second, time.Millisecond)
+
+	require.NoError(t, conn.Close())
+	<-done
+	require.Equal(t, before, bufpool.InUse())
+}
@@ -0,0 +1,102 @@
+// Package http3 serves HTTP/3 over QUIC. It's kept apart from the root package, so the QUIC
+// stack is linked only into applications opting in by importing the package.
+package http3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+
+	"github.com/indigo-web/indigo"
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/codec"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/internal/codecutil"
+	protocol "github.com/indigo-web/indigo/internal/protocol/http3"
+	"github.com/indigo-web/indigo/internal/strutil"
+	"github.com/indigo-web/indigo/router"
+	"github.com/indigo-web/indigo/transport"
+	"github.com/quic-go/quic-go"
+)
+
+// Enable additionally serves HTTP/3 over QUIC on the same port as the transport, but UDP.
+// Responses sent over TCP advertise it via the Alt-Svc header, so clients are able to switch
+// over on subsequent requests. Panics if the transport isn't a TLS one:
+//
+//	app.Listen(":443", http3.Enable(indigo.TLS(indigo.Cert("cert.pem", "key.pem"))))
+//
+// Note that graceful upgrades (see indigo.App.Upgrade) aren't available along with HTTP/3,
+// as QUIC listeners cannot be handed off.
+func Enable(t indigo.Transport) indigo.Transport {
+	inner, ok := t.Inner().(*transport.TLS)
+	if !ok {
+		panic("HTTP/3 is supported only along with TLS transports")
+	}
+
+	// the value is known only as the transport is bound to an address
+	altSvc := new(string)
+
+	return t.
+		Companion(func(addr string) []indigo.Transport {
+			_, port, _ := net.SplitHostPort(addr)
+			*altSvc = `h3=":` + port + `"; ma=86400`
+
+			return []indigo.Transport{quicTransport(inner.Config())}
+		}).
+		WrapRouter(func(r router.Router) router.Router {
+			return altSvcRouter{Router: r, value: *altSvc}
+		})
+}
+
+func quicTransport(tlsCfg *tls.Config) indigo.Transport {
+	tlsCfg = tlsCfg.Clone()
+	tlsCfg.NextProtos = []string{"h3"}
+	inner := NewQUIC(tlsCfg)
+
+	return indigo.NewTransport(inner, func(cfg *config.Config, r router.Router, c []codec.Codec) func(net.Conn) {
+		acceptString := codecutil.AcceptEncoding(c)
+		inner.Handle(func(ctx context.Context, conn *quic.Conn) {
+			protocol.Serve(ctx, cfg, conn, r, c, acceptString)
+		})
+
+		// connections are served via the handler instead
+		return nil
+	})
+}
+
+// altSvcRouter advertises the HTTP/3 endpoint in every response, unless the handler has set
+// the Alt-Svc header on its own.
+type altSvcRouter struct {
+	router.Router
+	value string
+}
+
+func (a altSvcRouter) OnRequest(request *http.Request) *http.Response {
+	return a.advertise(request, a.Router.OnRequest(request))
+}
+
+func (a altSvcRouter) OnError(request *http.Request, err error) *http.Response {
+	resp := a.Router.OnError(request, err)
+	if errors.Is(err, status.ErrCloseConnection) {
+		// nothing is going to be sent
+		return resp
+	}
+
+	return a.advertise(request, resp)
+}
+
+func (a altSvcRouter) advertise(request *http.Request, resp *http.Response) *http.Response {
+	if resp == nil {
+		resp = http.Respond(request)
+	}
+
+	for _, header := range resp.Expose().Headers {
+		if strutil.CmpFoldFast(header.Key, "Alt-Svc") {
+			return resp
+		}
+	}
+
+	return resp.Header("Alt-Svc", a.value)
+}
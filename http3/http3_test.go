@@ -0,0 +1,157 @@
+package http3
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	stdhttp "net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/indigo-web/indigo"
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/cookie"
+	"github.com/indigo-web/indigo/http/method"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/internal/construct"
+	"github.com/indigo-web/indigo/router/inbuilt"
+	"github.com/indigo-web/indigo/transport"
+	"github.com/indigo-web/indigo/transport/dummy"
+	h3client "github.com/quic-go/quic-go/http3"
+	"github.com/stretchr/testify/require"
+)
+
+func readFullBody(t *testing.T, resp *stdhttp.Response) string {
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	return string(body)
+}
+
+func TestHTTP3(t *testing.T) {
+	const h3Addr = "localhost:16290"
+
+	app := indigo.New("").Listen(h3Addr, Enable(indigo.TLS(indigo.LocalCert(t.TempDir()))))
+	go func(app *indigo.App) {
+		r := inbuilt.New().
+			Get("/", func(request *http.Request) *http.Response {
+				return http.String(request, fmt.Sprintf(
+					"%s %s %s %d", request.Protocol, request.Headers.Value("host"),
+					request.Headers.Value("cookie"), request.Env.Encryption,
+				)).Cookie(cookie.New("hello", "world"))
+			}).
+			Post("/", func(request *http.Request) *http.Response {
+				body, err := request.Body.String()
+				if err != nil {
+					return http.Error(request, err)
+				}
+
+				return http.String(request, strings.ToUpper(body))
+			})
+
+		_ = app.Serve(r)
+	}(app)
+	defer app.Stop()
+
+	tr := &stdhttp.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	defer tr.CloseIdleConnections()
+	h3 := &h3client.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	defer h3.Close()
+	client := &stdhttp.Client{Transport: h3}
+
+	t.Run("alt-svc", func(t *testing.T) {
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			resp, err := (&stdhttp.Client{Transport: tr}).Get("https://" + h3Addr + "/")
+			if err != nil {
+				require.True(t, time.Now().Before(deadline), err)
+				time.Sleep(50 * time.Millisecond)
+				continue
+			}
+
+			require.Equal(t, `h3=":16290"; ma=86400`, resp.Header.Get("Alt-Svc"))
+			_ = readFullBody(t, resp)
+			break
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		request, err := stdhttp.NewRequest(stdhttp.MethodGet, "https://"+h3Addr+"/", nil)
+		require.NoError(t, err)
+		request.AddCookie(&stdhttp.Cookie{Name: "a", Value: "b"})
+		request.AddCookie(&stdhttp.Cookie{Name: "c", Value: "d"})
+		resp, err := client.Do(request)
+		require.NoError(t, err)
+		require.Equal(t, stdhttp.StatusOK, resp.StatusCode)
+		require.Equal(t, 3, resp.ProtoMajor)
+		want := fmt.Sprintf("HTTP/3 %s a=b; c=d %d", h3Addr, tls.VersionTLS13)
+		require.Equal(t, want, readFullBody(t, resp))
+		require.Equal(t, "hello=world", resp.Header.Get("Set-Cookie"))
+		require.Empty(t, resp.Header.Get("Alt-Svc"))
+	})
+
+	t.Run("post", func(t *testing.T) {
+		resp, err := client.Post("https://"+h3Addr+"/", "text/plain", strings.NewReader("hello, world"))
+		require.NoError(t, err)
+		require.Equal(t, stdhttp.StatusOK, resp.StatusCode)
+		require.Equal(t, "HELLO, WORLD", readFullBody(t, resp))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		resp, err := client.Get("https://" + h3Addr + "/nonexistent")
+		require.NoError(t, err)
+		require.Equal(t, stdhttp.StatusNotFound, resp.StatusCode)
+		_ = readFullBody(t, resp)
+	})
+
+	t.Run("upgrade", func(t *testing.T) {
+		require.ErrorIs(t, app.Upgrade(), transport.ErrHandoffUnsupported)
+	})
+}
+
+func TestAltSvc(t *testing.T) {
+	r := inbuilt.New().
+		Get("/", http.Respond).
+		Get("/custom", func(request *http.Request) *http.Response {
+			return http.Respond(request).Header("alt-svc", "clear")
+		})
+	a := altSvcRouter{Router: r.Build(), value: `h3=":443"; ma=86400`}
+
+	altSvc := func(resp *http.Response) (values []string) {
+		for _, header := range resp.Expose().Headers {
+			if strings.EqualFold(header.Key, "Alt-Svc") {
+				values = append(values, header.Value)
+			}
+		}
+
+		return values
+	}
+
+	newRequest := func(path string) *http.Request {
+		request := construct.Request(config.Default(), dummy.NewNopClient())
+		request.Method = method.GET
+		request.Path = path
+		return request
+	}
+
+	t.Run("advertise", func(t *testing.T) {
+		resp := a.OnRequest(newRequest("/"))
+		require.Equal(t, []string{`h3=":443"; ma=86400`}, altSvc(resp))
+
+		resp = a.OnError(newRequest("/"), status.ErrBadRequest)
+		require.Equal(t, []string{`h3=":443"; ma=86400`}, altSvc(resp))
+	})
+
+	t.Run("set by handler", func(t *testing.T) {
+		resp := a.OnRequest(newRequest("/custom"))
+		require.Equal(t, []string{"clear"}, altSvc(resp))
+	})
+
+	t.Run("close connection", func(t *testing.T) {
+		resp := a.OnError(newRequest("/"), status.ErrCloseConnection)
+		require.Empty(t, altSvc(resp))
+	})
+}
@@ -0,0 +1,126 @@
+package http3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/transport"
+	"github.com/quic-go/quic-go"
+)
+
+// QUICHandler serves a single QUIC connection. The context is done as soon as the transport
+// stops, so the handler must finish the work in progress and return.
+type QUICHandler func(ctx context.Context, conn *quic.Conn)
+
+var _ transport.Transport = new(QUIC)
+
+// QUIC is a transport accepting QUIC connections over UDP. As QUIC connections aren't streams,
+// they're served by the handler set via Handle instead of the callback passed to Listen.
+//
+// The transport doesn't support listener handoff, as the state of QUIC connections lives in the
+// process, while packets of all of them arrive on the same socket. Therefore, App.Upgrade fails
+// with transport.ErrHandoffUnsupported as long as the transport is bound.
+type QUIC struct {
+	tlsCfg  *tls.Config
+	handler QUICHandler
+	udp     net.PacketConn
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      *sync.WaitGroup
+}
+
+// NewQUIC returns a new QUIC transport. The TLS configuration must set the application protocols
+// in NextProtos, as the ALPN is mandatory for QUIC.
+func NewQUIC(cfg *tls.Config) *QUIC {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &QUIC{
+		tlsCfg: cfg,
+		ctx:    ctx,
+		cancel: cancel,
+		wg:     new(sync.WaitGroup),
+	}
+}
+
+// Handle sets the handler of new connections. Must be called before Listen.
+func (q *QUIC) Handle(handler QUICHandler) {
+	q.handler = handler
+}
+
+func (q *QUIC) Bind(addr string) error {
+	udp, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	q.udp = udp
+	// Listen is guaranteed to follow a successful Bind, therefore Wait will be able
+	// to wait for it even if stopped right away
+	q.wg.Add(1)
+
+	return nil
+}
+
+func (q *QUIC) Listen(cfg config.NET, _ func(conn net.Conn)) error {
+	defer q.wg.Done()
+
+	if q.handler == nil {
+		return errors.New("quic: no connection handler set")
+	}
+
+	l, err := quic.Listen(q.udp, q.tlsCfg, &quic.Config{
+		MaxIdleTimeout: cfg.ReadTimeout,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer l.Close()
+
+	admit := transport.NewAdmission(cfg.Connections)
+
+	for {
+		conn, err := l.Accept(q.ctx)
+		if err != nil {
+			if q.ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		q.wg.Add(1)
+		go q.serve(admit, conn)
+	}
+}
+
+func (q *QUIC) serve(admit transport.Admission, conn *quic.Conn) {
+	defer q.wg.Done()
+
+	release, err := admit.Admit(conn.RemoteAddr())
+	if err != nil {
+		admit.Notify(conn.RemoteAddr(), err)
+		_ = conn.CloseWithError(0, err.Error())
+		return
+	}
+
+	q.handler(q.ctx, conn)
+	_ = conn.CloseWithError(0, "")
+	release()
+}
+
+func (q *QUIC) Stop() {
+	q.cancel()
+}
+
+func (q *QUIC) Close() {
+	_ = q.udp.Close()
+}
+
+func (q *QUIC) Wait() {
+	q.wg.Wait()
+}
@@ -18,13 +18,16 @@ const Version = "0.17.3"
 type App struct {
 	cfg   *config.Config
 	hooks struct {
-		OnStart func()
-		OnBind  func(addr string)
-		OnStop  func()
+		OnStart   func()
+		OnBind    func(addr string)
+		OnStop    func()
+		OnUpgrade func(err error)
 	}
 	codecs     []codec.Codec
 	transports []Transport
 	supervisor transport.Supervisor
+	upgrade    upgrader
+	router     swappable
 }
 
 // New returns a new App instance.
@@ -85,6 +88,18 @@ func (a *App) Listen(addr string, ts ...Transport) *App {
 	for _, t := range ts {
 		t.addr = addr
 		a.transports = append(a.transports, t)
+
+		if t.companions == nil {
+			continue
+		}
+
+		for _, companion := range t.companions(addr) {
+			if len(companion.addr) == 0 {
+				companion.addr = addr
+			}
+
+			a.transports = append(a.transports, companion)
+		}
 	}
 
 	return a
@@ -99,13 +114,21 @@ func (a *App) TLS(addr string, certs ...tls.Certificate) *App {
 }
 
 // Serve starts the web-application. If nil is passed instead of a router, empty inbuilt will
-// be used.
+// be used. The config is validated beforehand, so the application won't start with an
+// inconsistent one. The router can be replaced while serving via Swap.
 func (a *App) Serve(r router.Builder) error {
+	if err := a.cfg.Validate(); err != nil {
+		return err
+	}
+
 	if r == nil {
 		r = inbuilt.New()
 	}
 
-	return a.run(r.Build())
+	built := r.Build()
+	a.router.current.Store(&built)
+
+	return a.run(&a.router)
 }
 
 func (a *App) run(r router.Router) error {
@@ -123,7 +146,13 @@ func (a *App) run(r router.Router) error {
 		}
 	}
 
+	// if we were spawned by a parent process performing a graceful upgrade, it's waiting
+	// for us to adopt the listeners
+	_ = transport.Ready()
+	stopSignals := a.upgrade.Notify(a)
+
 	err := a.supervisor.Run(a.cfg.NET)
+	stopSignals()
 	if a.hooks.OnStop != nil {
 		a.hooks.OnStop()
 	}
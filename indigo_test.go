@@ -1,17 +1,26 @@
 package indigo
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"fmt"
 	"io"
 	"iter"
+	"math/big"
 	"net"
 	stdhttp "net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"runtime"
 	"slices"
 	"strconv"
 	"strings"
@@ -29,8 +38,10 @@ import (
 	"github.com/indigo-web/indigo/internal/httptest/parse"
 	"github.com/indigo-web/indigo/internal/httptest/serialize"
 	"github.com/indigo-web/indigo/kv"
+	"github.com/indigo-web/indigo/router"
 	"github.com/indigo-web/indigo/router/inbuilt"
 	"github.com/indigo-web/indigo/router/inbuilt/middleware"
+	"github.com/indigo-web/indigo/transport"
 	"github.com/klauspost/compress/gzip"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -446,8 +457,7 @@ func TestFirstPhase(t *testing.T) {
 		require.Equal(t, int(status.MethodNotAllowed), resp.StatusCode)
 
 		require.Contains(t, resp.Header, "Allow")
-		allow := resp.Header["Allow"][0]
-		require.True(t, allow == "GET,POST" || allow == "POST,GET")
+		require.Equal(t, "GET, HEAD, POST", resp.Header["Allow"][0])
 		require.Equal(t, 1, len(resp.Header["Allow"]))
 	})
 
@@ -876,6 +886,103 @@ func TestEscaping(t *testing.T) {
 	t.Run("dynamic", runTest(true))
 }
 
+func TestUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "indigo.sock")
+	app := New("").Listen(path, Unix())
+	go func(app *App) {
+		r := inbuilt.New().Get("/", func(request *http.Request) *http.Response {
+			return http.String(request, "hello over unix")
+		})
+
+		_ = app.Serve(r)
+	}(app)
+	defer app.Stop()
+
+	client := stdhttp.Client{
+		Transport: &stdhttp.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return new(net.Dialer).DialContext(ctx, "unix", path)
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err := client.Get("http://unix/")
+		if err != nil {
+			require.True(t, time.Now().Before(deadline), err)
+			time.Sleep(50 * time.Millisecond)
+			continue
+		}
+
+		require.Equal(t, stdhttp.StatusOK, resp.StatusCode)
+		require.Equal(t, "hello over unix", readFullBody(t, resp))
+		break
+	}
+}
+
+func TestEpoll(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("epoll is available only on Linux")
+	}
+
+	const epollAddr = "localhost:16180"
+
+	app := New("").Listen(epollAddr, Epoll(transport.EpollParams{Workers: 2}))
+	go func(app *App) {
+		r := inbuilt.New().
+			Get("/", func(request *http.Request) *http.Response {
+				return http.String(request, "hello from epoll")
+			}).
+			Post("/echo", func(request *http.Request) *http.Response {
+				body, err := request.Body.String()
+				if err != nil {
+					return http.Error(request, err)
+				}
+
+				return http.String(request, body)
+			})
+
+		_ = app.Serve(r)
+	}(app)
+	defer app.Stop()
+
+	waitForAvailability(t, epollAddr)
+
+	t.Run("keep-alive", func(t *testing.T) {
+		client := stdhttp.Client{Transport: new(stdhttp.Transport)}
+		defer client.CloseIdleConnections()
+
+		for range 5 {
+			resp, err := client.Get("http://" + epollAddr + "/")
+			require.NoError(t, err)
+			require.Equal(t, "hello from epoll", readFullBody(t, resp))
+
+			resp, err = client.Post("http://"+epollAddr+"/echo", "text/plain", strings.NewReader("ping"))
+			require.NoError(t, err)
+			require.Equal(t, "ping", readFullBody(t, resp))
+		}
+	})
+
+	t.Run("pipelined", func(t *testing.T) {
+		conn, err := net.Dial("tcp", epollAddr)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		const request = "POST /echo HTTP/1.1\r\nContent-Length: 4\r\n\r\npong"
+		_, err = conn.Write([]byte(request + request + "GET / HTTP/1.1\r\nConnection: close\r\n\r\n"))
+		require.NoError(t, err)
+
+		reader := bufio.NewReader(conn)
+		for _, want := range []string{"pong", "pong", "hello from epoll"} {
+			resp, err := stdhttp.ReadResponse(reader, nil)
+			require.NoError(t, err)
+			require.Equal(t, want, readFullBody(t, resp))
+		}
+	})
+}
+
 func waitForAvailability(t *testing.T, addrs ...string) {
 	for _, addr := range addrs {
 		deadline := time.Now().Add(2 * time.Second)
@@ -945,3 +1052,223 @@ func (c *circularReader) Read(b []byte) (int, error) {
 func (c *circularReader) Close() error {
 	return nil
 }
+
+func TestMutualTLS(t *testing.T) {
+	const mtlsAddr = "localhost:16190"
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "alice"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+
+	app := New("").Listen(mtlsAddr, TLS(LocalCert(t.TempDir())).ClientAuth(transport.ClientAuth{
+		Policy: tls.RequireAndVerifyClientCert,
+		CAs:    pool,
+	}))
+	go func(app *App) {
+		r := inbuilt.New().Get("/", func(request *http.Request) *http.Response {
+			state := request.TLS
+			return http.String(request, fmt.Sprintf(
+				"%s %s %d", state.VerifiedChains[0][0].Subject.CommonName,
+				state.ServerName, request.Env.Encryption,
+			))
+		})
+
+		_ = app.Serve(r)
+	}(app)
+	defer app.Stop()
+
+	dial := func(certs ...tls.Certificate) (*stdhttp.Client, func()) {
+		tr := &stdhttp.Transport{TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			ServerName:         "localhost",
+			Certificates:       certs,
+		}}
+
+		return &stdhttp.Client{Transport: tr}, tr.CloseIdleConnections
+	}
+
+	client, closeIdle := dial(tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf})
+	defer closeIdle()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err := client.Get("https://" + mtlsAddr + "/")
+		if err != nil {
+			require.True(t, time.Now().Before(deadline), err)
+			time.Sleep(50 * time.Millisecond)
+			continue
+		}
+
+		require.Equal(t, stdhttp.StatusOK, resp.StatusCode)
+		want := fmt.Sprintf("alice localhost %d", tls.VersionTLS13)
+		require.Equal(t, want, readFullBody(t, resp))
+		// the second request over the same connection must keep the state
+		resp, err = client.Get("https://" + mtlsAddr + "/")
+		require.NoError(t, err)
+		require.Equal(t, want, readFullBody(t, resp))
+		break
+	}
+
+	anonymous, closeAnonymous := dial()
+	defer closeAnonymous()
+	_, err = anonymous.Get("https://" + mtlsAddr + "/")
+	require.Error(t, err)
+}
+
+func TestAutocertCompanion(t *testing.T) {
+	const (
+		acmeHTTPSAddr = "localhost:16283"
+		acmeHTTPAddr  = "localhost:16284"
+		stubHTTPAddr  = "localhost:16285"
+	)
+
+	app := New("").Listen(acmeHTTPSAddr, AutocertWith(AutocertParams{
+		Domains:      []string{"localhost"},
+		Cache:        t.TempDir(),
+		DirectoryURL: "https://127.0.0.1:14000/dir",
+		HTTPAddr:     acmeHTTPAddr,
+	}))
+	go func(app *App) {
+		_ = app.Serve(nil)
+	}(app)
+	defer app.Stop()
+
+	// the challenge handler is stubbed, as the way autocert stores tokens is its own business
+	challenges := make(chan *stdhttp.Request, 1)
+	stub := New("").Listen(stubHTTPAddr, acmeCompanion(stubHTTPAddr, acmeHTTPSAddr, stdhttp.HandlerFunc(
+		func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			challenges <- r
+			if r.URL.Path != acmeChallengePrefix+"token" {
+				stdhttp.NotFound(w, r)
+				return
+			}
+
+			_, _ = io.WriteString(w, "token.thumbprint")
+		},
+	)))
+	go func(app *App) {
+		_ = app.Serve(nil)
+	}(stub)
+	defer stub.Stop()
+
+	tr := new(stdhttp.Transport)
+	defer tr.CloseIdleConnections()
+	client := &stdhttp.Client{
+		Transport: tr,
+		CheckRedirect: func(*stdhttp.Request, []*stdhttp.Request) error {
+			return stdhttp.ErrUseLastResponse
+		},
+	}
+
+	get := func(t *testing.T, addr, path string) *stdhttp.Response {
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			resp, err := client.Get("http://" + addr + path)
+			if err == nil {
+				return resp
+			}
+
+			require.True(t, time.Now().Before(deadline), err)
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	t.Run("challenge", func(t *testing.T) {
+		resp := get(t, stubHTTPAddr, "/.well-known/acme-challenge/token")
+		require.Equal(t, stdhttp.StatusOK, resp.StatusCode)
+		require.Equal(t, "token.thumbprint", readFullBody(t, resp))
+		r := <-challenges
+		require.Equal(t, stdhttp.MethodGet, r.Method)
+		require.Equal(t, "localhost", r.Host)
+
+		resp = get(t, stubHTTPAddr, "/.well-known/acme-challenge/unknown")
+		require.Equal(t, stdhttp.StatusNotFound, resp.StatusCode)
+		_ = readFullBody(t, resp)
+		<-challenges
+	})
+
+	t.Run("autocert", func(t *testing.T) {
+		// no challenge is pending, so the autocert handler must refuse the token
+		resp := get(t, acmeHTTPAddr, "/.well-known/acme-challenge/token")
+		require.Equal(t, stdhttp.StatusNotFound, resp.StatusCode)
+		_ = readFullBody(t, resp)
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		resp := get(t, acmeHTTPAddr, "/hello")
+		require.Equal(t, stdhttp.StatusPermanentRedirect, resp.StatusCode)
+		require.Equal(t, "https://localhost:16283/hello", resp.Header.Get("Location"))
+		_ = readFullBody(t, resp)
+	})
+}
+
+func TestServeInvalidConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.NET.ReadBufferSize = 0
+
+	err := New("").Tune(cfg).Serve(nil)
+	require.EqualError(t, err, "NET.ReadBufferSize: must be positive, got 0")
+}
+
+type panickingBuilder struct{}
+
+func (panickingBuilder) Build() router.Router {
+	panic("bad route")
+}
+
+func TestSwap(t *testing.T) {
+	const swapAddr = "localhost:16195"
+
+	respond := func(body string) router.Builder {
+		return inbuilt.New().Get("/", func(request *http.Request) *http.Response {
+			return http.String(request, body)
+		})
+	}
+
+	app := New(swapAddr)
+	require.ErrorIs(t, app.Swap(respond("too early")), ErrNotServing)
+
+	go func(app *App) {
+		_ = app.Serve(respond("first"))
+	}(app)
+	defer app.Stop()
+
+	var client stdhttp.Client
+	defer client.CloseIdleConnections()
+
+	get := func() string {
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			resp, err := client.Get("http://" + swapAddr + "/")
+			if err != nil {
+				require.True(t, time.Now().Before(deadline), err)
+				time.Sleep(50 * time.Millisecond)
+				continue
+			}
+
+			require.Equal(t, stdhttp.StatusOK, resp.StatusCode)
+			return readFullBody(t, resp)
+		}
+	}
+
+	require.Equal(t, "first", get())
+	require.NoError(t, app.Swap(respond("second")))
+	require.Equal(t, "second", get())
+
+	err := app.Swap(panickingBuilder{})
+	require.ErrorIs(t, err, ErrBuildPanicked)
+	require.ErrorContains(t, err, "bad route")
+	require.Equal(t, "second", get())
+}
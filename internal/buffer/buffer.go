@@ -1,17 +1,25 @@
 package buffer
 
+import "github.com/indigo-web/indigo/internal/bufpool"
+
 // Buffer is a giant slice of data you write into it. Serves primarily the purpose of a quasi-arena
 // by hosting non-interrelated byte sequences in a single place. Allows writing byte sequences streamingly.
+//
+// The memory is acquired from the bufpool. As previous segments might still be referenced when
+// the buffer grows, outgrown memory is retained until the buffer is cleared.
 type Buffer struct {
-	memory  []byte
-	begin   int
-	maxSize int
+	memory      []byte
+	retired     [][]byte
+	begin       int
+	initialSize int
+	maxSize     int
 }
 
 func New(initialSize, maxSize int) *Buffer {
 	return &Buffer{
-		memory:  make([]byte, 0, initialSize),
-		maxSize: maxSize,
+		memory:      bufpool.Get(initialSize),
+		initialSize: initialSize,
+		maxSize:     maxSize,
 	}
 }
 
@@ -27,6 +35,7 @@ func (b *Buffer) Append(elements []byte) (ok bool) {
 		return false
 	}
 
+	b.grow(len(elements))
 	b.memory = append(b.memory, elements...)
 	return true
 }
@@ -37,10 +46,25 @@ func (b *Buffer) AppendByte(c byte) (ok bool) {
 		return false
 	}
 
+	b.grow(1)
 	b.memory = append(b.memory, c)
 	return true
 }
 
+func (b *Buffer) grow(n int) {
+	if cap(b.memory)-len(b.memory) >= n {
+		return
+	}
+
+	grown := bufpool.Get(max(2*cap(b.memory), len(b.memory)+n, b.initialSize))
+	grown = append(grown, b.memory...)
+	if cap(b.memory) > 0 {
+		b.retired = append(b.retired, b.memory)
+	}
+
+	b.memory = grown
+}
+
 // SegmentLength returns a number of bytes, taken by current segment, calculated as a difference
 // between the beginning of the current segment and the current pointer.
 func (b *Buffer) SegmentLength() int {
@@ -85,8 +109,33 @@ func (b *Buffer) Len() int {
 	return len(b.memory)
 }
 
-// Clear just resets the pointers, so old values may be overridden by new ones.
+// Clear just resets the pointers, so old values may be overridden by new ones. Memory grown
+// beyond the initial size is returned, so a single outlier doesn't pin it forever.
 func (b *Buffer) Clear() {
 	b.begin = 0
 	b.memory = b.memory[:0]
+	b.recycle()
+
+	if cap(b.memory) > b.initialSize {
+		bufpool.Put(b.memory)
+		b.memory = bufpool.Get(b.initialSize)
+	}
+}
+
+// Release clears the buffer and returns all its memory. The buffer stays usable, acquiring
+// the memory again on demand.
+func (b *Buffer) Release() {
+	b.begin = 0
+	b.recycle()
+	bufpool.Put(b.memory)
+	b.memory = nil
+}
+
+func (b *Buffer) recycle() {
+	for i, mem := range b.retired {
+		bufpool.Put(mem)
+		b.retired[i] = nil
+	}
+
+	b.retired = b.retired[:0]
 }
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/indigo-web/indigo/internal/bufpool"
 	"github.com/stretchr/testify/require"
 )
 
@@ -104,6 +105,32 @@ func TestBuffer(t *testing.T) {
 		require.False(t, buff.AppendByte('a'))
 		require.Equal(t, "aaaaa", string(buff.Finish()))
 	})
+
+	t.Run("shrink after outlier", func(t *testing.T) {
+		buff := New(512, 4096)
+		bigString := strings.Repeat("a", 2048)
+		require.True(t, buff.Append([]byte("Hello")))
+		segment := buff.Finish()
+		pushSegment(t, buff, bigString)
+		require.Equal(t, "Hello", string(segment), "previous segments must stay intact")
+		require.Len(t, buff.retired, 1)
+
+		buff.Clear()
+		require.Empty(t, buff.retired)
+		require.Equal(t, 512, cap(buff.memory))
+	})
+
+	t.Run("release", func(t *testing.T) {
+		before := bufpool.InUse()
+		buff := New(512, 4096)
+		pushSegment(t, buff, strings.Repeat("a", 1024))
+		buff.Release()
+		require.Equal(t, before, bufpool.InUse())
+
+		pushSegment(t, buff, "Hello")
+		buff.Clear()
+		require.Equal(t, before+512, bufpool.InUse())
+	})
 }
 
 func testDiscard(t *testing.T, n int) {
@@ -0,0 +1,97 @@
+// Package bufpool provides process-wide size-classed pools of byte slices, along with the
+// accounting of memory held by slices acquired from them.
+package bufpool
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// minClass is the smallest pooled capacity, 512 bytes. Smaller requests are rounded up.
+	minClass = 9
+	// maxClass is the biggest pooled capacity, 16 megabytes. Bigger slices are allocated
+	// and accounted, but never pooled.
+	maxClass = 24
+)
+
+var (
+	pools [maxClass - minClass + 1]sync.Pool
+	// holders recycles pointers used to store slices in pools, because storing slices
+	// directly would allocate on every Put.
+	holders sync.Pool
+	inUse   atomic.Int64
+)
+
+// Get returns an empty slice with the capacity of at least size bytes.
+func Get(size int) []byte {
+	class := classOf(size)
+	if class > maxClass {
+		inUse.Add(int64(size))
+		return make([]byte, 0, size)
+	}
+
+	var b []byte
+	if holder, ok := pools[class-minClass].Get().(*[]byte); ok {
+		b = *holder
+		*holder = nil
+		holders.Put(holder)
+	} else {
+		b = make([]byte, 0, 1<<class)
+	}
+
+	inUse.Add(int64(cap(b)))
+
+	return b[:0]
+}
+
+// Put returns the slice into the pool. Neither the slice nor any of its sub-slices may be
+// used afterward.
+func Put(b []byte) {
+	if cap(b) == 0 {
+		return
+	}
+
+	inUse.Add(-int64(cap(b)))
+
+	class := bits.Len(uint(cap(b))) - 1
+	if cap(b) != 1<<class || class < minClass || class > maxClass {
+		// not ours, let GC do its job
+		return
+	}
+
+	holder, ok := holders.Get().(*[]byte)
+	if !ok {
+		holder = new([]byte)
+	}
+
+	*holder = b[:0]
+	pools[class-minClass].Put(holder)
+}
+
+// Grow returns a slice with the same content and the capacity for at least n more bytes. If
+// the slice is grown, the old one is returned into the pool.
+func Grow(b []byte, n int) []byte {
+	if cap(b)-len(b) >= n {
+		return b
+	}
+
+	grown := append(Get(max(2*cap(b), len(b)+n)), b...)
+	Put(b)
+
+	return grown
+}
+
+// InUse returns the total capacity of slices acquired and not yet returned.
+func InUse() int64 {
+	return inUse.Load()
+}
+
+func classOf(size int) int {
+	if size <= 1<<minClass {
+		return minClass
+	}
+
+	return bits.Len(uint(size - 1))
+}
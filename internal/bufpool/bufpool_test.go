@@ -0,0 +1,48 @@
+package bufpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBufpool(t *testing.T) {
+	t.Run("size classes", func(t *testing.T) {
+		for size, want := range map[int]int{
+			0:    512,
+			1:    512,
+			512:  512,
+			513:  1024,
+			4096: 4096,
+			4097: 8192,
+		} {
+			b := Get(size)
+			require.Empty(t, b)
+			require.Equal(t, want, cap(b), size)
+			Put(b)
+		}
+	})
+
+	t.Run("accounting", func(t *testing.T) {
+		before := InUse()
+		a, b := Get(1000), Get(1<<maxClass+1)
+		require.Equal(t, before+1024+1<<maxClass+1, InUse())
+		Put(a)
+		Put(b)
+		require.Equal(t, before, InUse())
+	})
+
+	t.Run("grow", func(t *testing.T) {
+		before := InUse()
+		b := append(Get(512), "hello"...)
+		b = Grow(b, 510)
+		require.Equal(t, "hello", string(b))
+		require.Equal(t, 1024, cap(b))
+		require.Equal(t, before+1024, InUse())
+
+		same := Grow(b, 10)
+		require.Equal(t, cap(b), cap(same))
+		Put(same)
+		require.Equal(t, before, InUse())
+	})
+}
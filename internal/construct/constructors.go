@@ -6,6 +6,7 @@ import (
 	"github.com/indigo-web/indigo/config"
 	"github.com/indigo-web/indigo/http"
 	"github.com/indigo-web/indigo/internal/buffer"
+	"github.com/indigo-web/indigo/internal/bufpool"
 	"github.com/indigo-web/indigo/kv"
 	"github.com/indigo-web/indigo/transport"
 )
@@ -20,12 +21,17 @@ func Request(cfg *config.Config, client transport.Client) *http.Request {
 }
 
 func Client(cfg config.NET, conn net.Conn) transport.Client {
-	readBuff := make([]byte, cfg.ReadBufferSize)
+	readBuff := bufpool.Get(cfg.ReadBufferSize)[:cfg.ReadBufferSize]
 
 	return transport.NewClient(conn, cfg.ReadTimeout, readBuff)
 }
 
-func Buffers(s *config.Config) (statusBuff, headersBuff *buffer.Buffer) {
-	return buffer.New(s.URI.RequestLineSize.Default, s.URI.RequestLineSize.Maximal),
+// Buffers returns the buffers for the decoded request line, the raw request-target and the
+// headers respectively. As percent-encoding triples the size at most, the raw request-target
+// is given thrice the space of the request line, so it normally doesn't overflow first.
+func Buffers(s *config.Config) (statusBuff, targetBuff, headersBuff *buffer.Buffer) {
+	lineSize := s.URI.RequestLineSize
+	return buffer.New(lineSize.Default, lineSize.Maximal),
+		buffer.New(lineSize.Default, 3*lineSize.Maximal),
 		buffer.New(s.Headers.Space.Default, s.Headers.Space.Maximal)
 }
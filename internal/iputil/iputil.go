@@ -0,0 +1,76 @@
+package iputil
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// ParsePrefix parses either a CIDR range or a single address, which is then represented as a
+// range containing just itself. IPv4-mapped IPv6 addresses are unmapped.
+func ParsePrefix(str string) (netip.Prefix, error) {
+	if strings.IndexByte(str, '/') != -1 {
+		prefix, err := netip.ParsePrefix(str)
+		if err != nil {
+			return prefix, err
+		}
+
+		if prefix.Addr().Is4In6() {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(str)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ParsePrefixes parses each of the passed strings via ParsePrefix.
+func ParsePrefixes(strs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, len(strs))
+
+	for i, str := range strs {
+		prefix, err := ParsePrefix(str)
+		if err != nil {
+			return nil, err
+		}
+
+		prefixes[i] = prefix
+	}
+
+	return prefixes, nil
+}
+
+// Contains reports whether the address falls into any of the prefixes.
+func Contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FromAddr extracts an IP address from the network address, if possible.
+func FromAddr(addr net.Addr) (netip.Addr, bool) {
+	switch a := addr.(type) {
+	case nil:
+		return netip.Addr{}, false
+	case *net.TCPAddr:
+		ip, ok := netip.AddrFromSlice(a.IP)
+		return ip.Unmap(), ok
+	case *net.UDPAddr:
+		ip, ok := netip.AddrFromSlice(a.IP)
+		return ip.Unmap(), ok
+	default:
+		addrport, err := netip.ParseAddrPort(addr.String())
+		return addrport.Addr().Unmap(), err == nil
+	}
+}
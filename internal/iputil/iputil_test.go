@@ -0,0 +1,37 @@
+package iputil
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrefix(t *testing.T) {
+	for _, tc := range []struct {
+		In, Want string
+	}{
+		{"10.0.0.1", "10.0.0.1/32"},
+		{"10.1.2.3/8", "10.0.0.0/8"},
+		{"::1", "::1/128"},
+		{"::ffff:192.168.0.1", "192.168.0.1/32"},
+		{"::ffff:192.168.0.0/112", "192.168.0.0/16"},
+	} {
+		prefix, err := ParsePrefix(tc.In)
+		require.NoError(t, err, tc.In)
+		require.Equal(t, tc.Want, prefix.String())
+	}
+
+	_, err := ParsePrefix("localhost")
+	require.Error(t, err)
+}
+
+func TestFromAddr(t *testing.T) {
+	addr, ok := FromAddr(&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 80})
+	require.True(t, ok)
+	require.Equal(t, netip.MustParseAddr("127.0.0.1"), addr)
+
+	_, ok = FromAddr(nil)
+	require.False(t, ok)
+}
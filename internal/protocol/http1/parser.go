@@ -45,13 +45,18 @@ type Parser struct {
 	cfg                 *config.Config
 	request             *http.Request
 	requestLine         *buffer.Buffer
+	target              *buffer.Buffer
 	headers             *buffer.Buffer
 	key                 string
 	acceptEncodings     []string
 	encodings           []string
 }
 
-func NewParser(cfg *config.Config, request *http.Request, statusBuff, headers *buffer.Buffer) *Parser {
+func NewParser(
+	cfg *config.Config,
+	request *http.Request,
+	statusBuff, targetBuff, headers *buffer.Buffer,
+) *Parser {
 	return &Parser{
 		cfg:             cfg,
 		state:           eMethod,
@@ -59,6 +64,7 @@ func NewParser(cfg *config.Config, request *http.Request, statusBuff, headers *b
 		acceptEncodings: make([]string, 0, cfg.Headers.MaxAcceptEncodingTokens),
 		encodings:       make([]string, 0, cfg.Headers.MaxEncodingTokens),
 		requestLine:     statusBuff,
+		target:          targetBuff,
 		headers:         headers,
 	}
 }
@@ -70,6 +76,10 @@ func (p *Parser) Parse(data []byte) (done bool, extra []byte, err error) {
 	headers := p.headers
 	headersCfg := p.cfg.Headers
 
+	if p.state > eMethod && p.state < eProtocol && !p.captureTarget(data) {
+		return true, nil, status.ErrURITooLong
+	}
+
 	switch p.state {
 	case eMethod:
 		goto method
@@ -132,6 +142,10 @@ method:
 			}
 
 			data = data[i+1:]
+			if !p.captureTarget(data) {
+				return true, nil, status.ErrURITooLong
+			}
+
 			goto path
 		}
 	}
@@ -440,6 +454,10 @@ protocol:
 		}
 
 		request.Protocol = protocol
+		if err := p.finishTarget(); err != nil {
+			return true, nil, err
+		}
+
 		data = data[boundary+1:]
 		// fallthrough to headerKey
 	}
@@ -629,8 +647,88 @@ contentLengthCR:
 	goto headerKey
 }
 
+// captureTarget preserves the raw request-target, as the path and params are decoded in-place.
+// Must be called before the data is processed, every time it's known to continue the target.
+func (p *Parser) captureTarget(data []byte) (ok bool) {
+	if end := bytes.IndexByte(data, ' '); end != -1 {
+		data = data[:end]
+	}
+
+	return p.target.Append(data)
+}
+
+// finishTarget completes the raw request-target and determines its form (RFC 9112, section 3.2).
+func (p *Parser) finishTarget() error {
+	request := p.request
+	target := uf.B2S(p.target.Finish())
+	request.Target, request.Query, request.Authority = target, "", ""
+
+	if query := strings.IndexByte(target, '?'); query != -1 {
+		request.Query = target[query+1:]
+	}
+
+	switch {
+	case len(target) == 0:
+		return status.ErrBadRequest
+	case target[0] == '/', target == "*":
+		// origin-form and asterisk-form
+		return nil
+	case request.Method == method.CONNECT:
+		request.Authority = target
+		return nil
+	}
+
+	// absolute-form, which is mostly sent to proxies. However, servers must accept it as well
+	scheme, rest, found := strings.Cut(target, "://")
+	if !found || !(strutil.CmpFoldSafe(scheme, "http") || strutil.CmpFoldSafe(scheme, "https")) {
+		return status.ErrBadRequest
+	}
+
+	authority := rest
+	if end := strings.IndexAny(rest, "/?"); end != -1 {
+		authority = rest[:end]
+	}
+
+	if at := strings.LastIndexByte(authority, '@'); at != -1 {
+		// userinfo is deprecated and must not be considered
+		authority = authority[at+1:]
+	}
+
+	if len(authority) == 0 {
+		return status.ErrBadRequest
+	}
+
+	request.Authority = authority
+	// the path is decoded along with the scheme and the authority, so they must be cut off
+	_, path, _ := strings.Cut(request.Path, "://")
+	if slash := strings.IndexByte(path, '/'); slash != -1 {
+		request.Path = path[slash:]
+	} else {
+		request.Path = "/"
+	}
+
+	return nil
+}
+
+// release returns the buffers into the bufpool, if no incomplete request occupies them.
+func (p *Parser) release() {
+	if p.state == eMethod && p.requestLine.Len() == 0 {
+		p.free()
+	}
+}
+
+// free returns the buffers into the bufpool unconditionally, dropping the incomplete request,
+// if any. The parser stays usable, acquiring the buffers again on demand.
+func (p *Parser) free() {
+	p.cleanup()
+	p.requestLine.Release()
+	p.target.Release()
+	p.headers.Release()
+}
+
 func (p *Parser) cleanup() {
 	p.requestLine.Clear()
+	p.target.Clear()
 	p.headers.Clear()
 
 	*p = Parser{
@@ -638,6 +736,7 @@ func (p *Parser) cleanup() {
 		cfg:             p.cfg,
 		request:         p.request,
 		requestLine:     p.requestLine,
+		target:          p.target,
 		headers:         p.headers,
 		acceptEncodings: p.acceptEncodings[:0],
 		encodings:       p.encodings[:0],
@@ -3,10 +3,11 @@ package http1
 import (
 	"io"
 	"math/bits"
+	"net"
+	"os"
 	"slices"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/indigo-web/indigo/config"
 	"github.com/indigo-web/indigo/http"
@@ -16,6 +17,7 @@ import (
 	"github.com/indigo-web/indigo/http/mime"
 	"github.com/indigo-web/indigo/http/proto"
 	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/internal/bufpool"
 	"github.com/indigo-web/indigo/internal/codecutil"
 	"github.com/indigo-web/indigo/internal/hexconv"
 	"github.com/indigo-web/indigo/internal/response"
@@ -31,6 +33,9 @@ type serializer struct {
 	client         transport.Client
 	buff           []byte
 	streamReadBuff []byte
+	// pooled is the slice acquired from the bufpool, backing buff. They might diverge if
+	// buff was grown by appending.
+	pooled         []byte
 	defaultHeaders defaultHeaders
 	codecs         codecutil.Cache
 }
@@ -48,6 +53,7 @@ func newSerializer(
 		client:  client,
 		codecs:  codecs,
 		buff:    buff,
+		pooled:  buff,
 		defaultHeaders: newDefaultHeaders(
 			pairsFromMap(cfg.Headers.Default, codecs.AcceptEncoding()),
 		),
@@ -56,6 +62,7 @@ func newSerializer(
 
 // Upgrade writes an informational response 101 Switching Protocols without immediately flushing it.
 func (s *serializer) Upgrade() {
+	s.acquire()
 	s.appendProtocol(s.request.Protocol)
 	s.buff = append(s.buff, "101 Switching Protocol\r\n"...)
 
@@ -68,6 +75,7 @@ func (s *serializer) Upgrade() {
 func (s *serializer) Write(protocol proto.Protocol, response *http.Response) error {
 	resp := response.Expose()
 
+	s.acquire()
 	s.appendProtocol(protocol)
 	s.appendStatus(resp)
 	s.appendHeaders(resp)
@@ -142,7 +150,23 @@ func (s *serializer) writeStream(resp *response.Fields) (err error) {
 		encoder = identityWriter{s}
 		s.appendContentLength(length)
 
-		if wt, ok := stream.(io.WriterTo); ok && s.request.Method != method.HEAD {
+		file, isFile := stream.(*os.File)
+		if isFile && s.request.Method != method.HEAD {
+			if conn, ok := s.client.Conn().(*net.TCPConn); ok {
+				// plain TCP connection and a file with known size are the perfect match for
+				// zero-copy transfer. Encrypted connections fall back to regular copying, as
+				// the data must pass through the user space anyway.
+				s.crlf() // to finalize the headers block
+
+				if err = s.flush(); err != nil {
+					return err
+				}
+
+				return sendfile(conn, file, length)
+			}
+		}
+
+		if wt, ok := stream.(io.WriterTo); ok && !isFile && s.request.Method != method.HEAD {
 			// there are chances to engage some smarter ways to transfer the stream.
 			// For example, sendfile(2) on files when running on Linux.
 
@@ -191,7 +215,8 @@ func (s *serializer) writeStream(resp *response.Fields) (err error) {
 		// TODO: if we use a big slice split in half for each buff and streamReadBuff, we could
 		// TODO: get by with just a single slices.Grow() call.
 		if cap(s.buff) > cap(s.streamReadBuff) {
-			s.streamReadBuff = slices.Grow(s.streamReadBuff[:0], cap(s.buff))
+			bufpool.Put(s.streamReadBuff)
+			s.streamReadBuff = bufpool.Get(cap(s.buff))
 		}
 
 		n, err := stream.Read(s.streamReadBuff[:cap(s.streamReadBuff)])
@@ -212,18 +237,62 @@ func (s *serializer) writeStream(resp *response.Fields) (err error) {
 	}
 }
 
+// sendfile transmits exactly n bytes of the file into the connection. net.TCPConn.ReadFrom
+// recognizes files (even limited ones) and offloads the transfer onto the kernel via sendfile(2)
+// or splice(2) where available, otherwise falling back to regular copying.
+func sendfile(conn *net.TCPConn, file *os.File, n int64) error {
+	written, err := conn.ReadFrom(io.LimitReader(file, n))
+	if err == nil && written < n {
+		// the file is exhausted before it must have been, which is only possible if it was
+		// truncated after its size was determined. The response cannot be completed anymore.
+		return status.ErrInternalServerError
+	}
+
+	return err
+}
+
 func (s *serializer) grow(newsize int) {
 	// cap the size at its top value from the config.
 	newsize = min(s.cfg.NET.WriteBufferSize.Maximal, newsize)
 	// the growth can be triggered even the buffer is already at its maximal size. Do nothing then.
 	if newsize > cap(s.buff) {
-		s.buff = make([]byte, 0, newsize)
+		pooled := bufpool.Get(newsize)
+		bufpool.Put(s.pooled)
+		s.pooled, s.buff = pooled, pooled[:0:newsize]
 	}
 }
 
 func (s *serializer) growToContain(n int) {
 	newsize := min(s.cfg.NET.WriteBufferSize.Maximal-len(s.buff), n)
-	s.buff = slices.Grow(s.buff, newsize)
+	if cap(s.buff)-len(s.buff) < newsize {
+		size := len(s.buff) + newsize
+		pooled := bufpool.Get(size)
+		s.buff = append(pooled[:0:size], s.buff...)
+		bufpool.Put(s.pooled)
+		s.pooled = pooled
+	}
+}
+
+// acquire makes sure the buffer is present, as it might've been released in between requests.
+func (s *serializer) acquire() {
+	if s.pooled == nil {
+		s.pooled = bufpool.Get(s.cfg.NET.WriteBufferSize.Default)
+		s.buff = s.pooled
+	}
+}
+
+// shrink releases the buffers if they were grown beyond the default size.
+func (s *serializer) shrink() {
+	if cap(s.buff) > s.cfg.NET.WriteBufferSize.Default || cap(s.streamReadBuff) > 0 {
+		s.release()
+	}
+}
+
+// release returns all the buffers into the bufpool. They're acquired again on demand.
+func (s *serializer) release() {
+	bufpool.Put(s.pooled)
+	bufpool.Put(s.streamReadBuff)
+	s.pooled, s.buff, s.streamReadBuff = nil, nil, nil
 }
 
 func (s *serializer) getCompressor(token string) codec.Compressor {
@@ -334,61 +403,9 @@ func (s *serializer) appendKnownHeader(key, value string) {
 	s.crlf()
 }
 
-var zoneGMT = time.FixedZone("GMT", 0)
-
 func (s *serializer) appendCookie(c cookie.Cookie) {
 	s.buff = append(s.buff, "Set-Cookie: "...)
-	s.buff = append(s.buff, c.Name...)
-	s.buff = append(s.buff, '=')
-	s.buff = append(s.buff, c.Value...)
-	s.buff = append(s.buff, ';', ' ')
-
-	if len(c.Path) > 0 {
-		s.buff = append(s.buff, "Path="...)
-		s.buff = append(s.buff, c.Path...)
-		s.buff = append(s.buff, ';', ' ')
-	}
-
-	if len(c.Domain) > 0 {
-		s.buff = append(s.buff, "Domain="...)
-		s.buff = append(s.buff, c.Domain...)
-		s.buff = append(s.buff, ';', ' ')
-	}
-
-	if !c.Expires.IsZero() {
-		s.buff = append(s.buff, "Expires="...)
-		// TODO: this _may_ be slow. We could write it manually instead
-		s.buff = c.Expires.In(zoneGMT).AppendFormat(s.buff, time.RFC1123)
-		s.buff = append(s.buff, ';', ' ')
-	}
-
-	if c.MaxAge != 0 {
-		maxage := "0"
-		if c.MaxAge > 0 {
-			maxage = strconv.Itoa(c.MaxAge)
-		}
-
-		s.buff = append(s.buff, "MaxAge="...)
-		s.buff = append(s.buff, maxage...)
-		s.buff = append(s.buff, ';', ' ')
-	}
-
-	if len(c.SameSite) > 0 {
-		s.buff = append(s.buff, "SameSite="...)
-		s.buff = append(s.buff, c.SameSite...)
-		s.buff = append(s.buff, ';', ' ')
-	}
-
-	if c.Secure {
-		s.buff = append(s.buff, "Secure; "...)
-	}
-
-	if c.HttpOnly {
-		s.buff = append(s.buff, "HttpOnly; "...)
-	}
-
-	// strip last 2 bytes, which are always a semicolon and a space
-	s.buff = s.buff[:len(s.buff)-2]
+	s.buff = cookie.Append(s.buff, c)
 	s.crlf()
 }
 
@@ -6,6 +6,7 @@ import (
 	"github.com/indigo-web/indigo/http/proto"
 	"github.com/indigo-web/indigo/http/status"
 	"github.com/indigo-web/indigo/internal/buffer"
+	"github.com/indigo-web/indigo/internal/bufpool"
 	"github.com/indigo-web/indigo/internal/codecutil"
 	"github.com/indigo-web/indigo/internal/construct"
 	"github.com/indigo-web/indigo/internal/strutil"
@@ -29,11 +30,11 @@ func newSuit(
 	client transport.Client,
 	body *body,
 	codecs codecutil.Cache,
-	statusBuff, headersBuff *buffer.Buffer,
+	statusBuff, targetBuff, headersBuff *buffer.Buffer,
 	respBuff []byte,
 ) *Suit {
 	return &Suit{
-		Parser:     NewParser(cfg, request, statusBuff, headersBuff),
+		Parser:     NewParser(cfg, request, statusBuff, targetBuff, headersBuff),
 		body:       body,
 		serializer: newSerializer(cfg, request, client, codecs, respBuff),
 		router:     r,
@@ -50,22 +51,64 @@ func New(
 	request *http.Request,
 	codecs codecutil.Cache,
 ) *Suit {
-	statusBuff, headersBuff := construct.Buffers(cfg)
-	respBuff := make([]byte, 0, cfg.NET.WriteBufferSize.Default)
+	statusBuff, targetBuff, headersBuff := construct.Buffers(cfg)
+	respBuff := bufpool.Get(cfg.NET.WriteBufferSize.Default)
 	b := newBody(client, cfg.Body)
 
-	return newSuit(cfg, r, request, client, b, codecs, statusBuff, headersBuff, respBuff)
+	return newSuit(cfg, r, request, client, b, codecs, statusBuff, targetBuff, headersBuff, respBuff)
 }
 
+type serveMode uint8
+
+const (
+	// serveForever serves the connection until it's closed.
+	serveForever serveMode = iota
+	// serveOnce serves at most one read.
+	serveOnce
+	// serveReady serves all the requests available without blocking on a connection read.
+	serveReady
+)
+
 func (s *Suit) ServeOnce() (ok bool) {
-	return s.serve(true)
+	return s.serve(serveOnce)
 }
 
 func (s *Suit) Serve() {
-	s.serve(false)
+	s.serve(serveForever)
+}
+
+// Resume serves the data arrived on the connection, which must be known to be readable, so the
+// first read doesn't block. All complete requests, including pipelined ones, are processed, while
+// an incomplete one is kept in the parser state until the next call. Returns false if the
+// connection must be closed.
+func (s *Suit) Resume() (keepAlive bool) {
+	keepAlive = s.serve(serveReady)
+	if keepAlive {
+		// the connection is going idle, so there's no reason to hold the memory meanwhile
+		s.release()
+	}
+
+	return keepAlive
+}
+
+// Release returns all the buffers into the bufpool, discarding an incomplete request and
+// pipelined data, if any. Must be called after the connection is done.
+func (s *Suit) Release() {
+	s.Parser.free()
+	s.serializer.release()
+	s.client.Pushback(nil)
+	s.client.Release()
 }
 
-func (s *Suit) serve(once bool) (ok bool) {
+// release returns the buffers into the bufpool while the connection is idle. Ones occupied
+// by an incomplete request or pending data are kept, as they're needed by the next read.
+func (s *Suit) release() {
+	s.Parser.release()
+	s.serializer.release()
+	s.client.Release()
+}
+
+func (s *Suit) serve(mode serveMode) (ok bool) {
 	client := s.client
 	request := s.Parser.request
 
@@ -86,7 +129,7 @@ func (s *Suit) serve(once bool) (ok bool) {
 		}
 
 		if !done {
-			if once {
+			if mode != serveForever {
 				return true
 			}
 
@@ -151,14 +194,27 @@ func (s *Suit) serve(once bool) (ok bool) {
 
 		if !isKeepAlive(version, request) {
 			s.router.OnError(request, status.ErrCloseConnection)
-			return true
+			return mode != serveReady
 		}
 
-		if once {
+		if mode == serveOnce {
 			return true
 		}
 
 		request.Reset()
+		// an outlier response shouldn't pin the grown buffers for the rest of the connection
+		s.serializer.shrink()
+
+		if len(client.Pending()) == 0 {
+			if mode == serveReady {
+				// no pipelined requests left, wait for the connection to get readable again
+				return true
+			}
+
+			// the connection is likely to go idle, so there's no reason to hold the memory
+			// while blocking on the next read
+			s.release()
+		}
 	}
 }
 
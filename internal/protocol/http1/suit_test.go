@@ -20,6 +20,7 @@ import (
 	"github.com/indigo-web/indigo/kv"
 	"github.com/indigo-web/indigo/router"
 	"github.com/indigo-web/indigo/router/inbuilt"
+	"github.com/indigo-web/indigo/router/simple"
 	"github.com/indigo-web/indigo/transport"
 	"github.com/indigo-web/indigo/transport/dummy"
 	"github.com/klauspost/compress/gzip"
@@ -303,3 +304,23 @@ func TestPOST(t *testing.T) {
 		}
 	})
 }
+
+func TestResetBetweenRequests(t *testing.T) {
+	var authorities []string
+	record := func(request *http.Request) *http.Response {
+		authorities = append(authorities, request.Authority)
+		return request.Respond()
+	}
+
+	data := "GET http://example.com/ HTTP/1.1\r\n\r\nFOOBAR / HTTP/1.1\r\n\r\n"
+	client := dummy.NewMockClient([]byte(data))
+	cfg := config.Default()
+	request := construct.Request(cfg, client)
+	suit := New(cfg, simple.New(record, record), client, request, codecutil.NewCache(nil, ""))
+	request.Body = http.NewBody(suit)
+
+	suit.Serve()
+	require.Equal(t, []string{"example.com", ""}, authorities)
+	require.Empty(t, request.Target)
+	require.Empty(t, request.Query)
+}
@@ -0,0 +1,91 @@
+package http3
+
+import (
+	"io"
+	"time"
+
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/internal/bufpool"
+	"github.com/indigo-web/indigo/internal/timer"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// body fetches the request body out of the DATA frames. Trailers and unknown frames
+// are silently skipped.
+type body struct {
+	str       *quic.Stream
+	reader    quicvarint.Reader
+	buff      []byte
+	size      int
+	timeout   time.Duration
+	remaining uint64
+	received  uint64
+	maxSize   uint64
+}
+
+func newBody(str *quic.Stream, reader quicvarint.Reader, timeout time.Duration, size int, maxSize uint64) *body {
+	return &body{
+		str:     str,
+		reader:  reader,
+		size:    size,
+		timeout: timeout,
+		maxSize: maxSize,
+	}
+}
+
+func (b *body) Fetch() ([]byte, error) {
+	if err := b.str.SetReadDeadline(timer.Now().Add(b.timeout)); err != nil {
+		return nil, err
+	}
+
+	for b.remaining == 0 {
+		h, err := readFrameHeader(b.reader)
+		switch {
+		case err == io.EOF:
+			return nil, io.EOF
+		case err != nil:
+			return nil, err
+		case h.typ == frameData:
+			b.remaining = h.length
+		case forbiddenOnRequestStream(h.typ):
+			return nil, status.ErrBadRequest
+		default:
+			// HEADERS at this point are trailers, which aren't supported
+			if err = skipFrame(b.reader, h); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if b.received+b.remaining > b.maxSize {
+		return nil, status.ErrBodyTooLarge
+	}
+
+	if b.buff == nil {
+		b.buff = bufpool.Get(b.size)
+	}
+
+	n, err := b.str.Read(b.buff[:min(uint64(b.size), b.remaining)])
+	b.remaining -= uint64(n)
+	b.received += uint64(n)
+
+	if err == io.EOF {
+		if b.remaining > 0 {
+			// the stream ended in the middle of a frame
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		if n > 0 {
+			// the rest is going to be fetched on the next call
+			err = nil
+		}
+	}
+
+	return b.buff[:n], err
+}
+
+func (b *body) release() {
+	bufpool.Put(b.buff)
+	b.buff = nil
+}
@@ -0,0 +1,95 @@
+package http3
+
+import (
+	"net"
+	"time"
+
+	"github.com/indigo-web/indigo/internal/timer"
+	"github.com/quic-go/quic-go"
+)
+
+// streamConn represents a request stream as a net.Conn, so a hijacked request exposes
+// the stream as a plain bidirectional pipe.
+type streamConn struct {
+	*quic.Stream
+	conn *quic.Conn
+}
+
+func (s streamConn) Close() error {
+	s.CancelRead(errNoError)
+	return s.Stream.Close()
+}
+
+func (s streamConn) LocalAddr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+func (s streamConn) RemoteAddr() net.Addr {
+	return s.conn.RemoteAddr()
+}
+
+// streamClient implements transport.Client over the raw request stream. It's used only
+// by hijackers, because the request and its body are read frame-wise.
+type streamClient struct {
+	conn    streamConn
+	buff    []byte
+	pending []byte
+	size    int
+	timeout time.Duration
+}
+
+func newStreamClient(conn streamConn, timeout time.Duration, size int) *streamClient {
+	return &streamClient{
+		conn:    conn,
+		size:    size,
+		timeout: timeout,
+	}
+}
+
+func (s *streamClient) Read() ([]byte, error) {
+	if len(s.pending) > 0 {
+		pending := s.pending
+		s.pending = nil
+
+		return pending, nil
+	}
+
+	if err := s.conn.SetReadDeadline(timer.Now().Add(s.timeout)); err != nil {
+		return nil, err
+	}
+
+	if s.buff == nil {
+		// most of the streams are never hijacked, so don't allocate in advance
+		s.buff = make([]byte, s.size)
+	}
+
+	n, err := s.conn.Read(s.buff)
+
+	return s.buff[:n], err
+}
+
+func (s *streamClient) Pushback(b []byte) {
+	s.pending = b
+}
+
+func (s *streamClient) Pending() []byte {
+	return s.pending
+}
+
+func (s *streamClient) Write(b []byte) (int, error) {
+	return s.conn.Write(b)
+}
+
+func (s *streamClient) Conn() net.Conn {
+	return s.conn
+}
+
+func (s *streamClient) Remote() net.Addr {
+	return s.conn.RemoteAddr()
+}
+
+func (s *streamClient) Close() error {
+	return s.conn.Close()
+}
+
+func (s *streamClient) Release() {}
@@ -0,0 +1,193 @@
+// Package http3 implements HTTP/3 (RFC 9114) on top of QUIC connections. Header blocks are
+// compressed with QPACK (RFC 9204), restricted to its static table, so no encoder or decoder
+// streams state is kept.
+package http3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/http/codec"
+	"github.com/indigo-web/indigo/kv"
+	"github.com/indigo-web/indigo/router"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+var errMissingSettingsFrame = errors.New("control stream doesn't start with SETTINGS")
+
+// Serve serves the QUIC connection as HTTP/3 until the context is done or the connection
+// is closed. Requests in progress are completed before the connection is closed gracefully.
+func Serve(
+	ctx context.Context,
+	cfg *config.Config,
+	conn *quic.Conn,
+	r router.Router,
+	codecs []codec.Codec,
+	acceptString string,
+) {
+	c := &connection{
+		cfg:             cfg,
+		conn:            conn,
+		tls:             conn.ConnectionState().TLS,
+		router:          r,
+		codecs:          codecs,
+		acceptString:    acceptString,
+		defaultHeaders:  defaultHeaders(cfg, acceptString),
+		maxFieldSection: uint64(cfg.URI.RequestLineSize.Maximal + cfg.Headers.Space.Maximal),
+	}
+
+	if err := c.openControlStream(); err != nil {
+		_ = conn.CloseWithError(errClosedCriticalStream, "")
+		return
+	}
+
+	go c.acceptUniStreams()
+
+	var (
+		wg       sync.WaitGroup
+		lastID   quic.StreamID
+		accepted bool
+	)
+
+	for {
+		str, err := conn.AcceptStream(ctx)
+		if err != nil {
+			break
+		}
+
+		lastID, accepted = str.StreamID(), true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.serveStream(str)
+		}()
+	}
+
+	if ctx.Err() != nil {
+		// the server is stopping, so tell the client which requests are going to be served
+		nextID := quic.StreamID(0)
+		if accepted {
+			nextID = lastID + 4
+		}
+
+		c.goAway(nextID)
+	}
+
+	wg.Wait()
+	_ = conn.CloseWithError(errNoError, "")
+}
+
+type connection struct {
+	cfg             *config.Config
+	conn            *quic.Conn
+	tls             tls.ConnectionState
+	router          router.Router
+	codecs          []codec.Codec
+	acceptString    string
+	defaultHeaders  []kv.Pair
+	maxFieldSection uint64
+	control         *quic.SendStream
+}
+
+func (c *connection) openControlStream() (err error) {
+	c.control, err = c.conn.OpenUniStream()
+	if err != nil {
+		return err
+	}
+
+	var settings []byte
+	settings = quicvarint.Append(settings, settingQPACKMaxTableCapacity)
+	settings = quicvarint.Append(settings, 0)
+	settings = quicvarint.Append(settings, settingQPACKBlockedStreams)
+	settings = quicvarint.Append(settings, 0)
+	settings = quicvarint.Append(settings, settingMaxFieldSectionSize)
+	settings = quicvarint.Append(settings, c.maxFieldSection)
+
+	buff := quicvarint.Append(nil, streamControl)
+	buff = appendFrameHeader(buff, frameSettings, uint64(len(settings)))
+	buff = append(buff, settings...)
+	_, err = c.control.Write(buff)
+
+	return err
+}
+
+func (c *connection) goAway(streamID quic.StreamID) {
+	buff := appendFrameHeader(nil, frameGoAway, uint64(quicvarint.Len(uint64(streamID))))
+	buff = quicvarint.Append(buff, uint64(streamID))
+	_, _ = c.control.Write(buff)
+}
+
+func (c *connection) acceptUniStreams() {
+	var controlMet bool
+
+	for {
+		str, err := c.conn.AcceptUniStream(c.conn.Context())
+		if err != nil {
+			return
+		}
+
+		r := quicvarint.NewReader(str)
+		typ, err := quicvarint.Read(r)
+		if err != nil {
+			str.CancelRead(errStreamCreation)
+			continue
+		}
+
+		switch typ {
+		case streamControl:
+			if controlMet {
+				_ = c.conn.CloseWithError(errStreamCreation, "duplicate control stream")
+				return
+			}
+
+			controlMet = true
+			go c.readControlStream(r)
+		case streamPush:
+			// only servers are allowed to push
+			_ = c.conn.CloseWithError(errStreamCreation, "push stream from client")
+			return
+		case streamQPACKEncoder, streamQPACKDecoder:
+			// the dynamic table is disabled by our settings, so there's nothing to process
+			go func() {
+				_, _ = io.Copy(io.Discard, str)
+			}()
+		default:
+			// unknown stream types must be ignored
+			str.CancelRead(errStreamCreation)
+		}
+	}
+}
+
+func (c *connection) readControlStream(r quicvarint.Reader) {
+	h, err := readFrameHeader(r)
+	if err == nil && h.typ != frameSettings {
+		err = errMissingSettingsFrame
+	}
+
+	for err == nil {
+		switch h.typ {
+		case frameData, frameHeaders, framePushPromise:
+			_ = c.conn.CloseWithError(errFrameUnexpected, "")
+			return
+		}
+
+		// settings of the peer and other control frames don't affect us, as we neither use the
+		// dynamic table nor push
+		if err = skipFrame(r, h); err == nil {
+			h, err = readFrameHeader(r)
+		}
+	}
+
+	switch {
+	case errors.Is(err, errMissingSettingsFrame):
+		_ = c.conn.CloseWithError(errMissingSettings, "")
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		// the control stream must never be closed
+		_ = c.conn.CloseWithError(errClosedCriticalStream, "")
+	}
+}
@@ -0,0 +1,93 @@
+package http3
+
+import (
+	"io"
+
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// frame types, RFC 9114 section 7.2
+const (
+	frameData        = 0x0
+	frameHeaders     = 0x1
+	frameCancelPush  = 0x3
+	frameSettings    = 0x4
+	framePushPromise = 0x5
+	frameGoAway      = 0x7
+	frameMaxPushID   = 0xd
+)
+
+// unidirectional stream types, RFC 9114 section 6.2 and RFC 9204 section 4.2
+const (
+	streamControl      = 0x0
+	streamPush         = 0x1
+	streamQPACKEncoder = 0x2
+	streamQPACKDecoder = 0x3
+)
+
+// settings identifiers, RFC 9114 section 7.2.4.1 and RFC 9204 section 5
+const (
+	settingQPACKMaxTableCapacity = 0x1
+	settingMaxFieldSectionSize   = 0x6
+	settingQPACKBlockedStreams   = 0x7
+)
+
+// error codes, RFC 9114 section 8.1
+const (
+	errNoError              = 0x100
+	errGeneralProtocol      = 0x101
+	errInternal             = 0x102
+	errStreamCreation       = 0x103
+	errClosedCriticalStream = 0x104
+	errFrameUnexpected      = 0x105
+	errFrame                = 0x106
+	errExcessiveLoad        = 0x107
+	errMissingSettings      = 0x10a
+	errRequestRejected      = 0x10b
+	errRequestIncomplete    = 0x10d
+	errMessage              = 0x10e
+	errQPACKDecompression   = 0x200
+)
+
+type frameHeader struct {
+	typ, length uint64
+}
+
+func readFrameHeader(r quicvarint.Reader) (h frameHeader, err error) {
+	if h.typ, err = quicvarint.Read(r); err != nil {
+		return h, err
+	}
+
+	if h.length, err = quicvarint.Read(r); err == io.EOF {
+		// the frame was cut in the middle
+		err = io.ErrUnexpectedEOF
+	}
+
+	return h, err
+}
+
+func appendFrameHeader(b []byte, typ, length uint64) []byte {
+	return quicvarint.Append(quicvarint.Append(b, typ), length)
+}
+
+// skipFrame discards the payload of a frame, which is of no interest.
+func skipFrame(r io.Reader, h frameHeader) error {
+	n, err := io.CopyN(io.Discard, r, int64(h.length))
+	if err == io.EOF && uint64(n) < h.length {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// forbiddenOnRequestStream reports whether the frame type must never be met on a request
+// stream. All the unknown and reserved types must be ignored instead.
+func forbiddenOnRequestStream(typ uint64) bool {
+	switch typ {
+	case frameCancelPush, frameSettings, frameGoAway, frameMaxPushID, framePushPromise:
+		return true
+	default:
+		// HTTP/2 frame types having no HTTP/3 equivalent are reserved and forbidden
+		return typ == 0x2 || typ == 0x6 || typ == 0x8 || typ == 0x9
+	}
+}
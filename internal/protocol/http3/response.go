@@ -0,0 +1,248 @@
+package http3
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/codec"
+	"github.com/indigo-web/indigo/http/cookie"
+	"github.com/indigo-web/indigo/http/method"
+	"github.com/indigo-web/indigo/http/mime"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/internal/bufpool"
+	"github.com/indigo-web/indigo/internal/codecutil"
+	"github.com/indigo-web/indigo/internal/response"
+	"github.com/indigo-web/indigo/internal/strutil"
+	"github.com/indigo-web/indigo/kv"
+	"github.com/quic-go/qpack"
+	"github.com/quic-go/quic-go"
+)
+
+type responseWriter struct {
+	cfg            *config.Config
+	str            *quic.Stream
+	codecs         codecutil.Cache
+	defaultHeaders []kv.Pair
+	block          bytes.Buffer
+	encoder        *qpack.Encoder
+}
+
+func newResponseWriter(
+	cfg *config.Config,
+	str *quic.Stream,
+	codecs codecutil.Cache,
+	defaultHeaders []kv.Pair,
+) *responseWriter {
+	w := &responseWriter{
+		cfg:            cfg,
+		str:            str,
+		codecs:         codecs,
+		defaultHeaders: defaultHeaders,
+	}
+	w.encoder = qpack.NewEncoder(&w.block)
+
+	return w
+}
+
+// finish writes the response and closes the stream. The rest of the request body isn't
+// of interest anymore, so the client is asked to stop sending it.
+func (w *responseWriter) finish(request *http.Request, resp *http.Response) {
+	if err := w.write(request, resp); err != nil {
+		w.str.CancelWrite(errInternal)
+		return
+	}
+
+	_ = w.str.Close()
+	w.str.CancelRead(errNoError)
+}
+
+func (w *responseWriter) write(request *http.Request, resp *http.Response) (err error) {
+	fields := resp.Expose()
+	w.writeField(":status", statusCode(fields.Code))
+	w.writeHeaders(fields)
+
+	for _, c := range fields.Cookies {
+		w.writeField("set-cookie", string(cookie.Append(nil, c)))
+	}
+
+	stream, length := fields.Stream, fields.StreamSize
+	if length == 0 {
+		w.writeField("content-length", "0")
+		return w.flushHeaders()
+	}
+
+	if stream == nil {
+		return status.ErrInternalServerError
+	}
+
+	defer func() {
+		if c, ok := stream.(io.Closer); ok {
+			if cerr := c.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+	}()
+
+	compression := fields.ContentEncoding
+	if fields.AutoCompress && (length == -1 || length >= w.cfg.NET.SmallBody) {
+		// small bodies aren't worth compressing, see the HTTP/1 serializer
+		compression = request.PreferredEncoding()
+	}
+
+	compressor := w.getCompressor(compression)
+	if compressor != nil {
+		// the size of the compressed stream is unknown in advance
+		length = -1
+	}
+
+	if length != -1 {
+		w.writeField("content-length", strconv.FormatInt(length, 10))
+	}
+
+	if err = w.flushHeaders(); err != nil || request.Method == method.HEAD {
+		return err
+	}
+
+	var (
+		dst io.Writer = dataWriter{w.str}
+		src           = stream
+	)
+
+	if compressor != nil {
+		compressor.ResetCompressor(dst)
+		dst = compressor
+	}
+
+	if length != -1 {
+		src = io.LimitReader(stream, length)
+	}
+
+	buff := bufpool.Get(w.cfg.NET.WriteBufferSize.Default)
+	n, err := io.CopyBuffer(dst, src, buff[:cap(buff)])
+	bufpool.Put(buff)
+	if err != nil {
+		return err
+	}
+
+	if compressor != nil {
+		return compressor.Close()
+	}
+
+	if n < length {
+		// the stream is exhausted before it must have been. No good.
+		return status.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (w *responseWriter) writeHeaders(fields *response.Fields) {
+	for _, header := range fields.Headers {
+		name := strings.ToLower(header.Key)
+		if connectionSpecific(name) {
+			// meaningless and even malformed in HTTP/3
+			continue
+		}
+
+		value := header.Value
+		if name == "content-type" && fields.Charset != mime.Unset {
+			value += "; charset=" + fields.Charset
+		}
+
+		w.writeField(name, value)
+	}
+
+	for _, header := range w.defaultHeaders {
+		if !hasHeader(fields.Headers, header.Key) {
+			w.writeField(header.Key, header.Value)
+		}
+	}
+}
+
+func (w *responseWriter) writeField(name, value string) {
+	// writing into bytes.Buffer never fails
+	_ = w.encoder.WriteField(qpack.HeaderField{Name: name, Value: value})
+}
+
+func (w *responseWriter) flushHeaders() error {
+	frame := appendFrameHeader(make([]byte, 0, 16+w.block.Len()), frameHeaders, uint64(w.block.Len()))
+	frame = append(frame, w.block.Bytes()...)
+	w.block.Reset()
+	_, err := w.str.Write(frame)
+
+	return err
+}
+
+func (w *responseWriter) getCompressor(token string) codec.Compressor {
+	if token == "" || token == "identity" {
+		return nil
+	}
+
+	compressor := w.codecs.Get(token)
+	if compressor != nil {
+		w.writeField("content-encoding", token)
+	}
+
+	return compressor
+}
+
+// dataWriter wraps every write into a DATA frame.
+type dataWriter struct {
+	str *quic.Stream
+}
+
+func (d dataWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	var header [16]byte
+	if _, err := d.str.Write(appendFrameHeader(header[:0], frameData, uint64(len(p)))); err != nil {
+		return 0, err
+	}
+
+	return d.str.Write(p)
+}
+
+func statusCode(code status.Code) string {
+	if str := status.StringCode(code); len(str) > 0 {
+		return str
+	}
+
+	return strconv.FormatUint(uint64(code), 10)
+}
+
+func connectionSpecific(name string) bool {
+	switch name {
+	case "connection", "keep-alive", "proxy-connection", "transfer-encoding", "upgrade":
+		return true
+	default:
+		return false
+	}
+}
+
+func hasHeader(headers []kv.Pair, key string) bool {
+	for _, header := range headers {
+		if strutil.CmpFoldSafe(header.Key, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// defaultHeaders returns the headers included into every response, unless overridden.
+func defaultHeaders(cfg *config.Config, acceptString string) []kv.Pair {
+	headers := make([]kv.Pair, 0, len(cfg.Headers.Default)+1)
+	headers = append(headers, kv.Pair{Key: "accept-encoding", Value: acceptString})
+
+	for key, value := range cfg.Headers.Default {
+		headers = append(headers, kv.Pair{Key: strings.ToLower(key), Value: value})
+	}
+
+	return headers
+}
@@ -0,0 +1,314 @@
+package http3
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/method"
+	"github.com/indigo-web/indigo/http/proto"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/internal/codecutil"
+	"github.com/indigo-web/indigo/internal/construct"
+	"github.com/indigo-web/indigo/internal/protocol/http1"
+	"github.com/indigo-web/indigo/internal/timer"
+	"github.com/quic-go/qpack"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// streamError aborts the stream with the code instead of responding.
+type streamError quic.StreamErrorCode
+
+func (s streamError) Error() string {
+	return "http3: stream error 0x" + strconv.FormatUint(uint64(s), 16)
+}
+
+func (c *connection) serveStream(str *quic.Stream) {
+	reader := quicvarint.NewReader(str)
+	conn := streamConn{Stream: str, conn: c.conn}
+	client := newStreamClient(conn, c.cfg.NET.ReadTimeout, c.cfg.NET.ReadBufferSize)
+	request := construct.Request(c.cfg, client)
+	request.TLS = &c.tls
+	request.Env.Encryption = c.tls.Version
+
+	b := newBody(str, reader, c.cfg.NET.ReadTimeout, c.cfg.NET.ReadBufferSize, c.cfg.Body.MaxSize)
+	defer b.release()
+	request.Body = http.NewBody(b)
+	request.Body.Reset(request)
+
+	codecs := codecutil.NewCache(c.codecs, c.acceptString)
+	w := newResponseWriter(c.cfg, str, codecs, c.defaultHeaders)
+
+	statusBuff, targetBuff, headersBuff := construct.Buffers(c.cfg)
+	defer func() {
+		// the request fields are referencing the buffers, so they're kept until the stream is done
+		statusBuff.Release()
+		targetBuff.Release()
+		headersBuff.Release()
+	}()
+
+	parser := http1.NewParser(c.cfg, request, statusBuff, targetBuff, headersBuff)
+	err := c.readRequest(str, reader, parser, request)
+	if err == nil {
+		err = applyDecoders(request, codecs, c.cfg.NET.ReadBufferSize)
+	}
+
+	var (
+		serr    streamError
+		httpErr status.HTTPError
+	)
+
+	switch {
+	case err == nil:
+	case errors.As(err, &serr):
+		c.router.OnError(request, status.ErrCloseConnection)
+		str.CancelRead(quic.StreamErrorCode(serr))
+		str.CancelWrite(quic.StreamErrorCode(serr))
+		return
+	case errors.As(err, &httpErr):
+		// the request is malformed, but the stream is still in a consistent state
+		w.finish(request, respond(request, c.router.OnError(request, err)))
+		return
+	default:
+		c.router.OnError(request, status.ErrCloseConnection)
+		str.CancelRead(errRequestIncomplete)
+		str.CancelWrite(errRequestIncomplete)
+		return
+	}
+
+	resp := respond(request, c.router.OnRequest(request))
+	if request.Hijacked() {
+		_ = conn.Close()
+		return
+	}
+
+	w.finish(request, resp)
+}
+
+// readRequest reads the HEADERS frame and feeds the request into the HTTP/1 parser, therefore
+// applying exactly the same validation and limits.
+func (c *connection) readRequest(
+	str *quic.Stream,
+	reader quicvarint.Reader,
+	parser *http1.Parser,
+	request *http.Request,
+) error {
+	if err := str.SetReadDeadline(timer.Now().Add(c.cfg.NET.ReadTimeout)); err != nil {
+		return err
+	}
+
+	fields, err := c.readHeaders(reader)
+	if err != nil {
+		return err
+	}
+
+	head, err := assembleHead(fields)
+	if err != nil {
+		return err
+	}
+
+	done, extra, err := parser.Parse(head)
+	switch {
+	case err != nil:
+		return err
+	case !done || len(extra) > 0:
+		return status.ErrBadRequest
+	}
+
+	request.Protocol = proto.HTTP3
+
+	return nil
+}
+
+func (c *connection) readHeaders(reader quicvarint.Reader) ([]qpack.HeaderField, error) {
+	for {
+		h, err := readFrameHeader(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case h.typ == frameHeaders:
+			if h.length > c.maxFieldSection {
+				return nil, status.ErrHeaderFieldsTooLarge
+			}
+
+			block := make([]byte, h.length)
+			if _, err = io.ReadFull(reader, block); err != nil {
+				return nil, err
+			}
+
+			fields, err := qpack.NewDecoder(nil).DecodeFull(block)
+			if err != nil {
+				return nil, streamError(errQPACKDecompression)
+			}
+
+			return fields, nil
+		case h.typ == frameData, forbiddenOnRequestStream(h.typ):
+			return nil, streamError(errFrameUnexpected)
+		default:
+			// unknown frame types must be ignored
+			if err = skipFrame(reader, h); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// assembleHead validates the header fields as RFC 9114 section 4.3 requires and represents them
+// as an HTTP/1.1 request head.
+func assembleHead(fields []qpack.HeaderField) ([]byte, error) {
+	var (
+		methodName, scheme, authority, path string
+		cookies                             []string
+		regularMet                          bool
+	)
+
+	for _, f := range fields {
+		if !validValue(f.Value) {
+			return nil, status.ErrBadRequest
+		}
+
+		if !f.IsPseudo() {
+			regularMet = true
+			continue
+		}
+
+		var field *string
+		switch f.Name {
+		case ":method":
+			field = &methodName
+		case ":scheme":
+			field = &scheme
+		case ":authority":
+			field = &authority
+		case ":path":
+			field = &path
+		default:
+			return nil, status.ErrBadRequest
+		}
+
+		if regularMet || len(*field) > 0 || len(f.Value) == 0 {
+			// pseudo-headers must precede regular ones and must not be repeated
+			return nil, status.ErrBadRequest
+		}
+
+		*field = f.Value
+	}
+
+	switch {
+	case methodName == method.CONNECT.String():
+		return nil, status.ErrNotImplemented
+	case len(methodName) == 0 || len(scheme) == 0 || len(path) == 0:
+		return nil, status.ErrBadRequest
+	case strings.Contains(methodName, " ") || strings.Contains(path, " "):
+		return nil, status.ErrBadRequest
+	}
+
+	head := make([]byte, 0, 256)
+	head = append(head, methodName...)
+	head = append(head, ' ')
+	head = append(head, path...)
+	head = append(head, " HTTP/1.1\r\n"...)
+
+	if len(authority) > 0 {
+		head = appendField(head, "host", authority)
+	}
+
+	for _, f := range fields {
+		if f.IsPseudo() {
+			continue
+		}
+
+		if !validName(f.Name) {
+			return nil, status.ErrBadRequest
+		}
+
+		if connectionSpecific(f.Name) {
+			return nil, status.ErrBadRequest
+		}
+
+		switch f.Name {
+		case "te":
+			if f.Value != "trailers" {
+				return nil, status.ErrBadRequest
+			}
+		case "host":
+			if len(authority) > 0 {
+				// :authority takes precedence
+				continue
+			}
+		case "cookie":
+			// the cookie header might be split into multiple fields for better compression
+			cookies = append(cookies, f.Value)
+			continue
+		}
+
+		head = appendField(head, f.Name, f.Value)
+	}
+
+	if len(cookies) > 0 {
+		head = appendField(head, "cookie", strings.Join(cookies, "; "))
+	}
+
+	return append(head, "\r\n"...), nil
+}
+
+func appendField(head []byte, name, value string) []byte {
+	head = append(head, name...)
+	head = append(head, ": "...)
+	head = append(head, value...)
+	return append(head, "\r\n"...)
+}
+
+// validName reports whether the header name contains neither uppercase characters nor ones
+// which are forbidden in field names.
+func validName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case c >= 'A' && c <= 'Z', c <= ' ', c == ':', c == 0x7f:
+			return false
+		}
+	}
+
+	return true
+}
+
+func validValue(value string) bool {
+	return !strings.ContainsAny(value, "\r\n\x00")
+}
+
+func applyDecoders(request *http.Request, codecs codecutil.Cache, bufferSize int) error {
+	tokens := request.ContentEncoding
+
+	for i := len(tokens); i > 0; i-- {
+		c := codecs.Get(tokens[i-1])
+		if c == nil {
+			return status.ErrUnsupportedEncoding
+		}
+
+		if err := c.ResetDecompressor(request.Body.Fetcher, bufferSize); err != nil {
+			return status.ErrInternalServerError
+		}
+
+		request.Body.Fetcher = c
+	}
+
+	return nil
+}
+
+// respond ensures the passed resp is not nil, otherwise http.Respond(req) is returned
+func respond(req *http.Request, resp *http.Response) *http.Response {
+	if resp != nil {
+		return resp
+	}
+
+	return http.Respond(req)
+}
@@ -0,0 +1,61 @@
+package http3
+
+import (
+	"testing"
+
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/quic-go/qpack"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssembleHead(t *testing.T) {
+	fields := func(pairs ...string) []qpack.HeaderField {
+		f := make([]qpack.HeaderField, 0, len(pairs)/2)
+		for i := 0; i < len(pairs); i += 2 {
+			f = append(f, qpack.HeaderField{Name: pairs[i], Value: pairs[i+1]})
+		}
+
+		return f
+	}
+
+	request := func(pairs ...string) []qpack.HeaderField {
+		return append(fields(":method", "GET", ":scheme", "https", ":path", "/"), fields(pairs...)...)
+	}
+
+	t.Run("authority and cookies", func(t *testing.T) {
+		head, err := assembleHead(request(
+			":authority", "example.com", "host", "other.com",
+			"cookie", "a=b", "accept", "*/*", "cookie", "c=d",
+		))
+		require.NoError(t, err)
+		want := "GET / HTTP/1.1\r\nhost: example.com\r\naccept: */*\r\ncookie: a=b; c=d\r\n\r\n"
+		require.Equal(t, want, string(head))
+	})
+
+	t.Run("host without authority", func(t *testing.T) {
+		head, err := assembleHead(request("host", "example.com"))
+		require.NoError(t, err)
+		require.Equal(t, "GET / HTTP/1.1\r\nhost: example.com\r\n\r\n", string(head))
+	})
+
+	for _, tc := range []struct {
+		Name   string
+		Fields []qpack.HeaderField
+		Err    error
+	}{
+		{"missing path", fields(":method", "GET", ":scheme", "https"), status.ErrBadRequest},
+		{"repeated pseudo-header", request(":path", "/"), status.ErrBadRequest},
+		{"unknown pseudo-header", request(":protocol", "websocket"), status.ErrBadRequest},
+		{"pseudo-header after regular", append(fields("accept", "*/*"), request()...), status.ErrBadRequest},
+		{"uppercase name", request("Accept", "*/*"), status.ErrBadRequest},
+		{"connection-specific", request("connection", "keep-alive"), status.ErrBadRequest},
+		{"te", request("te", "gzip"), status.ErrBadRequest},
+		{"crlf in value", request("accept", "*/*\r\nfoo: bar"), status.ErrBadRequest},
+		{"connect", fields(":method", "CONNECT", ":authority", "example.com:443"), status.ErrNotImplemented},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := assembleHead(tc.Fields)
+			require.ErrorIs(t, err, tc.Err)
+		})
+	}
+}
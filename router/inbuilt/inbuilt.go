@@ -1,6 +1,7 @@
 package inbuilt
 
 import (
+	"io"
 	"path"
 
 	"github.com/indigo-web/indigo/http"
@@ -32,6 +33,13 @@ type Router struct {
 	children     []*Router
 	traceHandler Handler
 	errHandlers  errorHandlers
+	names        routeNames
+	records      *routeRecords
+	mounts       []*mount
+	parent       *Router
+	routesOutput io.Writer
+	// lastPattern is the pattern of the most recently registered route, the one Name refers to
+	lastPattern string
 }
 
 // New constructs a new instance of inbuilt router
@@ -39,6 +47,8 @@ func New() *Router {
 	return &Router{
 		registrar:   newRegistrar(),
 		errHandlers: newErrorHandlers(),
+		names:       make(routeNames),
+		records:     new(routeRecords),
 	}
 }
 
@@ -53,6 +63,13 @@ func (r *Router) Route(method method.Method, path string, handler Handler, middl
 		panic(err)
 	}
 
+	r.lastPattern = r.prefix + path
+	r.recordRoute(routeRecord{
+		method:      method,
+		pattern:     uri.Normalize(r.prefix + path),
+		middlewares: len(middlewares),
+	})
+
 	return r
 }
 
@@ -112,6 +129,9 @@ func (r *Router) Group(prefix string) *Router {
 		prefix:      r.prefix + prefix,
 		registrar:   newRegistrar(),
 		errHandlers: r.errHandlers,
+		names:       r.names,
+		records:     r.records,
+		parent:      r,
 	}
 
 	r.children = append(r.children, subrouter)
@@ -130,6 +150,7 @@ func (r *Router) prepare() error {
 		}
 
 		r.mutators = append(r.mutators, child.mutators...)
+		r.mounts = append(r.mounts, child.mounts...)
 	}
 
 	r.applyMiddlewares()
@@ -149,7 +170,14 @@ func (r *Router) Resource(path string) Resource {
 // to be aliased. Otherwise, ANY requests matching alias will be aliased, which might not always be
 // the desired behavior.
 func (r *Router) Alias(from, to string, forMethods ...method.Method) *Router {
-	return r.Mutator(mutator.Alias(path.Join(r.prefix, from), to, forMethods...))
+	from = path.Join(r.prefix, from)
+	r.recordRoute(routeRecord{
+		methods: forMethods,
+		pattern: from,
+		alias:   to,
+	})
+
+	return r.Mutator(mutator.Alias(from, to, forMethods...))
 }
 
 type Mutator = internal.Mutator
@@ -179,6 +207,8 @@ type runtimeRouter struct {
 	errHandlers   errorHandlers
 	serverOptions string
 	mutators      []Mutator
+	// mounts are the mounted routers by their patterns
+	mounts map[string]*mount
 }
 
 func (r *Router) Build() router.Router {
@@ -199,6 +229,16 @@ func (r *Router) Build() router.Router {
 		rmap = r.registrar.AsMap()
 	}
 
+	mounts := make(map[string]*mount, len(r.mounts))
+	for _, m := range r.mounts {
+		m.router = m.builder.Build()
+		for _, pattern := range m.patterns {
+			mounts[pattern] = m
+		}
+	}
+
+	r.printRoutes()
+
 	return &runtimeRouter{
 		enableTRACE:   r.enableTRACE,
 		isStatic:      !isDynamic,
@@ -207,6 +247,7 @@ func (r *Router) Build() router.Router {
 		errHandlers:   r.errHandlers,
 		serverOptions: r.registrar.Options(r.enableTRACE),
 		mutators:      r.mutators,
+		mounts:        mounts,
 	}
 }
 
@@ -234,6 +275,8 @@ func (r *runtimeRouter) onRequest(request *http.Request) *http.Response {
 		return r.onError(request, status.ErrNotFound)
 	}
 
+	request.Env.Route = e.pattern
+
 	handler := getHandler(request.Method, e.methods)
 	if handler == nil {
 		request.Env.AllowedMethods = e.allow
@@ -248,9 +291,27 @@ func (r *runtimeRouter) onRequest(request *http.Request) *http.Response {
 func (r *runtimeRouter) OnError(request *http.Request, err error) *http.Response {
 	r.runMutators(request)
 
+	if m := r.lookupMount(request); m != nil {
+		return m.onError(request, err)
+	}
+
 	return r.onError(request, err)
 }
 
+// lookupMount returns the mounted router, responsible for the request path, if any.
+func (r *runtimeRouter) lookupMount(request *http.Request) *mount {
+	if len(r.mounts) == 0 || len(request.Path) == 0 || request.Path[0] != '/' {
+		return nil
+	}
+
+	e, found := r.tree.Lookup(uri.Normalize(request.Path), request.Vars)
+	if !found {
+		return nil
+	}
+
+	return r.mounts[e.pattern]
+}
+
 func (r *runtimeRouter) onError(request *http.Request, err error) *http.Response {
 	switch {
 	case request.Method == method.OPTIONS && request.Path == "*": // server-wide options
@@ -322,7 +383,11 @@ func compose(handler Handler, middlewares []Middleware) Handler {
 func getHandler(reqMethod method.Method, mlut methodLUT) Handler {
 	handler := mlut[reqMethod]
 	if handler == nil && reqMethod == method.HEAD {
-		return getHandler(method.GET, mlut)
+		handler = mlut[method.GET]
+	}
+
+	if handler == nil {
+		handler = mlut[anyMethod]
 	}
 
 	return handler
@@ -25,6 +25,10 @@ func readbody(t *testing.T, r io.Reader) string {
 	return string(data)
 }
 
+// purge is an extension method. It's registered on the package level, as method.List, which is
+// iterated by some tests, is affected by the registration.
+var purge = method.Register("PURGE")
+
 func getRequest(m method.Method, path string) *http.Request {
 	request := construct.Request(config.Default(), dummy.NewNopClient())
 	request.Method = m
@@ -204,6 +208,17 @@ func TestDynamic(t *testing.T) {
 		testDynamic(t, "/user123", "123", "id", "/user:id", "/user:id/edit")
 		testDynamic(t, "/user123/edit", "123", "id", "/user:id", "/user:id/edit")
 	})
+
+	t.Run("route pattern", func(t *testing.T) {
+		r := New().
+			Get("/user/:id", func(request *http.Request) *http.Response {
+				return request.Respond().Status(request.Env.Route)
+			}).
+			Build()
+
+		resp := r.OnRequest(getRequest(method.GET, "/user/42"))
+		require.Equal(t, "/user/:id", resp.Expose().Status)
+	})
 }
 
 func TestMethodShorthands(t *testing.T) {
@@ -376,7 +391,8 @@ func TestResource(t *testing.T) {
 			Lock(echoMethod).
 			Unlock(echoMethod).
 			Propfind(echoMethod).
-			Proppatch(echoMethod)
+			Proppatch(echoMethod).
+			Route(purge, echoMethod)
 
 		r := raw.Build()
 
@@ -578,3 +594,66 @@ func TestMutators(t *testing.T) {
 
 	require.Equal(t, 3, timesCalled)
 }
+
+func TestExtensionMethods(t *testing.T) {
+	r := New().
+		Get("/", http.Respond).
+		Route(purge, "/", http.Respond).
+		Get("/:id", http.Respond).
+		Route(purge, "/:id", http.Respond)
+
+	for _, r := range []router.Router{r.Build(), New().Route(purge, "/", http.Respond).Build()} {
+		require.Equal(t, status.OK, r.OnRequest(getRequest(purge, "/")).Expose().Code)
+	}
+
+	built := r.Build()
+	resp := built.OnRequest(getRequest(method.POST, "/42")).Expose()
+	require.Equal(t, status.MethodNotAllowed, resp.Code)
+
+	resp = built.OnRequest(getRequest(method.OPTIONS, "/")).Expose()
+	require.Equal(t, "GET, HEAD, PURGE", kv.NewFromPairs(resp.Headers).Value("Allow"))
+}
+
+func TestAny(t *testing.T) {
+	echoMethod := func(req *http.Request) *http.Response {
+		return req.Respond().Status(req.Method.String())
+	}
+	explicit := func(req *http.Request) *http.Response {
+		return req.Respond().Status("explicit")
+	}
+
+	for _, dynamic := range []bool{false, true} {
+		raw := New().
+			Any("/", echoMethod).
+			Get("/", explicit)
+		if dynamic {
+			raw.Get("/:id", http.Respond)
+		}
+
+		r := raw.Build()
+
+		for _, m := range method.List {
+			want := m.String()
+			if m == method.GET || m == method.HEAD {
+				// explicit handlers take precedence, while HEAD falls back to GET first
+				want = "explicit"
+			}
+
+			resp := r.OnRequest(getRequest(m, "/")).Expose()
+			require.Equal(t, status.OK, resp.Code)
+			require.Equal(t, want, resp.Status, m.String())
+		}
+	}
+
+	t.Run("duplicate", func(t *testing.T) {
+		require.Panics(t, func() {
+			New().Any("/", http.Respond).Any("/", http.Respond)
+		})
+	})
+
+	t.Run("routes", func(t *testing.T) {
+		routes := New().Any("/", http.Respond).Routes()
+		require.Len(t, routes, 1)
+		require.Equal(t, "*", routes[0].Method)
+	})
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/internal/strutil"
+	"github.com/indigo-web/indigo/router/inbuilt"
+)
+
+// BasicValidator checks the credentials and returns the principal they belong to. The principal
+// is stored in Request.Env.Principal.
+type BasicValidator func(username, password string) (principal any, ok bool)
+
+// Users returns a BasicValidator over a static username-password mapping. Passwords are compared
+// in constant time. The username is used as a principal.
+func Users(users map[string]string) BasicValidator {
+	return func(username, password string) (any, bool) {
+		expected, found := users[username]
+		// compare digests instead of raw values, so that neither password length nor user
+		// existence can be inferred from the timing
+		var (
+			want = sha256.Sum256([]byte(expected))
+			got  = sha256.Sum256([]byte(password))
+		)
+
+		if subtle.ConstantTimeCompare(want[:], got[:]) != 1 || !found {
+			return nil, false
+		}
+
+		return username, true
+	}
+}
+
+// Basic authenticates requests via the Basic HTTP authentication scheme (RFC 7617).
+func Basic(realm string, validate BasicValidator) inbuilt.Middleware {
+	challenge := "Basic realm=" + strconv.Quote(realm) + ", charset=\"UTF-8\""
+
+	return func(next inbuilt.Handler, request *http.Request) *http.Response {
+		credentials, found := credentials(request, "Basic")
+		if !found {
+			return unauthorized(request, challenge)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(credentials)
+		if err != nil {
+			return http.Error(request, status.ErrBadRequest)
+		}
+
+		username, password, found := strings.Cut(string(decoded), ":")
+		if !found {
+			return http.Error(request, status.ErrBadRequest)
+		}
+
+		principal, ok := validate(username, password)
+		if !ok {
+			return unauthorized(request, challenge)
+		}
+
+		request.Env.Principal = principal
+
+		return next(request)
+	}
+}
+
+// credentials extracts credentials of the scheme from the Authorization header.
+func credentials(request *http.Request, scheme string) (string, bool) {
+	for value := range request.Headers.Values("authorization") {
+		if len(value) > len(scheme) && value[len(scheme)] == ' ' &&
+			strutil.CmpFoldSafe(value[:len(scheme)], scheme) {
+			return strings.TrimSpace(value[len(scheme)+1:]), true
+		}
+	}
+
+	return "", false
+}
+
+func unauthorized(request *http.Request, challenge string) *http.Response {
+	return request.Respond().
+		Error(status.ErrUnauthorized).
+		Header("WWW-Authenticate", challenge)
+}
@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/internal/construct"
+	"github.com/indigo-web/indigo/transport/dummy"
+	json "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+)
+
+func getRequest(headers ...string) *http.Request {
+	request := construct.Request(config.Default(), dummy.NewNopClient())
+	for i := 0; i < len(headers); i += 2 {
+		request.Headers.Add(headers[i], headers[i+1])
+	}
+
+	return request
+}
+
+func principal(request *http.Request) *http.Response {
+	return http.String(request, fmt.Sprint(request.Env.Principal))
+}
+
+func header(resp *http.Response, key string) string {
+	for _, h := range resp.Expose().Headers {
+		if h.Key == key {
+			return h.Value
+		}
+	}
+
+	return ""
+}
+
+func TestBasic(t *testing.T) {
+	mware := Basic("admin area", Users(map[string]string{"admin": "qwerty"}))
+	basic := func(credentials string) string {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+
+	resp := mware(principal, getRequest("Authorization", basic("admin:qwerty")))
+	require.Equal(t, status.OK, resp.Expose().Code)
+
+	for _, credentials := range []string{"admin:qwert", "admin:qwertyy", "root:qwerty", "admin:"} {
+		resp = mware(principal, getRequest("Authorization", basic(credentials)))
+		require.Equal(t, status.Unauthorized, resp.Expose().Code, credentials)
+		require.Equal(t, `Basic realm="admin area", charset="UTF-8"`, header(resp, "WWW-Authenticate"))
+	}
+
+	resp = mware(principal, getRequest())
+	require.Equal(t, status.Unauthorized, resp.Expose().Code)
+
+	resp = mware(principal, getRequest("Authorization", "Basic !!!"))
+	require.Equal(t, status.BadRequest, resp.Expose().Code)
+}
+
+func TestAPIKey(t *testing.T) {
+	validator := Keys(map[string]any{"secret-key": "service-a"})
+
+	t.Run("header", func(t *testing.T) {
+		mware := APIKey("api", validator)
+		request := getRequest("X-API-Key", "secret-key")
+		resp := mware(principal, request)
+		require.Equal(t, status.OK, resp.Expose().Code)
+		require.Equal(t, "service-a", request.Env.Principal)
+
+		resp = mware(principal, getRequest("X-API-Key", "secret-kez"))
+		require.Equal(t, status.Unauthorized, resp.Expose().Code)
+		require.Equal(t, `APIKey realm="api"`, header(resp, "WWW-Authenticate"))
+	})
+
+	t.Run("query and cookie", func(t *testing.T) {
+		mware := APIKey("api", validator, FromQuery("key"), FromCookie("key"))
+		request := getRequest()
+		request.Params.Add("key", "secret-key")
+		require.Equal(t, status.OK, mware(principal, request).Expose().Code)
+
+		request = getRequest("Cookie", "key=secret-key")
+		require.Equal(t, status.OK, mware(principal, request).Expose().Code)
+
+		request = getRequest("X-API-Key", "secret-key")
+		require.Equal(t, status.Unauthorized, mware(principal, request).Expose().Code)
+	})
+}
+
+func b64json(model any) string {
+	data, err := json.Marshal(model)
+	if err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func sign(alg string, key any, claims map[string]any) string {
+	signed := b64json(map[string]string{"alg": alg, "typ": "JWT"}) + "." + b64json(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+
+	switch alg {
+	case HS256:
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case RS256:
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	case ES256:
+		r, s, _ := ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case EdDSA:
+		signature = ed25519.Sign(key.(ed25519.PrivateKey), []byte(signed))
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	secret := []byte("hmac secret")
+
+	claims := map[string]any{
+		"sub":  "user",
+		"iss":  "indigo",
+		"aud":  []string{"api", "web"},
+		"exp":  time.Now().Add(time.Hour).Unix(),
+		"role": "admin",
+	}
+
+	for _, tc := range []struct {
+		Alg     string
+		Private any
+		Public  any
+	}{
+		{HS256, secret, secret},
+		{RS256, rsaKey, &rsaKey.PublicKey},
+		{ES256, ecKey, &ecKey.PublicKey},
+		{EdDSA, edKey, edPub},
+	} {
+		t.Run(tc.Alg, func(t *testing.T) {
+			mware := Bearer("api", JWT(JWTParams{
+				Keys:     StaticKey(tc.Alg, tc.Public),
+				Issuer:   "indigo",
+				Audience: "api",
+			}))
+
+			token := sign(tc.Alg, tc.Private, claims)
+			request := getRequest("Authorization", "Bearer "+token)
+			resp := mware(principal, request)
+			require.Equal(t, status.OK, resp.Expose().Code)
+			require.IsType(t, new(Claims), request.Env.Principal)
+			c := request.Env.Principal.(*Claims)
+			require.Equal(t, "user", c.Subject)
+			require.Equal(t, "admin", c.Extra["role"])
+
+			request = getRequest("Authorization", "Bearer "+token[:len(token)-4]+"AAAA")
+			resp = mware(principal, request)
+			require.Equal(t, status.Unauthorized, resp.Expose().Code)
+			require.Contains(t, header(resp, "WWW-Authenticate"), `error="invalid_token"`)
+		})
+	}
+
+	t.Run("claims", func(t *testing.T) {
+		params := JWTParams{
+			Keys:              StaticKey(HS256, secret),
+			Algorithms:        []string{HS256},
+			Issuer:            "indigo",
+			Audience:          "api",
+			Leeway:            time.Minute,
+			RequireExpiration: true,
+		}
+		now := time.Now()
+
+		for _, tc := range []struct {
+			Name   string
+			Claims map[string]any
+			Err    error
+		}{
+			{"valid", map[string]any{"iss": "indigo", "aud": "api", "exp": now.Unix() + 10}, nil},
+			{"leeway", map[string]any{"iss": "indigo", "aud": "api", "exp": now.Unix() - 10}, nil},
+			{"expired", map[string]any{"iss": "indigo", "aud": "api", "exp": now.Unix() - 100}, ErrTokenExpired},
+			{"no exp", map[string]any{"iss": "indigo", "aud": "api"}, ErrMissingExpiration},
+			{"nbf", map[string]any{"iss": "indigo", "aud": "api", "exp": now.Unix() + 1000, "nbf": now.Unix() + 100}, ErrTokenNotValidYet},
+			{"iss", map[string]any{"iss": "other", "aud": "api", "exp": now.Unix() + 10}, ErrInvalidIssuer},
+			{"aud", map[string]any{"iss": "indigo", "aud": []string{"web"}, "exp": now.Unix() + 10}, ErrInvalidAudience},
+		} {
+			_, err := verifyJWT(params, sign(HS256, secret, tc.Claims), now)
+			require.Equal(t, tc.Err, err, tc.Name)
+		}
+	})
+
+	t.Run("numeric dates", func(t *testing.T) {
+		params := JWTParams{
+			Keys:       StaticKey(HS256, secret),
+			Algorithms: []string{HS256},
+			Leeway:     1500 * time.Millisecond,
+		}
+		now := time.Unix(1760000000, 0)
+
+		c, err := verifyJWT(params, sign(HS256, secret, map[string]any{
+			"exp": 1760000000.5, "nbf": 1760000001.9, "iat": 1759999999.999,
+		}), now)
+		require.NoError(t, err)
+		require.Equal(t, NumericDate(1760000000), c.ExpiresAt)
+		require.Equal(t, NumericDate(1760000001), c.NotBefore)
+		require.Equal(t, NumericDate(1759999999), c.IssuedAt)
+
+		// the sub-second leeway isn't dropped
+		for _, tc := range []struct {
+			Claims map[string]any
+			Now    time.Time
+			Err    error
+		}{
+			{map[string]any{"exp": 1760000000}, now.Add(1400 * time.Millisecond), nil},
+			{map[string]any{"exp": 1760000000}, now.Add(1500 * time.Millisecond), ErrTokenExpired},
+			{map[string]any{"nbf": 1760000002}, now.Add(500 * time.Millisecond), nil},
+			{map[string]any{"nbf": 1760000002}, now.Add(400 * time.Millisecond), ErrTokenNotValidYet},
+		} {
+			_, err = verifyJWT(params, sign(HS256, secret, tc.Claims), tc.Now)
+			require.Equal(t, tc.Err, err, tc.Claims)
+		}
+
+		_, err = verifyJWT(params, sign(HS256, secret, map[string]any{"exp": "soon"}), now)
+		require.Equal(t, ErrMalformedToken, err)
+	})
+
+	t.Run("alg none and confusion", func(t *testing.T) {
+		params := JWTParams{Keys: StaticKey(RS256, &rsaKey.PublicKey), Algorithms: []string{RS256}}
+		none := b64json(map[string]string{"alg": "none"}) + "." + b64json(claims) + "."
+		_, err := verifyJWT(params, none, time.Now())
+		require.Equal(t, ErrUnknownAlgorithm, err)
+
+		_, err = verifyJWT(params, sign(HS256, secret, claims), time.Now())
+		require.Equal(t, ErrUnknownAlgorithm, err)
+	})
+
+	t.Run("JWKS", func(t *testing.T) {
+		b64 := func(b []byte) string {
+			return base64.RawURLEncoding.EncodeToString(b)
+		}
+
+		jwks := map[string]any{
+			"keys": []map[string]string{
+				{"kty": "RSA", "kid": "rsa", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+				{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecKey.X.Bytes()), "y": b64(ecKey.Y.Bytes())},
+				{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(edPub)},
+				{"kty": "oct", "kid": "hs", "k": b64(secret)},
+				{"kty": "RSA", "kid": "enc", "use": "enc", "n": b64(rsaKey.N.Bytes()), "e": "AQAB"},
+			},
+		}
+
+		data, err := json.Marshal(jwks)
+		require.NoError(t, err)
+		ks, err := ParseJWKS(data)
+		require.NoError(t, err)
+		require.Equal(t, 4, ks.Len())
+
+		validate := JWT(JWTParams{Keys: ks})
+		for alg, key := range map[string]any{RS256: rsaKey, ES256: ecKey, EdDSA: edKey, HS256: secret} {
+			_, err = validate(sign(alg, key, claims))
+			require.NoError(t, err, alg)
+		}
+
+		_, err = ks.Key("unknown", RS256)
+		require.Equal(t, ErrUnknownKey, err)
+	})
+}
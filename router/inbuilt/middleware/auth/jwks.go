@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+
+	json "github.com/json-iterator/go"
+)
+
+// KeyProvider resolves a verification key by its identifier and the signing algorithm. The key
+// must be []byte for HS256, *rsa.PublicKey for RS256, *ecdsa.PublicKey for ES256 and
+// ed25519.PublicKey for EdDSA. Implementations may fetch keys remotely, but must be safe for
+// concurrent use.
+type KeyProvider interface {
+	Key(kid, alg string) (any, error)
+}
+
+type staticKey struct {
+	alg string
+	key any
+}
+
+// StaticKey is a KeyProvider serving a single key for the single algorithm, regardless of
+// the key identifier.
+func StaticKey(alg string, key any) KeyProvider {
+	return staticKey{alg, key}
+}
+
+func (s staticKey) Key(_, alg string) (any, error) {
+	if alg != s.alg {
+		return nil, ErrUnknownKey
+	}
+
+	return s.key, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+type jwkEntry struct {
+	kid, alg string
+	key      any
+}
+
+// KeySet is a static KeyProvider built from a JSON Web Key Set (RFC 7517).
+type KeySet struct {
+	keys []jwkEntry
+}
+
+// ParseJWKS parses a JSON Web Key Set. Keys of unsupported types or curves, as well as those
+// not intended for signatures, are skipped.
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("malformed JWKS: %w", err)
+	}
+
+	ks := new(KeySet)
+
+	for _, k := range set.Keys {
+		if len(k.Use) > 0 && k.Use != "sig" {
+			continue
+		}
+
+		key, alg, err := k.decode()
+		if err != nil {
+			return nil, fmt.Errorf("JWK %q: %w", k.Kid, err)
+		}
+
+		if key == nil {
+			continue
+		}
+
+		if len(k.Alg) > 0 && k.Alg != alg {
+			continue
+		}
+
+		ks.keys = append(ks.keys, jwkEntry{kid: k.Kid, alg: alg, key: key})
+	}
+
+	return ks, nil
+}
+
+// JWKSFile reads and parses a JSON Web Key Set from the file.
+func JWKSFile(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseJWKS(data)
+}
+
+// Key returns the key matching the identifier and the algorithm. If the token carries no
+// identifier, the first key of the algorithm is picked.
+func (k *KeySet) Key(kid, alg string) (any, error) {
+	for _, entry := range k.keys {
+		if entry.alg == alg && (len(kid) == 0 || entry.kid == kid) {
+			return entry.key, nil
+		}
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// Len returns the number of usable keys in the set.
+func (k *KeySet) Len() int {
+	return len(k.keys)
+}
+
+func (k jwk) decode() (key any, alg string, err error) {
+	switch k.Kty {
+	case "oct":
+		secret, err := b64(k.K)
+		return secret, HS256, err
+	case "RSA":
+		n, err := b64(k.N)
+		if err != nil {
+			return nil, "", err
+		}
+
+		e, err := b64(k.E)
+		if err != nil {
+			return nil, "", err
+		}
+
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() > 1<<31-1 {
+			return nil, "", fmt.Errorf("exponent is too large")
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, RS256, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, "", nil
+		}
+
+		x, err := b64(k.X)
+		if err != nil {
+			return nil, "", err
+		}
+
+		y, err := b64(k.Y)
+		if err != nil {
+			return nil, "", err
+		}
+
+		// validate the point is on the curve
+		uncompressed := append([]byte{4}, append(pad(x, 32), pad(y, 32)...)...)
+		if _, err = ecdh.P256().NewPublicKey(uncompressed); err != nil {
+			return nil, "", err
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, ES256, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, "", nil
+		}
+
+		x, err := b64(k.X)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, "", fmt.Errorf("bad Ed25519 key size")
+		}
+
+		return ed25519.PublicKey(x), EdDSA, nil
+	default:
+		return nil, "", nil
+	}
+}
+
+func b64(str string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(str)
+}
+
+func pad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+
+	return append(make([]byte, size-len(b)), b...)
+}
@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	json "github.com/json-iterator/go"
+)
+
+var (
+	ErrInvalidKey        = errors.New("invalid API key")
+	ErrMalformedToken    = errors.New("malformed token")
+	ErrUnknownAlgorithm  = errors.New("unsupported signing algorithm")
+	ErrUnknownKey        = errors.New("unknown signing key")
+	ErrInvalidSignature  = errors.New("invalid signature")
+	ErrTokenExpired      = errors.New("token is expired")
+	ErrTokenNotValidYet  = errors.New("token is not valid yet")
+	ErrInvalidIssuer     = errors.New("invalid issuer")
+	ErrInvalidAudience   = errors.New("invalid audience")
+	ErrMissingExpiration = errors.New("token has no expiration time")
+)
+
+// Supported signing algorithms.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+// Audience is the `aud` claim, which may be represented either by a single string or by an array.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+// NumericDate is the number of seconds since the epoch. Non-integer values are permitted
+// (RFC 7519, 2), however they're truncated to whole seconds.
+type NumericDate int64
+
+func (n *NumericDate) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+
+	if len(number) == 0 {
+		// null
+		return nil
+	}
+
+	seconds, err := number.Int64()
+	if err != nil {
+		fractional, err := number.Float64()
+		if err != nil {
+			return err
+		}
+
+		seconds = int64(fractional)
+	}
+
+	*n = NumericDate(seconds)
+	return nil
+}
+
+// Time returns the date as time.Time.
+func (n NumericDate) Time() time.Time {
+	return time.Unix(int64(n), 0)
+}
+
+// Claims are the decoded payload of a verified token. Registered claims are mapped to the fields,
+// all the others are available via Extra.
+type Claims struct {
+	Issuer    string         `json:"iss"`
+	Subject   string         `json:"sub"`
+	Audience  Audience       `json:"aud"`
+	ExpiresAt NumericDate    `json:"exp"`
+	NotBefore NumericDate    `json:"nbf"`
+	IssuedAt  NumericDate    `json:"iat"`
+	ID        string         `json:"jti"`
+	Extra     map[string]any `json:"-"`
+}
+
+type JWTParams struct {
+	// Keys provides verification keys. See StaticKey and JWKS.
+	Keys KeyProvider
+	// Algorithms restricts accepted signing algorithms. Defaults to all the supported ones,
+	// which are HS256, RS256, ES256 and EdDSA. The `none` algorithm is never accepted.
+	Algorithms []string
+	// Issuer, if set, must match the `iss` claim.
+	Issuer string
+	// Audience, if set, must be contained in the `aud` claim.
+	Audience string
+	// Leeway is the tolerated clock skew for `exp` and `nbf` claims.
+	Leeway time.Duration
+	// RequireExpiration refuses tokens with no `exp` claim.
+	RequireExpiration bool
+}
+
+// JWT returns a TokenValidator verifying compact-serialized JSON Web Signatures (RFC 7515) and
+// checking their claims (RFC 7519). The principal is the *Claims.
+func JWT(params JWTParams) TokenValidator {
+	if params.Keys == nil {
+		panic("auth: no JWT keys provider")
+	}
+
+	if len(params.Algorithms) == 0 {
+		params.Algorithms = []string{HS256, RS256, ES256, EdDSA}
+	}
+
+	return func(token string) (any, error) {
+		return verifyJWT(params, token, time.Now())
+	}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+func verifyJWT(params JWTParams, token string, now time.Time) (*Claims, error) {
+	rawHeader, rest, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrMalformedToken
+	}
+
+	rawPayload, rawSignature, ok := strings.Cut(rest, ".")
+	if !ok || strings.IndexByte(rawSignature, '.') != -1 {
+		return nil, ErrMalformedToken
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(rawHeader, &header); err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(params.Algorithms, header.Alg) {
+		return nil, ErrUnknownAlgorithm
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(rawSignature)
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	key, err := params.Keys.Key(header.Kid, header.Alg)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := token[:len(rawHeader)+1+len(rawPayload)]
+	if err = verifySignature(header.Alg, key, signed, signature); err != nil {
+		return nil, err
+	}
+
+	claims := new(Claims)
+	if err = decodeSegment(rawPayload, claims); err != nil {
+		return nil, err
+	}
+
+	if err = decodeSegment(rawPayload, &claims.Extra); err != nil {
+		return nil, err
+	}
+
+	return claims, validateClaims(params, claims, now)
+}
+
+func decodeSegment(segment string, into any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformedToken
+	}
+
+	if err = json.Unmarshal(data, into); err != nil {
+		return ErrMalformedToken
+	}
+
+	return nil
+}
+
+func verifySignature(alg string, key any, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+
+	var valid bool
+
+	switch alg {
+	case HS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrUnknownKey
+		}
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signed))
+		valid = hmac.Equal(mac.Sum(nil), signature)
+	case RS256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrUnknownKey
+		}
+
+		valid = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) == nil
+	case ES256:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrUnknownKey
+		}
+
+		if len(signature) != 64 {
+			return ErrInvalidSignature
+		}
+
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		valid = ecdsa.Verify(pub, digest[:], r, s)
+	case EdDSA:
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return ErrUnknownKey
+		}
+
+		valid = ed25519.Verify(pub, []byte(signed), signature)
+	default:
+		return ErrUnknownAlgorithm
+	}
+
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func validateClaims(params JWTParams, claims *Claims, now time.Time) error {
+	switch {
+	case claims.ExpiresAt == 0 && params.RequireExpiration:
+		return ErrMissingExpiration
+	case claims.ExpiresAt != 0 && !now.Before(claims.ExpiresAt.Time().Add(params.Leeway)):
+		return ErrTokenExpired
+	case claims.NotBefore != 0 && now.Before(claims.NotBefore.Time().Add(-params.Leeway)):
+		return ErrTokenNotValidYet
+	case len(params.Issuer) > 0 && claims.Issuer != params.Issuer:
+		return ErrInvalidIssuer
+	case len(params.Audience) > 0 && !slices.Contains(claims.Audience, params.Audience):
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"strconv"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/router/inbuilt"
+)
+
+// TokenValidator checks the token and returns the principal it belongs to. The principal is
+// stored in Request.Env.Principal. Returned errors are used as a human-readable description
+// in the challenge and must therefore not expose any sensitive details.
+type TokenValidator func(token string) (principal any, err error)
+
+// Bearer authenticates requests via the Bearer authentication scheme (RFC 6750). See JWT for
+// a validator of JSON Web Tokens.
+func Bearer(realm string, validate TokenValidator) inbuilt.Middleware {
+	challenge := "Bearer realm=" + strconv.Quote(realm)
+
+	return func(next inbuilt.Handler, request *http.Request) *http.Response {
+		token, found := credentials(request, "Bearer")
+		if !found {
+			return unauthorized(request, challenge)
+		}
+
+		principal, err := validate(token)
+		if err != nil {
+			return unauthorized(request, challenge+
+				`, error="invalid_token", error_description=`+strconv.Quote(err.Error()),
+			)
+		}
+
+		request.Env.Principal = principal
+
+		return next(request)
+	}
+}
+
+// Source extracts a raw credential from the request.
+type Source func(request *http.Request) (value string, found bool)
+
+// FromHeader looks the credential up in the request header.
+func FromHeader(name string) Source {
+	return func(request *http.Request) (string, bool) {
+		return request.Headers.Lookup(name)
+	}
+}
+
+// FromQuery looks the credential up in the URI parameters. Please note that URIs tend to be
+// logged, which makes it the least secure way to pass credentials.
+func FromQuery(name string) Source {
+	return func(request *http.Request) (string, bool) {
+		return request.Params.Lookup(name)
+	}
+}
+
+// FromCookie looks the credential up in the request cookies.
+func FromCookie(name string) Source {
+	return func(request *http.Request) (string, bool) {
+		jar, err := request.Cookies()
+		if err != nil {
+			return "", false
+		}
+
+		return jar.Lookup(name)
+	}
+}
+
+// Keys returns a TokenValidator over a static mapping of API keys to their principals. Keys are
+// compared in constant time.
+func Keys(keys map[string]any) TokenValidator {
+	type entry struct {
+		digest    [sha256.Size]byte
+		principal any
+	}
+
+	entries := make([]entry, 0, len(keys))
+	for key, principal := range keys {
+		entries = append(entries, entry{sha256.Sum256([]byte(key)), principal})
+	}
+
+	return func(token string) (any, error) {
+		var (
+			digest    = sha256.Sum256([]byte(token))
+			principal any
+			found     int
+		)
+
+		// walk through all the keys unconditionally, so the timing doesn't depend on the match
+		for _, e := range entries {
+			match := subtle.ConstantTimeCompare(e.digest[:], digest[:])
+			if match == 1 {
+				principal = e.principal
+			}
+
+			found |= match
+		}
+
+		if found != 1 {
+			return nil, ErrInvalidKey
+		}
+
+		return principal, nil
+	}
+}
+
+// APIKey authenticates requests by API keys, extracted from the first source yielding a value.
+func APIKey(realm string, validate TokenValidator, sources ...Source) inbuilt.Middleware {
+	if len(sources) == 0 {
+		sources = append(sources, FromHeader("X-API-Key"))
+	}
+
+	challenge := "APIKey realm=" + strconv.Quote(realm)
+
+	return func(next inbuilt.Handler, request *http.Request) *http.Response {
+		var (
+			key   string
+			found bool
+		)
+
+		for _, source := range sources {
+			if key, found = source(request); found {
+				break
+			}
+		}
+
+		if !found {
+			return unauthorized(request, challenge)
+		}
+
+		principal, err := validate(key)
+		if err != nil {
+			return unauthorized(request, challenge)
+		}
+
+		request.Env.Principal = principal
+
+		return next(request)
+	}
+}
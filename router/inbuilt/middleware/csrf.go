@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/cookie"
+	"github.com/indigo-web/indigo/http/method"
+	"github.com/indigo-web/indigo/http/mime"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/router/inbuilt"
+)
+
+// CSRFStore keeps synchronizer tokens on the server side. How tokens are bound to clients
+// (e.g. by a session) is up to the implementation.
+type CSRFStore interface {
+	// Token returns the token issued to the request's client, if any.
+	Token(request *http.Request) (token string, found bool)
+	// SetToken binds a freshly issued token to the request's client.
+	SetToken(request *http.Request, token string) error
+}
+
+type CSRFParams struct {
+	// Cookie is a template for the cookie carrying the token in the double-submit mode. Its value
+	// is ignored. Not used if Store is set.
+	Cookie cookie.Builder
+	// Header is the request header the token is looked up in first.
+	Header string
+	// FormField is the name of the form field, which is checked if the token wasn't submitted via
+	// the Header. Both urlencoded and multipart forms are supported.
+	FormField string
+	// Store enables the synchronizer token pattern, keeping the tokens server-side. Otherwise,
+	// the double-submit cookie pattern is used.
+	Store CSRFStore
+	// TokenLength is the number of random bytes each token consists of.
+	TokenLength int
+}
+
+// DefaultCSRF returns default CSRF params, using the double-submit cookie pattern. The cookie
+// isn't HttpOnly, so it can be read by scripts in order to be echoed via the header.
+func DefaultCSRF() CSRFParams {
+	return CSRFParams{
+		Cookie: cookie.Build("csrf_token", "").
+			Path("/").
+			SameSite(cookie.SameSiteLax).
+			Secure(true),
+		Header:      "X-CSRF-Token",
+		FormField:   "csrf_token",
+		TokenLength: 32,
+	}
+}
+
+// CSRF protects state-changing requests (all except GET, HEAD, OPTIONS and TRACE) from
+// cross-site request forgery by requiring a token, issued by the server earlier, to be submitted
+// either via a header or a form field. The issued token is available in Request.Env.CSRFToken.
+// Requests missing a valid token are answered with 403 Forbidden.
+//
+// Please note that looking the token up in forms consumes the request body, so handlers can
+// access it only via Body.Form, Body.Bytes or Body.String afterward.
+func CSRF(optionalParams ...CSRFParams) inbuilt.Middleware {
+	params := optional(optionalParams, DefaultCSRF())
+	cookieName := params.Cookie.Cookie().Name
+
+	return func(next inbuilt.Handler, request *http.Request) *http.Response {
+		expected, err := expectedCSRFToken(request, params.Store, cookieName)
+		if err != nil {
+			return http.Error(request, err)
+		}
+
+		if !isSafeMethod(request.Method) {
+			submitted := submittedCSRFToken(request, params.Header, params.FormField)
+			if len(expected) == 0 || subtle.ConstantTimeCompare([]byte(expected), []byte(submitted)) != 1 {
+				return request.Respond().
+					Code(status.Forbidden).
+					String("CSRF token is missing or invalid")
+			}
+		}
+
+		if len(expected) > 0 {
+			request.Env.CSRFToken = expected
+			return next(request)
+		}
+
+		token := randomToken(params.TokenLength)
+		request.Env.CSRFToken = token
+
+		if params.Store != nil {
+			if err = params.Store.SetToken(request, token); err != nil {
+				return http.Error(request, err)
+			}
+
+			return next(request)
+		}
+
+		c := params.Cookie.Cookie()
+		c.Value = token
+
+		return next(request).Cookie(c)
+	}
+}
+
+func expectedCSRFToken(request *http.Request, store CSRFStore, cookieName string) (string, error) {
+	if store != nil {
+		token, _ := store.Token(request)
+		return token, nil
+	}
+
+	jar, err := request.Cookies()
+	if err != nil {
+		return "", status.ErrBadRequest
+	}
+
+	return jar.Value(cookieName), nil
+}
+
+func submittedCSRFToken(request *http.Request, header, field string) string {
+	if token := request.Headers.Value(header); len(token) > 0 || len(field) == 0 {
+		return token
+	}
+
+	if !mime.Complies(mime.FormUrlencoded, request.ContentType) &&
+		!mime.Complies(mime.Multipart, request.ContentType) {
+		return ""
+	}
+
+	form, err := request.Body.Form()
+	if err != nil {
+		return ""
+	}
+
+	data, _ := form.Name(field)
+	return data.Value
+}
+
+func isSafeMethod(m method.Method) bool {
+	switch m {
+	case method.GET, method.HEAD, method.OPTIONS, method.TRACE:
+		return true
+	default:
+		return false
+	}
+}
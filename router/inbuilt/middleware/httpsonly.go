@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"net"
 	"strings"
 
 	"github.com/indigo-web/indigo/http"
@@ -17,8 +18,9 @@ type HTTPOnlyParams struct {
 	Port string
 }
 
-// HTTPSOnly redirects all http requests to https. In case no Host header is provided,
-// 400 Bad Request will be returned without calling the actual handler.
+// HTTPSOnly redirects all http requests to https, preserving the original request-target
+// including the query. In case no host is known, 400 Bad Request will be returned without
+// calling the actual handler.
 func HTTPSOnly(optionalParams ...HTTPOnlyParams) inbuilt.Middleware {
 	params := optional(optionalParams, HTTPOnlyParams{})
 
@@ -30,7 +32,7 @@ func HTTPSOnly(optionalParams ...HTTPOnlyParams) inbuilt.Middleware {
 		host := params.RedirectTo
 
 		if len(host) == 0 {
-			host = removePort(request.Headers.Value("host"))
+			host = removePort(request.Host())
 			if len(host) == 0 {
 				return request.Respond().
 					Code(status.BadRequest).
@@ -38,25 +40,28 @@ func HTTPSOnly(optionalParams ...HTTPOnlyParams) inbuilt.Middleware {
 			}
 		}
 
-		host += ":" + params.Port
-		if len(request.Env.AliasFrom) > 0 {
-			host += request.Env.AliasFrom
-		} else {
-			host += request.Path
+		if len(params.Port) > 0 {
+			host += ":" + params.Port
 		}
 
+		// the raw request-target isn't affected by aliases, so the original path is preserved
 		return request.Respond().
 			Code(status.MovedPermanently).
-			Header("Location", "https://"+host)
+			Header("Location", "https://"+host+request.RequestURI())
 	}
 }
 
 func removePort(str string) string {
-	if colon := strings.IndexByte(str, ':'); colon != -1 {
-		return str[:colon]
+	host, _, err := net.SplitHostPort(str)
+	switch {
+	case err != nil:
+		return str
+	case strings.IndexByte(host, ':') != -1:
+		// IPv6 addresses must stay enclosed in brackets
+		return "[" + host + "]"
+	default:
+		return host
 	}
-
-	return str
 }
 
 func optional[T any](custom []T, default_ T) T {
@@ -24,7 +24,7 @@ func LogRequests(loggers ...Logger) inbuilt.Middleware {
 		}
 
 		for _, logger := range loggers {
-			logger.Printf("%s %s %d", request.Method.String(), request.Path, response.Expose().Code)
+			logger.Printf("%s %s %d", request.Method.String(), request.URL(), response.Expose().Code)
 		}
 
 		return response
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/method"
+	"github.com/indigo-web/indigo/http/mime"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/internal/construct"
+	"github.com/indigo-web/indigo/transport/dummy"
+	"github.com/stretchr/testify/require"
+)
+
+func getRequest(m method.Method, body string) *http.Request {
+	request := construct.Request(config.Default(), dummy.NewNopClient())
+	request.Method = m
+	request.Path = "/"
+	request.ContentLength = len(body)
+	request.Body = http.NewBody(dummy.NewMockClient([]byte(body)))
+	request.Body.Reset(request)
+
+	return request
+}
+
+type csrfStore struct {
+	token string
+}
+
+func (c *csrfStore) Token(*http.Request) (string, bool) {
+	return c.token, len(c.token) > 0
+}
+
+func (c *csrfStore) SetToken(_ *http.Request, token string) error {
+	c.token = token
+	return nil
+}
+
+func TestCSRF(t *testing.T) {
+	handler := func(request *http.Request) *http.Response {
+		return http.String(request, request.Env.CSRFToken)
+	}
+
+	t.Run("double-submit issue token", func(t *testing.T) {
+		request := getRequest(method.GET, "")
+		resp := CSRF()(handler, request).Expose()
+		require.Equal(t, status.OK, resp.Code)
+		require.Len(t, resp.Cookies, 1)
+		require.Equal(t, "csrf_token", resp.Cookies[0].Name)
+		require.Equal(t, request.Env.CSRFToken, resp.Cookies[0].Value)
+		require.True(t, resp.Cookies[0].Secure)
+	})
+
+	t.Run("double-submit via header", func(t *testing.T) {
+		request := getRequest(method.POST, "")
+		request.Headers.Add("Cookie", "csrf_token=hello")
+		request.Headers.Add("X-CSRF-Token", "hello")
+		resp := CSRF()(handler, request).Expose()
+		require.Equal(t, status.OK, resp.Code)
+		require.Empty(t, resp.Cookies)
+	})
+
+	t.Run("double-submit via form", func(t *testing.T) {
+		request := getRequest(method.POST, "csrf_token=hello&name=world")
+		request.ContentType = mime.FormUrlencoded
+		request.Headers.Add("Cookie", "csrf_token=hello")
+		resp := CSRF()(handler, request).Expose()
+		require.Equal(t, status.OK, resp.Code)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		request := getRequest(method.POST, "")
+		request.Headers.Add("Cookie", "csrf_token=hello")
+		request.Headers.Add("X-CSRF-Token", "world")
+		resp := CSRF()(handler, request).Expose()
+		require.Equal(t, status.Forbidden, resp.Code)
+	})
+
+	t.Run("no cookie", func(t *testing.T) {
+		request := getRequest(method.DELETE, "")
+		request.Headers.Add("X-CSRF-Token", "")
+		resp := CSRF()(handler, request).Expose()
+		require.Equal(t, status.Forbidden, resp.Code)
+	})
+
+	t.Run("synchronizer", func(t *testing.T) {
+		params := DefaultCSRF()
+		store := new(csrfStore)
+		params.Store = store
+		mware := CSRF(params)
+
+		request := getRequest(method.GET, "")
+		resp := mware(handler, request).Expose()
+		require.Equal(t, status.OK, resp.Code)
+		require.Empty(t, resp.Cookies)
+		require.NotEmpty(t, store.token)
+		require.Equal(t, store.token, request.Env.CSRFToken)
+
+		request = getRequest(method.POST, "")
+		request.Headers.Add("X-CSRF-Token", store.token)
+		require.Equal(t, status.OK, mware(handler, request).Expose().Code)
+
+		request = getRequest(method.POST, "")
+		request.Headers.Add("X-CSRF-Token", "definitely not a token")
+		require.Equal(t, status.Forbidden, mware(handler, request).Expose().Code)
+	})
+}
+
+func TestSecureHeaders(t *testing.T) {
+	handler := func(request *http.Request) *http.Response {
+		return http.String(request, request.Env.Nonce).
+			Header("Referrer-Policy", "no-referrer")
+	}
+
+	t.Run("plain", func(t *testing.T) {
+		request := getRequest(method.GET, "")
+		resp := SecureHeaders()(handler, request).Expose()
+		require.NotEmpty(t, request.Env.Nonce)
+
+		var csp, referrer, hsts []string
+		for _, header := range resp.Headers {
+			switch header.Key {
+			case "Content-Security-Policy":
+				csp = append(csp, header.Value)
+			case "Referrer-Policy":
+				referrer = append(referrer, header.Value)
+			case "Strict-Transport-Security":
+				hsts = append(hsts, header.Value)
+			}
+		}
+
+		require.Len(t, csp, 1)
+		require.Contains(t, csp[0], "'nonce-"+request.Env.Nonce+"'")
+		require.Equal(t, []string{"no-referrer"}, referrer)
+		require.Empty(t, hsts)
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		request := getRequest(method.GET, "")
+		request.Env.Encryption = 0x0304
+		resp := SecureHeaders()(handler, request).Expose()
+
+		var found bool
+		for _, header := range resp.Headers {
+			found = found || header.Key == "Strict-Transport-Security"
+		}
+
+		require.True(t, found)
+	})
+}
+
+func TestHTTPSOnly(t *testing.T) {
+	handler := func(request *http.Request) *http.Response {
+		return http.Respond(request)
+	}
+
+	t.Run("redirect", func(t *testing.T) {
+		request := getRequest(method.GET, "")
+		request.Headers.Add("Host", "example.com:8080")
+		request.Path, request.Target = "/b", "/a%20b?c=d"
+		resp := HTTPSOnly()(handler, request).Expose()
+		require.Equal(t, status.MovedPermanently, resp.Code)
+		require.Contains(t, resp.Headers, http.Header{Key: "Location", Value: "https://example.com/a%20b?c=d"})
+	})
+
+	t.Run("absolute form with port", func(t *testing.T) {
+		request := getRequest(method.GET, "")
+		request.Headers.Add("Host", "localhost")
+		request.Target, request.Authority = "http://[::1]:8080/a?b", "[::1]:8080"
+		resp := HTTPSOnly(HTTPOnlyParams{Port: "8443"})(handler, request).Expose()
+		require.Equal(t, status.MovedPermanently, resp.Code)
+		require.Contains(t, resp.Headers, http.Header{Key: "Location", Value: "https://[::1]:8443/a?b"})
+	})
+
+	t.Run("no host", func(t *testing.T) {
+		resp := HTTPSOnly()(handler, getRequest(method.GET, "")).Expose()
+		require.Equal(t, status.BadRequest, resp.Code)
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		request := getRequest(method.GET, "")
+		request.Env.Encryption = 0x0304
+		resp := HTTPSOnly()(handler, request).Expose()
+		require.Equal(t, status.OK, resp.Code)
+	})
+}
+
+func TestRedirect(t *testing.T) {
+	handler := func(request *http.Request) *http.Response {
+		return http.Respond(request)
+	}
+
+	request := getRequest(method.GET, "")
+	request.Query = "a=b"
+	resp := Redirect("/", "/new")(handler, request).Expose()
+	require.Equal(t, status.TemporaryRedirect, resp.Code)
+	require.Contains(t, resp.Headers, http.Header{Key: "Location", Value: "/new?a=b"})
+
+	resp = Redirect("/", "/new?c=d")(handler, request).Expose()
+	require.Contains(t, resp.Headers, http.Header{Key: "Location", Value: "/new?c=d"})
+}
@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// State is an opaque per-key record, interpreted by an Algorithm. It's kept deliberately flat,
+// so external stores can serialize it trivially.
+type State struct {
+	A, B  float64
+	Stamp int64
+}
+
+// Result describes the outcome of a single Algorithm.Take call.
+type Result struct {
+	// Allowed tells whether the request may pass.
+	Allowed bool
+	// Limit is the maximal number of requests within the quota.
+	Limit int
+	// Remaining is the number of requests left within the current quota.
+	Remaining int
+	// Reset is the time left until the quota is fully restored.
+	Reset time.Duration
+	// RetryAfter is the time left until the next request would be allowed. Zero if Allowed.
+	RetryAfter time.Duration
+}
+
+// Algorithm decides whether a request must be let through, mutating the per-key State.
+type Algorithm interface {
+	// Take tries to consume a single request from the state.
+	Take(state *State, now time.Time) Result
+	// TTL is the duration an idle state must be kept for. After it expires, the state
+	// is equivalent to a zero one.
+	TTL() time.Duration
+}
+
+type tokenBucket struct {
+	burst    float64
+	interval time.Duration
+}
+
+// TokenBucket allows bursts up to the burst value, refilling one token every interval.
+// Uses A as the number of available tokens and Stamp as the last refill time.
+func TokenBucket(burst int, interval time.Duration) Algorithm {
+	if burst <= 0 || interval <= 0 {
+		panic("ratelimit: burst and interval must be positive")
+	}
+
+	return tokenBucket{
+		burst:    float64(burst),
+		interval: interval,
+	}
+}
+
+func (t tokenBucket) Take(state *State, now time.Time) Result {
+	if state.Stamp == 0 {
+		state.A = t.burst
+	} else {
+		elapsed := now.UnixNano() - state.Stamp
+		state.A = min(t.burst, state.A+float64(elapsed)/float64(t.interval))
+	}
+
+	state.Stamp = now.UnixNano()
+	result := Result{Limit: int(t.burst)}
+
+	if state.A >= 1 {
+		state.A--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = t.duration(1 - state.A)
+	}
+
+	result.Remaining = int(state.A)
+	result.Reset = t.duration(t.burst - state.A)
+
+	return result
+}
+
+func (t tokenBucket) TTL() time.Duration {
+	return t.duration(t.burst)
+}
+
+func (t tokenBucket) duration(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens * float64(t.interval)))
+}
+
+type slidingWindow struct {
+	limit  int
+	window time.Duration
+}
+
+// SlidingWindow allows at most limit requests within any window-long period. It approximates
+// the period by weighting the previous fixed window's counter. Uses A as the previous
+// window's counter, B as the current window's one and Stamp as the current window's start.
+func SlidingWindow(limit int, window time.Duration) Algorithm {
+	if limit <= 0 || window <= 0 {
+		panic("ratelimit: limit and window must be positive")
+	}
+
+	return slidingWindow{
+		limit:  limit,
+		window: window,
+	}
+}
+
+func (s slidingWindow) Take(state *State, now time.Time) Result {
+	var (
+		nanos  = now.UnixNano()
+		window = int64(s.window)
+		start  = nanos - nanos%window
+	)
+
+	switch {
+	case state.Stamp == start:
+	case state.Stamp == start-window:
+		state.A, state.B = state.B, 0
+		state.Stamp = start
+	default:
+		state.A, state.B = 0, 0
+		state.Stamp = start
+	}
+
+	var (
+		elapsed = nanos - start
+		weight  = float64(window-elapsed) / float64(window)
+		used    = state.A*weight + state.B
+		result  = Result{
+			Limit: s.limit,
+			Reset: time.Duration(window - elapsed),
+		}
+	)
+
+	if used+1 <= float64(s.limit) {
+		state.B++
+		used++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = s.retryAfter(state, elapsed)
+	}
+
+	result.Remaining = max(0, s.limit-int(math.Ceil(used)))
+	if state.B > 0 {
+		// requests made in the current window keep affecting the next one, too
+		result.Reset += s.window
+	}
+
+	return result
+}
+
+// retryAfter computes the time until the weighted previous window decays enough to let
+// one more request through.
+func (s slidingWindow) retryAfter(state *State, elapsed int64) time.Duration {
+	window := float64(s.window)
+	free := float64(s.limit) - 1 - state.B
+
+	if free < 0 || state.A == 0 {
+		// the current window alone is exhausted, so wait until the next one begins. Counters
+		// of the current window then turn into the previous one, so account for them as well.
+		next := window - float64(elapsed)
+		if excess := state.B - float64(s.limit) + 1; excess > 0 {
+			next += window * excess / state.B
+		}
+
+		return time.Duration(math.Ceil(next))
+	}
+
+	// solve A * (window - elapsed - t) / window <= free for t
+	t := window - float64(elapsed) - free*window/state.A
+	return time.Duration(math.Ceil(max(t, 0)))
+}
+
+func (s slidingWindow) TTL() time.Duration {
+	return 2 * s.window
+}
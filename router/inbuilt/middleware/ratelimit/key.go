@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/internal/iputil"
+)
+
+// Key extracts the identity requests are accounted by. Requests resulting in an empty key
+// aren't limited.
+type Key func(request *http.Request) string
+
+// ByIP identifies requests by their remote address. If the remote address belongs to one of the
+// trusted proxies (either single addresses or CIDR ranges), the X-Forwarded-For header is walked
+// from right to left and the first untrusted address is picked instead. Panics if any of the
+// proxies is malformed.
+func ByIP(trustedProxies ...string) Key {
+	trusted, err := iputil.ParsePrefixes(trustedProxies)
+	if err != nil {
+		panic(fmt.Errorf("ratelimit: bad trusted proxy: %w", err))
+	}
+
+	return func(request *http.Request) string {
+		remote, ok := iputil.FromAddr(request.Remote)
+		if !ok {
+			return ""
+		}
+
+		if !iputil.Contains(trusted, remote) {
+			return remote.String()
+		}
+
+		// the header might be split into multiple lines, which must be treated as a single list
+		values := slices.Collect(request.Headers.Values("x-forwarded-for"))
+		hops := strings.Split(strings.Join(values, ","), ",")
+
+		for i := len(hops) - 1; i >= 0 && len(values) > 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// the header is forged or broken. Don't trust anything to the left of it
+				return remote.String()
+			}
+
+			remote = addr.Unmap()
+			if !iputil.Contains(trusted, remote) {
+				return remote.String()
+			}
+		}
+
+		return remote.String()
+	}
+}
+
+// ByHeader identifies requests by the value of the header.
+func ByHeader(name string) Key {
+	return func(request *http.Request) string {
+		return request.Headers.Value(name)
+	}
+}
+
+// ByRoute identifies requests by the matched route pattern, therefore sharing a single quota
+// between all the clients requesting the endpoint.
+func ByRoute() Key {
+	return func(request *http.Request) string {
+		return request.Env.Route
+	}
+}
+
+// Join combines multiple keys into a single one, e.g. in order to limit each client per route.
+// If any of keys is empty, so is the resulting key.
+func Join(keys ...Key) Key {
+	return func(request *http.Request) string {
+		var b strings.Builder
+
+		for i, key := range keys {
+			value := key(request)
+			if len(value) == 0 {
+				return ""
+			}
+
+			if i > 0 {
+				b.WriteByte(0)
+			}
+
+			b.WriteString(value)
+		}
+
+		return b.String()
+	}
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/router/inbuilt"
+)
+
+type Params struct {
+	// Algorithm decides whether a request is let through. Mandatory.
+	Algorithm Algorithm
+	// Key identifies the client. Defaults to ByIP() without trusted proxies.
+	Key Key
+	// Store keeps per-key states. Defaults to a new MemoryStore. Share a single store between
+	// multiple middlewares only if their keys never collide.
+	Store Store
+	// Headers enables RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers in
+	// responses to allowed requests. Rejected requests carry them unconditionally.
+	Headers bool
+	// FailOpen lets requests through if the Store returns an error. Otherwise, such
+	// requests are answered with 503 Service Unavailable.
+	FailOpen bool
+}
+
+// New returns a middleware limiting requests rate. Requests exceeding the limit are answered
+// with 429 Too Many Requests, carrying Retry-After and RateLimit-* headers.
+func New(params Params) inbuilt.Middleware {
+	if params.Algorithm == nil {
+		panic("ratelimit: no algorithm specified")
+	}
+
+	if params.Key == nil {
+		params.Key = ByIP()
+	}
+
+	if params.Store == nil {
+		params.Store = NewMemoryStore()
+	}
+
+	ttl := params.Algorithm.TTL()
+
+	return func(next inbuilt.Handler, request *http.Request) *http.Response {
+		key := params.Key(request)
+		if len(key) == 0 {
+			return next(request)
+		}
+
+		var (
+			result Result
+			now    = time.Now()
+		)
+
+		err := params.Store.Update(key, now, ttl, func(state *State) {
+			result = params.Algorithm.Take(state, now)
+		})
+		if err != nil {
+			if params.FailOpen {
+				return next(request)
+			}
+
+			return http.Error(request, status.ErrServiceUnavailable)
+		}
+
+		if !result.Allowed {
+			return withHeaders(http.Error(request, status.ErrTooManyRequests), result).
+				Header("Retry-After", seconds(result.RetryAfter))
+		}
+
+		if !params.Headers {
+			return next(request)
+		}
+
+		return withHeaders(next(request), result)
+	}
+}
+
+func withHeaders(response *http.Response, result Result) *http.Response {
+	return response.
+		Header("RateLimit-Limit", strconv.Itoa(result.Limit)).
+		Header("RateLimit-Remaining", strconv.Itoa(result.Remaining)).
+		Header("RateLimit-Reset", seconds(result.Reset))
+}
+
+// seconds rounds the duration up to whole seconds, as partial ones aren't allowed.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/internal/construct"
+	"github.com/indigo-web/indigo/transport/dummy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	var (
+		state State
+		now   = time.Unix(1000, 0)
+		tb    = TokenBucket(3, time.Second)
+	)
+
+	for i := 2; i >= 0; i-- {
+		result := tb.Take(&state, now)
+		require.True(t, result.Allowed)
+		require.Equal(t, i, result.Remaining)
+	}
+
+	result := tb.Take(&state, now)
+	require.False(t, result.Allowed)
+	require.Equal(t, time.Second, result.RetryAfter)
+	require.Equal(t, 3*time.Second, result.Reset)
+
+	result = tb.Take(&state, now.Add(1500*time.Millisecond))
+	require.True(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+	result = tb.Take(&state, now.Add(1500*time.Millisecond))
+	require.False(t, result.Allowed)
+	require.Equal(t, 500*time.Millisecond, result.RetryAfter)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	var (
+		state State
+		now   = time.Unix(1000, 0)
+		sw    = SlidingWindow(4, time.Second)
+	)
+
+	for range 4 {
+		require.True(t, sw.Take(&state, now).Allowed)
+	}
+
+	result := sw.Take(&state, now)
+	require.False(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+	require.Equal(t, 1250*time.Millisecond, result.RetryAfter)
+
+	// 4 * (1 - 0.5) = 2 requests are still accounted from the previous window
+	now = now.Add(1500 * time.Millisecond)
+	require.True(t, sw.Take(&state, now).Allowed)
+	require.True(t, sw.Take(&state, now).Allowed)
+	result = sw.Take(&state, now)
+	require.False(t, result.Allowed)
+	require.Equal(t, 250*time.Millisecond, result.RetryAfter)
+
+	require.True(t, sw.Take(&state, now.Add(result.RetryAfter)).Allowed)
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(3)
+	require.Len(t, store.shards, 4)
+
+	now := time.Unix(1000, 0)
+	inc := func(state *State) {
+		state.A++
+	}
+
+	require.NoError(t, store.Update("a", now, time.Second, inc))
+	require.NoError(t, store.Update("a", now, time.Second, func(state *State) {
+		require.Equal(t, 1.0, state.A)
+	}))
+	require.NoError(t, store.Update("a", now.Add(time.Second), time.Second, func(state *State) {
+		require.Zero(t, state.A, "must be expired")
+	}))
+
+	for _, key := range []string{"b", "c", "d", "e", "f"} {
+		require.NoError(t, store.Update(key, now, time.Second, inc))
+	}
+
+	require.NoError(t, store.Update("g", now.Add(time.Hour), time.Second, inc))
+	// only the shard containing "g" was swept, so can't strictly assert the exact number
+	require.LessOrEqual(t, store.Len(), 7)
+}
+
+func TestByIP(t *testing.T) {
+	getRequest := func(remote string, xff ...string) *http.Request {
+		request := construct.Request(config.Default(), dummy.NewNopClient())
+		addr, err := net.ResolveTCPAddr("tcp", remote)
+		if err != nil {
+			panic(err)
+		}
+
+		request.Remote = addr
+		for _, value := range xff {
+			request.Headers.Add("X-Forwarded-For", value)
+		}
+
+		return request
+	}
+
+	key := ByIP("10.0.0.0/8", "::1")
+	require.Equal(t, "1.2.3.4", key(getRequest("1.2.3.4:80", "5.6.7.8")))
+	require.Equal(t, "5.6.7.8", key(getRequest("10.1.2.3:80", "9.9.9.9, 5.6.7.8, 10.0.0.2")))
+	require.Equal(t, "5.6.7.8", key(getRequest("[::1]:80", "5.6.7.8")))
+	require.Equal(t, "10.1.2.3", key(getRequest("10.1.2.3:80", "garbage")))
+	require.Equal(t, "10.1.2.3", key(getRequest("10.1.2.3:80")))
+}
+
+func TestMiddleware(t *testing.T) {
+	mware := New(Params{
+		Algorithm: TokenBucket(1, time.Hour),
+		Key:       ByHeader("X-Client"),
+		Headers:   true,
+	})
+
+	getRequest := func(client string) *http.Request {
+		request := construct.Request(config.Default(), dummy.NewNopClient())
+		request.Headers.Add("X-Client", client)
+		return request
+	}
+
+	resp := mware(http.Respond, getRequest("a")).Expose()
+	require.Equal(t, status.OK, resp.Code)
+	require.Contains(t, resp.Headers, http.Header{Key: "RateLimit-Remaining", Value: "0"})
+
+	resp = mware(http.Respond, getRequest("a")).Expose()
+	require.Equal(t, status.TooManyRequests, resp.Code)
+	require.Contains(t, resp.Headers, http.Header{Key: "Retry-After", Value: "3600"})
+
+	resp = mware(http.Respond, getRequest("b")).Expose()
+	require.Equal(t, status.OK, resp.Code)
+
+	resp = mware(http.Respond, getRequest("")).Expose()
+	require.Equal(t, status.OK, resp.Code)
+}
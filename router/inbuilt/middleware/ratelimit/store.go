@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// Store keeps per-key states. Implementations backed by external storages (e.g. Redis) must
+// guarantee Update to be atomic across all the application instances sharing the storage.
+type Store interface {
+	// Update calls fn with the state stored by the key (zero state if there's none) and saves
+	// the mutated state, keeping it for at least ttl since now.
+	Update(key string, now time.Time, ttl time.Duration, fn func(state *State)) error
+}
+
+// DefaultShards is the number of shards used by NewMemoryStore if none is specified.
+const DefaultShards = 64
+
+type memoryEntry struct {
+	state   State
+	expires int64
+}
+
+type memoryShard struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	nextSweep int64
+}
+
+// MemoryStore is an in-memory Store, split into shards in order to reduce lock contention.
+// Expired entries are evicted lazily: each shard is periodically swept on access.
+type MemoryStore struct {
+	seed   maphash.Seed
+	shards []memoryShard
+}
+
+// NewMemoryStore returns a new instance of MemoryStore. The number of shards is rounded up
+// to the nearest power of two.
+func NewMemoryStore(shards ...int) *MemoryStore {
+	n := DefaultShards
+	if len(shards) > 0 && shards[0] > 0 {
+		n = shards[0]
+	}
+
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+
+	store := &MemoryStore{
+		seed:   maphash.MakeSeed(),
+		shards: make([]memoryShard, size),
+	}
+
+	for i := range store.shards {
+		store.shards[i].entries = make(map[string]memoryEntry)
+	}
+
+	return store
+}
+
+func (m *MemoryStore) Update(key string, now time.Time, ttl time.Duration, fn func(state *State)) error {
+	shard := &m.shards[maphash.String(m.seed, key)&uint64(len(m.shards)-1)]
+	nanos := now.UnixNano()
+
+	shard.mu.Lock()
+	if nanos >= shard.nextSweep {
+		shard.sweep(nanos)
+		shard.nextSweep = nanos + int64(ttl)
+	}
+
+	entry, found := shard.entries[key]
+	if found && entry.expires <= nanos {
+		entry = memoryEntry{}
+	}
+
+	fn(&entry.state)
+	entry.expires = nanos + int64(ttl)
+	shard.entries[key] = entry
+	shard.mu.Unlock()
+
+	return nil
+}
+
+// Len returns the number of stored entries, including expired but not yet evicted.
+func (m *MemoryStore) Len() (total int) {
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mu.Lock()
+		total += len(shard.entries)
+		shard.mu.Unlock()
+	}
+
+	return total
+}
+
+func (m *memoryShard) sweep(now int64) {
+	for key, entry := range m.entries {
+		if entry.expires <= now {
+			delete(m.entries, key)
+		}
+	}
+}
@@ -1,19 +1,28 @@
 package middleware
 
 import (
+	"strings"
+
 	"github.com/indigo-web/indigo/http"
 	"github.com/indigo-web/indigo/http/status"
 	"github.com/indigo-web/indigo/router/inbuilt"
 )
 
+// Redirect temporarily redirects requests to the from path onto the to location. The query is
+// carried over, unless the location specifies its own one.
 func Redirect(from, to string) inbuilt.Middleware {
 	return func(next inbuilt.Handler, request *http.Request) *http.Response {
 		if request.Path != from {
 			return next(request)
 		}
 
+		location := to
+		if len(request.Query) > 0 && !strings.Contains(to, "?") {
+			location += "?" + request.Query
+		}
+
 		return request.Respond().
 			Code(status.TemporaryRedirect).
-			Header("Location", to)
+			Header("Location", location)
 	}
 }
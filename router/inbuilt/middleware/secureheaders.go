@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/internal/strutil"
+	"github.com/indigo-web/indigo/router/inbuilt"
+)
+
+// NoncePlaceholder is substituted in the SecureHeadersParams.CSP by a fresh nonce for every request.
+const NoncePlaceholder = "{nonce}"
+
+type SecureHeadersParams struct {
+	// HSTS is the Strict-Transport-Security header value. It is sent over encrypted connections
+	// only, as user-agents ignore it otherwise.
+	HSTS string
+	// CSP is the Content-Security-Policy header value. Each NoncePlaceholder occurrence is
+	// replaced by a per-request nonce, which is also exposed to handlers via Request.Env.Nonce.
+	CSP string
+	// ContentTypeOptions is the X-Content-Type-Options header value.
+	ContentTypeOptions string
+	// ReferrerPolicy is the Referrer-Policy header value.
+	ReferrerPolicy string
+	// PermissionsPolicy is the Permissions-Policy header value.
+	PermissionsPolicy string
+	// CrossOriginOpenerPolicy is the Cross-Origin-Opener-Policy header value.
+	CrossOriginOpenerPolicy string
+	// CrossOriginEmbedderPolicy is the Cross-Origin-Embedder-Policy header value.
+	CrossOriginEmbedderPolicy string
+}
+
+// DefaultSecureHeaders returns reasonably strict defaults. It's recommended to modify them instead
+// of filling SecureHeadersParams from scratch, as empty values disable corresponding headers.
+func DefaultSecureHeaders() SecureHeadersParams {
+	return SecureHeadersParams{
+		HSTS:                      "max-age=63072000; includeSubDomains",
+		CSP:                       "default-src 'self'; script-src 'self' 'nonce-" + NoncePlaceholder + "'; object-src 'none'; base-uri 'self'",
+		ContentTypeOptions:        "nosniff",
+		ReferrerPolicy:            "strict-origin-when-cross-origin",
+		PermissionsPolicy:         "camera=(), microphone=(), geolocation=()",
+		CrossOriginOpenerPolicy:   "same-origin",
+		CrossOriginEmbedderPolicy: "require-corp",
+	}
+}
+
+// SecureHeaders sets security-related headers on every response, unless a handler has already
+// set them itself. If no params are passed, DefaultSecureHeaders are used.
+func SecureHeaders(optionalParams ...SecureHeadersParams) inbuilt.Middleware {
+	params := optional(optionalParams, DefaultSecureHeaders())
+	csp := strings.Split(params.CSP, NoncePlaceholder)
+	needsNonce := len(csp) > 1
+	headers := nonEmptyPairs(
+		"X-Content-Type-Options", params.ContentTypeOptions,
+		"Referrer-Policy", params.ReferrerPolicy,
+		"Permissions-Policy", params.PermissionsPolicy,
+		"Cross-Origin-Opener-Policy", params.CrossOriginOpenerPolicy,
+		"Cross-Origin-Embedder-Policy", params.CrossOriginEmbedderPolicy,
+	)
+
+	return func(next inbuilt.Handler, request *http.Request) *http.Response {
+		if needsNonce {
+			request.Env.Nonce = randomToken(16)
+		}
+
+		response := next(request)
+
+		if len(params.HSTS) > 0 && request.Env.Encryption != 0 {
+			setDefault(response, "Strict-Transport-Security", params.HSTS)
+		}
+
+		if len(params.CSP) > 0 {
+			setDefault(response, "Content-Security-Policy", strings.Join(csp, request.Env.Nonce))
+		}
+
+		for i := 0; i < len(headers); i += 2 {
+			setDefault(response, headers[i], headers[i+1])
+		}
+
+		return response
+	}
+}
+
+// setDefault adds the header only if it isn't present in the response yet.
+func setDefault(response *http.Response, key, value string) {
+	for _, header := range response.Expose().Headers {
+		if strutil.CmpFoldFast(header.Key, key) {
+			return
+		}
+	}
+
+	response.Header(key, value)
+}
+
+func nonEmptyPairs(kv ...string) []string {
+	pairs := make([]string, 0, len(kv))
+
+	for i := 0; i < len(kv); i += 2 {
+		if len(kv[i+1]) > 0 {
+			pairs = append(pairs, kv[i], kv[i+1])
+		}
+	}
+
+	return pairs
+}
+
+// randomToken returns a URL-safe base64 representation of n cryptographically secure random bytes.
+func randomToken(n int) string {
+	buff := make([]byte, n)
+	_, _ = rand.Read(buff)
+
+	return base64.RawURLEncoding.EncodeToString(buff)
+}
@@ -0,0 +1,117 @@
+package sessions
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+var ErrInvalidCookie = errors.New("session cookie is malformed or forged")
+
+// codec signs or encrypts cookie values. The first key is used to produce new values, while
+// all of them are tried in order to verify existing ones, which enables seamless key rotation.
+type codec struct {
+	encrypt bool
+	macs    [][]byte
+	aeads   []cipher.AEAD
+}
+
+func newCodec(keys [][]byte, encrypt bool) codec {
+	if len(keys) == 0 {
+		panic("sessions: at least one key is required")
+	}
+
+	c := codec{encrypt: encrypt}
+
+	for _, key := range keys {
+		// derive independent keys for each purpose, so the same secret can be safely reused
+		c.macs = append(c.macs, derive(key, "indigo/sessions/mac"))
+
+		if encrypt {
+			block, err := aes.NewCipher(derive(key, "indigo/sessions/enc"))
+			if err != nil {
+				panic(err)
+			}
+
+			aead, err := cipher.NewGCM(block)
+			if err != nil {
+				panic(err)
+			}
+
+			c.aeads = append(c.aeads, aead)
+		}
+	}
+
+	return c
+}
+
+// Encode protects the value, binding it to the name in order to prevent swapping values
+// between cookies.
+func (c codec) Encode(name string, value []byte) string {
+	if c.encrypt {
+		aead := c.aeads[0]
+		nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+		_, _ = rand.Read(nonce)
+
+		return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, value, []byte(name)))
+	}
+
+	signed := append(value, sign(c.macs[0], name, value)...)
+	return base64.RawURLEncoding.EncodeToString(signed)
+}
+
+// Decode verifies and recovers the value.
+func (c codec) Decode(name, encoded string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCookie
+	}
+
+	if c.encrypt {
+		for _, aead := range c.aeads {
+			if len(data) < aead.NonceSize() {
+				return nil, ErrInvalidCookie
+			}
+
+			nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+			if plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(name)); err == nil {
+				return plaintext, nil
+			}
+		}
+
+		return nil, ErrInvalidCookie
+	}
+
+	if len(data) < sha256.Size {
+		return nil, ErrInvalidCookie
+	}
+
+	value, mac := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	for _, key := range c.macs {
+		if hmac.Equal(mac, sign(key, name, value)) {
+			return value, nil
+		}
+	}
+
+	return nil, ErrInvalidCookie
+}
+
+func sign(key []byte, name string, value []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write(value)
+
+	return mac.Sum(nil)
+}
+
+func derive(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,152 @@
+package sessions
+
+import (
+	"errors"
+	"time"
+
+	"github.com/indigo-web/indigo/http"
+	json "github.com/json-iterator/go"
+)
+
+var ErrNoSession = errors.New("no session attached to the request")
+
+// Session is a set of key-value pairs persisted across requests of a single client. Values
+// are stored JSON-encoded, so they can be retrieved as their original types via Value.
+type Session struct {
+	id       string
+	data     sessionData
+	modified bool
+	renew    bool
+	destroy  bool
+}
+
+type sessionData struct {
+	Values  map[string]json.RawMessage `json:"v,omitempty"`
+	Flashes []json.RawMessage          `json:"f,omitempty"`
+	Expires int64                      `json:"e"`
+}
+
+func newSession(id string) *Session {
+	return &Session{
+		id: id,
+		data: sessionData{
+			Values: make(map[string]json.RawMessage),
+		},
+	}
+}
+
+// Get returns the session attached to the request by the middleware. Panics if there's none,
+// as it indicates the middleware isn't applied to the endpoint.
+func Get(request *http.Request) *Session {
+	session, ok := request.Env.Session.(*Session)
+	if !ok {
+		panic(ErrNoSession)
+	}
+
+	return session
+}
+
+// ID returns the session identifier. It's empty for cookie-stored sessions.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// IsNew tells whether the session was created during the current request.
+func (s *Session) IsNew() bool {
+	return s.data.Expires == 0
+}
+
+// Set stores the value by the key. The value must be JSON-serializable.
+func (s *Session) Set(key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	s.data.Values[key] = data
+	s.modified = true
+
+	return nil
+}
+
+// Has tells whether there's a value stored by the key.
+func (s *Session) Has(key string) bool {
+	_, found := s.data.Values[key]
+	return found
+}
+
+// Delete removes the value by the key.
+func (s *Session) Delete(key string) {
+	if _, found := s.data.Values[key]; found {
+		delete(s.data.Values, key)
+		s.modified = true
+	}
+}
+
+// Clear removes all the values and flashes.
+func (s *Session) Clear() {
+	clear(s.data.Values)
+	s.data.Flashes = nil
+	s.modified = true
+}
+
+// AddFlash adds a one-time message, which is removed as soon as it is read via Flashes.
+func (s *Session) AddFlash(value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	s.data.Flashes = append(s.data.Flashes, data)
+	s.modified = true
+
+	return nil
+}
+
+// Flashes decodes all the flash messages into T and removes them from the session. Messages
+// which cannot be decoded into T are dropped as well.
+func Flashes[T any](s *Session) []T {
+	if len(s.data.Flashes) == 0 {
+		return nil
+	}
+
+	flashes := make([]T, 0, len(s.data.Flashes))
+	for _, raw := range s.data.Flashes {
+		var value T
+		if json.Unmarshal(raw, &value) == nil {
+			flashes = append(flashes, value)
+		}
+	}
+
+	s.data.Flashes = nil
+	s.modified = true
+
+	return flashes
+}
+
+// Value decodes the value stored by the key into T. The second return value is false if there's
+// no such key or the stored value cannot be represented as T.
+func Value[T any](s *Session, key string) (value T, ok bool) {
+	raw, found := s.data.Values[key]
+	if !found {
+		return value, false
+	}
+
+	return value, json.Unmarshal(raw, &value) == nil
+}
+
+// Regenerate issues a new identifier for the session, keeping its values. It must be called
+// whenever the privilege level changes (e.g. on log in) in order to prevent session fixation.
+func (s *Session) Regenerate() {
+	s.renew = true
+	s.modified = true
+}
+
+// Destroy removes the session both from the store and from the client.
+func (s *Session) Destroy() {
+	s.destroy = true
+}
+
+func (s *Session) expired(now time.Time) bool {
+	return s.data.Expires != 0 && now.Unix() >= s.data.Expires
+}
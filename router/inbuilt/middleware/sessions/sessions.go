@@ -0,0 +1,181 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/cookie"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/router/inbuilt"
+	json "github.com/json-iterator/go"
+)
+
+// MaxCookieSize is the maximal length of a cookie value, which user-agents are guaranteed to
+// accept (RFC 6265, 6.1).
+const MaxCookieSize = 4096
+
+var ErrCookieTooLarge = errors.New("session is too large to be stored in a cookie")
+
+type Params struct {
+	// Cookie is a template for the session cookie. Its value is ignored, and MaxAge is
+	// derived from the TTL.
+	Cookie cookie.Builder
+	// TTL is the lifetime of a session since its last modification.
+	TTL time.Duration
+	// Keys are secrets used to sign (or encrypt) cookies. The first key is used for new cookies,
+	// while the rest are only used for verification of existing ones. So in order to rotate
+	// keys, prepend a new one and drop the oldest one as soon as it's safe.
+	Keys [][]byte
+	// Encrypt makes cookie-stored sessions encrypted instead of just signed, hiding their
+	// contents from clients. Ignored if the Store is set, as cookies carry only identifiers then.
+	Encrypt bool
+	// Store keeps sessions server-side, so cookies carry only session identifiers. If nil,
+	// the whole session is stored in the cookie.
+	Store Store
+}
+
+// Default returns default params for cookie-stored signed sessions.
+func Default(keys ...[]byte) Params {
+	return Params{
+		Cookie: cookie.Build("session", "").
+			Path("/").
+			SameSite(cookie.SameSiteLax).
+			Secure(true).
+			HttpOnly(true),
+		TTL:  24 * time.Hour,
+		Keys: keys,
+	}
+}
+
+// New returns a middleware loading a session for every request, accessible via Get. Changes
+// are persisted automatically after the handler returns.
+func New(params Params) inbuilt.Middleware {
+	var (
+		c    = newCodec(params.Keys, params.Encrypt && params.Store == nil)
+		name = params.Cookie.Cookie().Name
+	)
+
+	return func(next inbuilt.Handler, request *http.Request) *http.Response {
+		now := time.Now()
+		session := load(params.Store, c, name, request, now)
+		request.Env.Session = session
+
+		response := next(request)
+
+		if session.destroy {
+			if params.Store != nil && len(session.id) > 0 {
+				if err := params.Store.Delete(session.id); err != nil {
+					return http.Error(request, status.ErrInternalServerError)
+				}
+			}
+
+			if session.IsNew() {
+				return response
+			}
+
+			expired := params.Cookie.MaxAge(-1).Expires(time.Unix(0, 0)).Cookie()
+			return response.Cookie(expired)
+		}
+
+		if !session.modified {
+			return response
+		}
+
+		value, err := save(params.Store, c, name, session, now.Add(params.TTL))
+		if err != nil {
+			return http.Error(request, status.ErrInternalServerError)
+		}
+
+		sessionCookie := params.Cookie.MaxAge(int(params.TTL / time.Second)).Cookie()
+		sessionCookie.Value = value
+
+		return response.Cookie(sessionCookie)
+	}
+}
+
+func load(store Store, c codec, name string, request *http.Request, now time.Time) *Session {
+	jar, err := request.Cookies()
+	if err != nil {
+		return fresh(store)
+	}
+
+	value, found := jar.Lookup(name)
+	if !found {
+		return fresh(store)
+	}
+
+	decoded, err := c.Decode(name, value)
+	if err != nil {
+		return fresh(store)
+	}
+
+	session := newSession("")
+	data := decoded
+
+	if store != nil {
+		session.id = string(decoded)
+		data, found, err = store.Load(session.id)
+		if err != nil || !found {
+			return fresh(store)
+		}
+	}
+
+	if json.Unmarshal(data, &session.data) != nil || session.expired(now) {
+		return fresh(store)
+	}
+
+	if session.data.Values == nil {
+		session.data.Values = make(map[string]json.RawMessage)
+	}
+
+	return session
+}
+
+func save(store Store, c codec, name string, session *Session, expires time.Time) (string, error) {
+	session.data.Expires = expires.Unix()
+	data, err := json.Marshal(session.data)
+	if err != nil {
+		return "", err
+	}
+
+	if store == nil {
+		value := c.Encode(name, data)
+		if len(value) > MaxCookieSize {
+			return "", ErrCookieTooLarge
+		}
+
+		return value, nil
+	}
+
+	if session.renew {
+		if err = store.Delete(session.id); err != nil {
+			return "", err
+		}
+
+		session.id = newID()
+	}
+
+	if err = store.Save(session.id, data, expires); err != nil {
+		return "", err
+	}
+
+	return c.Encode(name, []byte(session.id)), nil
+}
+
+func fresh(store Store) *Session {
+	if store == nil {
+		return newSession("")
+	}
+
+	return newSession(newID())
+}
+
+func newID() string {
+	buff := make([]byte, 32)
+	_, _ = rand.Read(buff)
+
+	return base64.RawURLEncoding.EncodeToString(buff)
+}
@@ -0,0 +1,210 @@
+package sessions
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/cookie"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/internal/construct"
+	"github.com/indigo-web/indigo/router/inbuilt"
+	"github.com/indigo-web/indigo/transport/dummy"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	Name string
+	Age  int
+}
+
+// roundtrip passes the request with the cookies through the middleware and returns
+// the response with the session cookie, if any.
+func roundtrip(
+	t *testing.T, mware inbuilt.Middleware, handler inbuilt.Handler, cookies ...cookie.Cookie,
+) (*http.Response, []cookie.Cookie) {
+	request := construct.Request(config.Default(), dummy.NewNopClient())
+	for _, c := range cookies {
+		request.Headers.Add("Cookie", c.Name+"="+c.Value)
+	}
+
+	resp := mware(handler, request)
+	require.NotNil(t, resp)
+
+	return resp, resp.Expose().Cookies
+}
+
+func testSessions(t *testing.T, params Params) {
+	mware := New(params)
+
+	set := func(request *http.Request) *http.Response {
+		session := Get(request)
+		require.True(t, session.IsNew())
+		require.NoError(t, session.Set("user", user{"Pavlo", 21}))
+		require.NoError(t, session.AddFlash("welcome"))
+		return request.Respond()
+	}
+
+	_, cookies := roundtrip(t, mware, set)
+	require.Len(t, cookies, 1)
+	require.Equal(t, "session", cookies[0].Name)
+	require.True(t, cookies[0].HttpOnly)
+
+	get := func(request *http.Request) *http.Response {
+		session := Get(request)
+		require.False(t, session.IsNew())
+		u, ok := Value[user](session, "user")
+		require.True(t, ok)
+		require.Equal(t, user{"Pavlo", 21}, u)
+		_, ok = Value[int](session, "user")
+		require.False(t, ok)
+		return request.Respond().String(join(Flashes[string](session)))
+	}
+
+	resp, next := roundtrip(t, mware, get, cookies...)
+	require.Equal(t, "welcome", body(t, resp))
+	require.Len(t, next, 1, "reading flashes must persist the session")
+
+	resp, _ = roundtrip(t, mware, get, next...)
+	require.Empty(t, body(t, resp))
+
+	forged := cookies[0]
+	forged.Value = forged.Value[:len(forged.Value)-2] + "AA"
+	_, _ = roundtrip(t, mware, func(request *http.Request) *http.Response {
+		require.True(t, Get(request).IsNew())
+		return request.Respond()
+	}, forged)
+}
+
+func body(t *testing.T, resp *http.Response) string {
+	data, err := io.ReadAll(resp.Expose().Stream)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func join(strs []string) (result string) {
+	for _, str := range strs {
+		result += str
+	}
+
+	return result
+}
+
+func TestCookieSessions(t *testing.T) {
+	key := []byte("the very secret key")
+
+	t.Run("signed", func(t *testing.T) {
+		testSessions(t, Default(key))
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		params := Default(key)
+		params.Encrypt = true
+		testSessions(t, params)
+	})
+
+	t.Run("key rotation", func(t *testing.T) {
+		_, cookies := roundtrip(t, New(Default(key)), func(request *http.Request) *http.Response {
+			require.NoError(t, Get(request).Set("hello", "world"))
+			return request.Respond()
+		})
+
+		rotated := New(Default([]byte("the newer key"), key))
+		_, _ = roundtrip(t, rotated, func(request *http.Request) *http.Response {
+			value, ok := Value[string](Get(request), "hello")
+			require.True(t, ok)
+			require.Equal(t, "world", value)
+			return request.Respond()
+		}, cookies...)
+
+		dropped := New(Default([]byte("the newer key")))
+		_, _ = roundtrip(t, dropped, func(request *http.Request) *http.Response {
+			require.True(t, Get(request).IsNew())
+			return request.Respond()
+		}, cookies...)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		resp, _ := roundtrip(t, New(Default(key)), func(request *http.Request) *http.Response {
+			require.NoError(t, Get(request).Set("blob", make([]byte, MaxCookieSize)))
+			return request.Respond()
+		})
+		require.Equal(t, status.InternalServerError, resp.Expose().Code)
+	})
+}
+
+func TestStoreSessions(t *testing.T) {
+	key := []byte("the very secret key")
+
+	t.Run("memory", func(t *testing.T) {
+		params := Default(key)
+		params.Store = NewMemoryStore(time.Minute)
+		testSessions(t, params)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		store, err := NewFileStore(t.TempDir())
+		require.NoError(t, err)
+		params := Default(key)
+		params.Store = store
+		testSessions(t, params)
+	})
+
+	t.Run("regenerate and destroy", func(t *testing.T) {
+		store := NewMemoryStore(time.Minute)
+		params := Default(key)
+		params.Store = store
+		mware := New(params)
+
+		var id string
+		_, cookies := roundtrip(t, mware, func(request *http.Request) *http.Response {
+			id = Get(request).ID()
+			require.NoError(t, Get(request).Set("role", "guest"))
+			return request.Respond()
+		})
+		require.Equal(t, 1, store.Len())
+
+		_, cookies = roundtrip(t, mware, func(request *http.Request) *http.Response {
+			session := Get(request)
+			require.Equal(t, id, session.ID())
+			require.NoError(t, session.Set("role", "admin"))
+			session.Regenerate()
+			return request.Respond()
+		}, cookies...)
+		require.Equal(t, 1, store.Len())
+		_, found, _ := store.Load(id)
+		require.False(t, found, "old session must be gone")
+
+		_, cookies = roundtrip(t, mware, func(request *http.Request) *http.Response {
+			session := Get(request)
+			require.NotEqual(t, id, session.ID())
+			role, _ := Value[string](session, "role")
+			require.Equal(t, "admin", role)
+			session.Destroy()
+			return request.Respond()
+		}, cookies...)
+		require.Equal(t, 0, store.Len())
+		require.Len(t, cookies, 1)
+		require.Equal(t, -1, cookies[0].MaxAge)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		store := NewMemoryStore(time.Minute)
+		require.NoError(t, store.Save("a", []byte("b"), time.Now().Add(-time.Second)))
+		_, found, err := store.Load("a")
+		require.NoError(t, err)
+		require.False(t, found)
+
+		fs, err := NewFileStore(t.TempDir())
+		require.NoError(t, err)
+		require.NoError(t, fs.Save("a", []byte("b"), time.Now().Add(-time.Second)))
+		_, found, err = fs.Load("a")
+		require.NoError(t, err)
+		require.False(t, found)
+		_, found, err = fs.Load("../../etc/passwd")
+		require.NoError(t, err)
+		require.False(t, found)
+	})
+}
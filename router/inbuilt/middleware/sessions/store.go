@@ -0,0 +1,217 @@
+package sessions
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Store keeps server-side session data by session identifiers. Implementations must be safe
+// for concurrent use.
+type Store interface {
+	// Load returns the data saved by the id. Missing or expired sessions must be reported via
+	// found=false rather than an error.
+	Load(id string) (data []byte, found bool, err error)
+	// Save stores the data by the id, so it expires at the given moment.
+	Save(id string, data []byte, expires time.Time) error
+	// Delete removes the data by the id. Deleting a non-existing session is not an error.
+	Delete(id string) error
+}
+
+type memoryEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// MemoryStore keeps sessions in memory. Expired sessions are evicted lazily.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	nextSweep time.Time
+	sweep     time.Duration
+}
+
+// NewMemoryStore returns a new instance of MemoryStore. Expired sessions are swept at most once
+// per the sweep period.
+func NewMemoryStore(sweep time.Duration) *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+		sweep:   sweep,
+	}
+}
+
+func (m *MemoryStore) Load(id string) ([]byte, bool, error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.maybeSweep(now)
+	entry, found := m.entries[id]
+	if !found || !now.Before(entry.expires) {
+		return nil, false, nil
+	}
+
+	return entry.data, true, nil
+}
+
+func (m *MemoryStore) Save(id string, data []byte, expires time.Time) error {
+	m.mu.Lock()
+	m.entries[id] = memoryEntry{data: data, expires: expires}
+	m.mu.Unlock()
+
+	return nil
+}
+
+func (m *MemoryStore) Delete(id string) error {
+	m.mu.Lock()
+	delete(m.entries, id)
+	m.mu.Unlock()
+
+	return nil
+}
+
+// Len returns the number of stored sessions, including expired but not yet evicted ones.
+func (m *MemoryStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.entries)
+}
+
+func (m *MemoryStore) maybeSweep(now time.Time) {
+	if now.Before(m.nextSweep) {
+		return
+	}
+
+	for id, entry := range m.entries {
+		if !now.Before(entry.expires) {
+			delete(m.entries, id)
+		}
+	}
+
+	m.nextSweep = now.Add(m.sweep)
+}
+
+// FileStore keeps each session in a separate file in the directory. Expired sessions are removed
+// only when they're accessed, so the directory might require periodic cleanup via Sweep.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a new instance of FileStore, creating the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &FileStore{dir: dir}, nil
+}
+
+func (f *FileStore) Load(id string) ([]byte, bool, error) {
+	if !isValidID(id) {
+		return nil, false, nil
+	}
+
+	content, err := os.ReadFile(f.path(id))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, err
+	case len(content) < 8:
+		return nil, false, f.Delete(id)
+	}
+
+	expires := int64(binary.BigEndian.Uint64(content))
+	if time.Now().Unix() >= expires {
+		return nil, false, f.Delete(id)
+	}
+
+	return content[8:], true, nil
+}
+
+func (f *FileStore) Save(id string, data []byte, expires time.Time) error {
+	if !isValidID(id) {
+		return ErrInvalidCookie
+	}
+
+	content := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(data)), uint64(expires.Unix()))
+	content = append(content, data...)
+
+	// write into a temporary file first, so concurrent readers never observe a partial write
+	tmp, err := os.CreateTemp(f.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path(id))
+}
+
+func (f *FileStore) Delete(id string) error {
+	if !isValidID(id) {
+		return nil
+	}
+
+	if err := os.Remove(f.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// Sweep removes all the expired sessions.
+func (f *FileStore) Sweep() error {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isValidID(entry.Name()) {
+			continue
+		}
+
+		if _, _, err = f.Load(entry.Name()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *FileStore) path(id string) string {
+	return filepath.Join(f.dir, id)
+}
+
+// isValidID guards file paths from being traversed by forged identifiers. Identifiers are
+// always base64url-encoded, therefore any other character is a sign of forgery.
+func isValidID(id string) bool {
+	if len(id) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		switch c := id[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
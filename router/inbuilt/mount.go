@@ -0,0 +1,87 @@
+package inbuilt
+
+import (
+	"strings"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/router"
+	"github.com/indigo-web/indigo/router/inbuilt/uri"
+)
+
+// mountVar is the greedy wildcard holding the path relative to the mount prefix. It's removed
+// from the request vars before the mounted router is called.
+const mountVar = "__mount"
+
+// mount is a router.Builder attached under a prefix. It's built along with the parent router.
+type mount struct {
+	builder  router.Builder
+	router   router.Router
+	patterns []string
+}
+
+// Mount delegates all the requests under the prefix (including the prefix itself) to the router
+// built from the builder, e.g. the simple or the virtual one, or even another inbuilt router. The
+// prefix is stripped from the request path before it's passed, while the original path is stored
+// in Request.Env.MountedFrom. The errors occurred on requests under the prefix are delegated to
+// the mounted router as well.
+//
+// Middlewares of the group and the passed ones wrap the mounted router as a whole. The builder
+// is built when the router itself is.
+func (r *Router) Mount(prefix string, builder router.Builder, middlewares ...Middleware) *Router {
+	m := &mount{builder: builder}
+	handler := compose(m.serve, middlewares)
+	pattern := uri.Normalize(r.prefix + prefix)
+
+	m.patterns = append(m.patterns, strings.TrimSuffix(pattern, "/")+"/:"+mountVar+"...")
+	if len(pattern) > 0 && pattern != "/" {
+		m.patterns = append(m.patterns, pattern)
+	} else {
+		pattern = "/"
+	}
+
+	for _, p := range m.patterns {
+		if err := r.registrar.Add(p, anyMethod, handler); err != nil {
+			panic(err)
+		}
+	}
+
+	r.mounts = append(r.mounts, m)
+	r.recordRoute(routeRecord{
+		method:      anyMethod,
+		pattern:     pattern,
+		middlewares: len(middlewares),
+		mount:       builder,
+	})
+
+	return r
+}
+
+func (m *mount) serve(request *http.Request) *http.Response {
+	original := m.strip(request)
+	response := m.router.OnRequest(request)
+	request.Path = original
+
+	return response
+}
+
+func (m *mount) onError(request *http.Request, err error) *http.Response {
+	original := m.strip(request)
+	response := m.router.OnError(request, err)
+	request.Path = original
+
+	return response
+}
+
+// strip replaces the request path by the one relative to the mount prefix, returning the
+// original one.
+func (m *mount) strip(request *http.Request) (original string) {
+	original = request.Path
+	if len(request.Env.MountedFrom) == 0 {
+		request.Env.MountedFrom = original
+	}
+
+	request.Path = "/" + request.Vars.Value(mountVar)
+	request.Vars.Delete(mountVar)
+
+	return original
+}
@@ -0,0 +1,123 @@
+package inbuilt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/method"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/router/simple"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMount(t *testing.T) {
+	legacy := simple.New(
+		func(request *http.Request) *http.Response {
+			return request.Respond().String(request.Path)
+		},
+		func(request *http.Request) *http.Response {
+			return request.Respond().Code(status.Teapot).String(request.Path)
+		},
+	)
+
+	admin := New()
+	admin.Get("/users/:id", func(request *http.Request) *http.Response {
+		return request.Respond().String(request.Vars.Value("tenant") + ":" + request.Vars.Value("id"))
+	})
+
+	var calls int
+	counter := func(next Handler, request *http.Request) *http.Response {
+		calls++
+		return next(request)
+	}
+
+	r := New()
+	r.Get("/", http.Respond)
+	r.Mount("/legacy/", legacy, counter)
+	r.Group("/t/:tenant").Mount("/admin", admin)
+	rtr := r.Build()
+
+	t.Run("strip prefix", func(t *testing.T) {
+		for path, want := range map[string]string{
+			"/legacy":          "/",
+			"/legacy/":         "/",
+			"/legacy/a/b":      "/a/b",
+			"/legacy/a/b/":     "/a/b",
+			"/legacy/legacy/x": "/legacy/x",
+		} {
+			request := getRequest(method.POST, path)
+			resp := rtr.OnRequest(request)
+			require.Equal(t, status.OK, resp.Expose().Code, path)
+			require.Equal(t, want, readbody(t, resp.Expose().Stream), path)
+			// the path is normalized by the router before being mounted
+			require.Equal(t, strings.TrimSuffix(path, "/"), request.Env.MountedFrom, path)
+			require.False(t, request.Vars.Has(mountVar))
+		}
+
+		require.Equal(t, 5, calls)
+	})
+
+	t.Run("original path is kept", func(t *testing.T) {
+		request := getRequest(method.GET, "/legacy/hello")
+		rtr.OnRequest(request)
+		require.Equal(t, "/legacy/hello", request.Path)
+	})
+
+	t.Run("mounted inbuilt", func(t *testing.T) {
+		request := getRequest(method.GET, "/t/acme/admin/users/42")
+		resp := rtr.OnRequest(request)
+		require.Equal(t, status.OK, resp.Expose().Code)
+		require.Equal(t, "acme:42", readbody(t, resp.Expose().Stream))
+
+		request = getRequest(method.DELETE, "/t/acme/admin/users/42")
+		resp = rtr.OnRequest(request)
+		require.Equal(t, status.MethodNotAllowed, resp.Expose().Code)
+
+		request = getRequest(method.GET, "/t/acme/admin/nothing")
+		resp = rtr.OnRequest(request)
+		require.Equal(t, status.NotFound, resp.Expose().Code)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		request := getRequest(method.GET, "/legacy/broken")
+		resp := rtr.OnError(request, status.ErrBadRequest)
+		require.Equal(t, status.Teapot, resp.Expose().Code)
+		require.Equal(t, "/broken", readbody(t, resp.Expose().Stream))
+		require.Equal(t, status.ErrBadRequest, request.Env.Error)
+		require.Equal(t, "/legacy/broken", request.Path)
+
+		request = getRequest(method.GET, "/elsewhere")
+		resp = rtr.OnError(request, status.ErrBadRequest)
+		require.Equal(t, status.BadRequest, resp.Expose().Code)
+
+		request = getRequest(method.GET, "")
+		resp = rtr.OnError(request, status.ErrBadRequest)
+		require.Equal(t, status.BadRequest, resp.Expose().Code)
+	})
+
+	t.Run("root", func(t *testing.T) {
+		r := New().Mount("/", legacy)
+		request := getRequest(method.GET, "/anything")
+		resp := r.Build().OnRequest(request)
+		require.Equal(t, "/anything", readbody(t, resp.Expose().Stream))
+	})
+
+	t.Run("routes", func(t *testing.T) {
+		routes := r.Routes()
+		require.Len(t, routes, 4)
+		for i := range routes {
+			require.Contains(t, routes[i].Source, "mount_test.go:")
+			routes[i].Source = ""
+		}
+
+		require.Equal(t, RouteInfo{Method: "GET", Pattern: "/"}, routes[0])
+		require.Equal(t, RouteInfo{
+			Method: "*", Pattern: "/legacy", Middlewares: 1, Mount: "*simple.Router",
+		}, routes[1])
+		require.Equal(t, RouteInfo{
+			Method: "*", Pattern: "/t/:tenant/admin", Mount: "*inbuilt.Router",
+		}, routes[2])
+		require.Equal(t, RouteInfo{Method: "GET", Pattern: "/t/:tenant/admin/users/:id"}, routes[3])
+	})
+}
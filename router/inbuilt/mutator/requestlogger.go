@@ -18,7 +18,7 @@ func LogRequest(loggers ...Logger) internal.Mutator {
 
 	return func(request *http.Request) {
 		for _, logger := range loggers {
-			logger.Printf("%s %s", request.Method.String(), request.Path)
+			logger.Printf("%s %s", request.Method.String(), request.URL())
 		}
 	}
 }
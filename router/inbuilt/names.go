@@ -0,0 +1,137 @@
+package inbuilt
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/indigo-web/indigo/kv"
+	"github.com/indigo-web/indigo/router/inbuilt/uri"
+)
+
+var (
+	ErrUnknownRoute = errors.New("no route with such name")
+	ErrMissingVar   = errors.New("route var is not provided")
+	ErrExtraVar     = errors.New("route has no such var")
+)
+
+// routeNames maps route names to their patterns. It's shared by the whole groups tree, so
+// routes registered on groups can be referred to from anywhere.
+type routeNames map[string]string
+
+// Name attaches the name to the most recently registered route, so its URL can be later built
+// via URL. Panics if no routes were registered yet or the name is already taken:
+//
+//	r.Get("/user/:id", getUser).Name("user")
+func (r *Router) Name(name string) *Router {
+	if len(r.lastPattern) == 0 {
+		panic("no route to name")
+	}
+
+	r.nameRoute(name, r.lastPattern)
+
+	return r
+}
+
+func (r *Router) nameRoute(name, pattern string) {
+	if existing, taken := r.names[name]; taken {
+		panic(fmt.Sprintf("route name %q is already taken by %s", name, existing))
+	}
+
+	r.names[name] = uri.Normalize(pattern)
+}
+
+// URL builds the path of a named route, substituting the vars into dynamic segments. Greedy
+// segments might span multiple path segments, in which case slashes are preserved. Query
+// params, if any, are appended in their order. Both vars and query are allowed to be nil.
+// Every var present in the pattern must be provided, and no others.
+func (r *Router) URL(name string, vars, query *kv.Storage) (string, error) {
+	pattern, found := r.names[name]
+	if !found {
+		return "", fmt.Errorf("%w: %s", ErrUnknownRoute, name)
+	}
+
+	if vars == nil {
+		vars = kv.New()
+	}
+
+	var (
+		b         strings.Builder
+		wildcards []string
+	)
+
+	for len(pattern) > 0 {
+		colon := strings.IndexByte(pattern, ':')
+		if colon == -1 {
+			b.WriteString(pattern)
+			break
+		}
+
+		b.WriteString(pattern[:colon])
+		pattern = pattern[colon+1:]
+
+		boundary := strings.IndexByte(pattern, '/')
+		if boundary == -1 {
+			boundary = len(pattern)
+		}
+
+		wildcard := pattern[:boundary]
+		pattern = pattern[boundary:]
+		wildcard, greedy := strings.CutSuffix(wildcard, "...")
+
+		value, found := vars.Lookup(wildcard)
+		if !found {
+			return "", fmt.Errorf("%w: %s", ErrMissingVar, wildcard)
+		}
+
+		wildcards = append(wildcards, wildcard)
+		if greedy {
+			b.WriteString(escapeSegments(value))
+		} else {
+			b.WriteString(url.PathEscape(value))
+		}
+	}
+
+	for key := range vars.Keys() {
+		if !slices.Contains(wildcards, key) {
+			return "", fmt.Errorf("%w: %s", ErrExtraVar, key)
+		}
+	}
+
+	if query != nil && !query.Empty() {
+		b.WriteByte('?')
+		for i, pair := range query.Expose() {
+			if i > 0 {
+				b.WriteByte('&')
+			}
+
+			b.WriteString(url.QueryEscape(pair.Key))
+			b.WriteByte('=')
+			b.WriteString(url.QueryEscape(pair.Value))
+		}
+	}
+
+	return b.String(), nil
+}
+
+// MustURL is like URL, but panics on error. Handy for building URLs of routes known to exist.
+func (r *Router) MustURL(name string, vars, query *kv.Storage) string {
+	u, err := r.URL(name, vars, query)
+	if err != nil {
+		panic(err)
+	}
+
+	return u
+}
+
+// escapeSegments escapes every path segment separately, so the slashes are kept intact.
+func escapeSegments(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
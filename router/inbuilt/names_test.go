@@ -0,0 +1,64 @@
+package inbuilt
+
+import (
+	"testing"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNames(t *testing.T) {
+	r := New().
+		Get("/", http.Respond).Name("index").
+		Get("/user/:id/post/:post", http.Respond).Name("post")
+
+	api := r.Group("/api")
+	api.Get("/files/:path...", http.Respond).Name("files")
+	api.Resource("/items").Get(http.Respond).Post(http.Respond).Name("items")
+
+	t.Run("static", func(t *testing.T) {
+		u, err := r.URL("index", nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, "/", u)
+	})
+
+	t.Run("dynamic", func(t *testing.T) {
+		u, err := r.URL("post", kv.New().Add("id", "42").Add("post", "hello world/x"), nil)
+		require.NoError(t, err)
+		require.Equal(t, "/user/42/post/hello%20world%2Fx", u)
+	})
+
+	t.Run("greedy", func(t *testing.T) {
+		u, err := r.URL("files", kv.New().Add("path", "docs/a b.txt"), nil)
+		require.NoError(t, err)
+		require.Equal(t, "/api/files/docs/a%20b.txt", u)
+	})
+
+	t.Run("resource with query", func(t *testing.T) {
+		query := kv.New().Add("q", "a&b").Add("page", "2")
+		u, err := api.URL("items", nil, query)
+		require.NoError(t, err)
+		require.Equal(t, "/api/items?q=a%26b&page=2", u)
+	})
+
+	t.Run("built router", func(t *testing.T) {
+		_ = r.Build()
+		require.Equal(t, "/api/items", r.MustURL("items", nil, nil))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := r.URL("nonexistent", nil, nil)
+		require.ErrorIs(t, err, ErrUnknownRoute)
+		_, err = r.URL("post", kv.New().Add("id", "42"), nil)
+		require.ErrorIs(t, err, ErrMissingVar)
+		_, err = r.URL("index", kv.New().Add("id", "42"), nil)
+		require.ErrorIs(t, err, ErrExtraVar)
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		require.Panics(t, func() {
+			r.Get("/another", http.Respond).Name("index")
+		})
+	})
+}
@@ -90,19 +90,15 @@ func (r *registrar) AsRadixTree() radixTree {
 	tree := radix.New[endpoint]()
 
 	for path, e := range r.endpoints {
-		var (
-			mlut  methodLUT
-			allow string
-		)
-
+		var mlut methodLUT
 		for m, handler := range e {
 			mlut[m] = handler
-			allow += m.String() + ","
 		}
 
 		if err := tree.Insert(path, endpoint{
 			methods: mlut,
-			allow:   strings.TrimSuffix(allow, ","),
+			allow:   getAllowString(mlut),
+			pattern: path,
 		}); err != nil {
 			panic(err)
 		}
@@ -120,6 +116,14 @@ func (r *registrar) Options(includeTRACE bool) string {
 	for _, ep := range r.endpoints {
 		totalEndpoints++
 
+		if _, ok := ep[anyMethod]; ok {
+			for _, m := range method.List {
+				methodsStatistic[m]++
+			}
+
+			continue
+		}
+
 		for m := range ep {
 			methodsStatistic[m]++
 		}
@@ -13,6 +13,12 @@ type Resource struct {
 	group *Router
 }
 
+// Name attaches the name to the resource path, so its URL can be built via Router.URL.
+func (r Resource) Name(name string) Resource {
+	r.group.nameRoute(name, r.group.prefix)
+	return r
+}
+
 // Use applies middlewares to the resource, wrapping all the already registered
 // and registered in future handlers
 func (r Resource) Use(middlewares ...Middleware) Resource {
@@ -128,3 +134,9 @@ func (r Resource) Proppatch(handler Handler, mwares ...Middleware) Resource {
 	r.group.Proppatch("", handler, mwares...)
 	return r
 }
+
+// Any registers a handler for requests of every method, which has no own handler
+func (r Resource) Any(handler Handler, mwares ...Middleware) Resource {
+	r.group.Any("", handler, mwares...)
+	return r
+}
@@ -0,0 +1,218 @@
+package inbuilt
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/method"
+	"github.com/indigo-web/indigo/router"
+)
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	// Method is the method name. Aliases might be applied to multiple methods, which are
+	// listed comma-separated then, or * if applied to all methods, as well as routes
+	// registered via Any.
+	Method string `json:"method"`
+	// Pattern is the full path pattern, including the prefixes of all the parent groups.
+	Pattern string `json:"pattern"`
+	// Name is the name attached to the route, if any.
+	Name string `json:"name,omitempty"`
+	// Alias is the path the requests are transparently redirected to. Set only for aliases.
+	Alias string `json:"alias,omitempty"`
+	// Middlewares is the total number of middlewares wrapping the handler, including ones
+	// inherited from the groups.
+	Middlewares int `json:"middlewares"`
+	// Source is the file:line the route was registered at.
+	Source string `json:"source"`
+	// Mount is the type of the router mounted at the pattern. Set only for mounts. Routes of
+	// mounted inbuilt routers are listed right after, prefixed by the pattern.
+	Mount string `json:"mount,omitempty"`
+}
+
+type routeRecord struct {
+	method      method.Method
+	methods     []method.Method
+	pattern     string
+	alias       string
+	middlewares int
+	owner       *Router
+	source      string
+	mount       router.Builder
+}
+
+// routeRecords is shared by the whole groups tree, the same way as the route names are.
+type routeRecords []routeRecord
+
+func (r *Router) recordRoute(rec routeRecord) {
+	rec.owner = r
+	rec.source = callerSource()
+	*r.records = append(*r.records, rec)
+}
+
+// Routes lists all the routes registered on the router and its groups, sorted by patterns.
+// Middlewares registered after the call aren't counted.
+func (r *Router) Routes() []RouteInfo {
+	names := make(map[string]string, len(r.names))
+	for name, pattern := range r.names {
+		names[pattern] = name
+	}
+
+	records := slices.Clone(*r.records)
+	slices.SortStableFunc(records, func(a, b routeRecord) int {
+		return cmp.Or(
+			cmp.Compare(a.pattern, b.pattern),
+			cmp.Compare(len(a.alias), len(b.alias)),
+			cmp.Compare(a.method, b.method),
+		)
+	})
+
+	routes := make([]RouteInfo, 0, len(records))
+	for _, rec := range records {
+		info := RouteInfo{
+			Method:      rec.method.String(),
+			Pattern:     rec.pattern,
+			Alias:       rec.alias,
+			Middlewares: rec.middlewares,
+			Source:      rec.source,
+		}
+
+		if len(rec.alias) > 0 || rec.method == anyMethod {
+			info.Method = methodsList(rec.methods)
+		}
+
+		if len(rec.alias) == 0 {
+			info.Name = names[rec.pattern]
+			for group := rec.owner; group != nil; group = group.parent {
+				info.Middlewares += len(group.middlewares)
+			}
+		}
+
+		routes = append(routes, info)
+		if rec.mount != nil {
+			info.Mount = fmt.Sprintf("%T", rec.mount)
+			routes[len(routes)-1] = info
+			routes = append(routes, mountedRoutes(info, rec.mount)...)
+		}
+	}
+
+	return routes
+}
+
+// mountedRoutes lists the routes of the mounted router, if it's the inbuilt one.
+func mountedRoutes(mount RouteInfo, builder router.Builder) []RouteInfo {
+	mounted, ok := builder.(*Router)
+	if !ok {
+		return nil
+	}
+
+	routes := mounted.Routes()
+	for i, route := range routes {
+		routes[i].Pattern = joinPattern(mount.Pattern, route.Pattern)
+		if len(route.Alias) > 0 {
+			routes[i].Alias = joinPattern(mount.Pattern, route.Alias)
+		} else {
+			routes[i].Middlewares += mount.Middlewares
+		}
+	}
+
+	return routes
+}
+
+func joinPattern(prefix, pattern string) string {
+	switch {
+	case prefix == "/":
+		return pattern
+	case pattern == "/":
+		return prefix
+	default:
+		return prefix + pattern
+	}
+}
+
+// PrintRoutes makes the router print the table of all the routes into w as it's built, which
+// is normally right before the server starts.
+func (r *Router) PrintRoutes(w io.Writer) *Router {
+	r.routesOutput = w
+	return r
+}
+
+// RoutesHandler returns a handler, responding with the list of routes in JSON. It's intended
+// for debugging purposes and therefore shouldn't be publicly exposed:
+//
+//	r.Get("/debug/routes", r.RoutesHandler(), adminOnly)
+func (r *Router) RoutesHandler() Handler {
+	return func(request *http.Request) *http.Response {
+		return http.JSON(request, r.Routes())
+	}
+}
+
+func (r *Router) printRoutes() {
+	if r.routesOutput == nil {
+		return
+	}
+
+	tw := tabwriter.NewWriter(r.routesOutput, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "METHOD\tPATTERN\tNAME\tMIDDLEWARES\tSOURCE")
+
+	for _, route := range r.Routes() {
+		pattern := route.Pattern
+		switch {
+		case len(route.Alias) > 0:
+			pattern += " -> " + route.Alias
+		case len(route.Mount) > 0:
+			pattern += " => " + route.Mount
+		}
+
+		_, _ = fmt.Fprintf(
+			tw, "%s\t%s\t%s\t%d\t%s\n",
+			route.Method, pattern, route.Name, route.Middlewares, route.Source,
+		)
+	}
+
+	_ = tw.Flush()
+}
+
+func methodsList(methods []method.Method) string {
+	if len(methods) == 0 {
+		return "*"
+	}
+
+	names := make([]string, len(methods))
+	for i, m := range methods {
+		names[i] = m.String()
+	}
+
+	return strings.Join(names, ",")
+}
+
+// packageDir is the directory of the package source, used to skip the frames inside it.
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// callerSource returns the file:line of the first caller outside the package.
+func callerSource() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	for {
+		frame, more := frames.Next()
+		inPackage := filepath.Dir(frame.File) == packageDir && !strings.HasSuffix(frame.File, "_test.go")
+		if !inPackage {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+
+		if !more {
+			return "unknown"
+		}
+	}
+}
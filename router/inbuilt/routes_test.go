@@ -0,0 +1,55 @@
+package inbuilt
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/method"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutes(t *testing.T) {
+	nop := func(next Handler, request *http.Request) *http.Response {
+		return next(request)
+	}
+
+	r := New().Use(nop)
+	r.Get("/", http.Respond).Name("index")
+	api := r.Group("/api").Use(nop)
+	api.Post("/user/:id", http.Respond, nop, nop)
+	api.Get("/user/:id", http.Respond)
+	r.Alias("/home", "/", method.GET)
+
+	routes := r.Routes()
+	require.Len(t, routes, 4)
+	require.Equal(t, RouteInfo{Method: "GET", Pattern: "/", Name: "index", Middlewares: 1}, withoutSource(t, routes[0]))
+	require.Equal(t, RouteInfo{Method: "GET", Pattern: "/api/user/:id", Middlewares: 2}, withoutSource(t, routes[1]))
+	require.Equal(t, RouteInfo{Method: "POST", Pattern: "/api/user/:id", Middlewares: 4}, withoutSource(t, routes[2]))
+	require.Equal(t, RouteInfo{Method: "GET", Pattern: "/home", Alias: "/"}, withoutSource(t, routes[3]))
+
+	t.Run("print", func(t *testing.T) {
+		var buff bytes.Buffer
+		r.PrintRoutes(&buff).Build()
+		lines := strings.Split(strings.TrimSpace(buff.String()), "\n")
+		require.Len(t, lines, 5)
+		require.Equal(t, []string{"METHOD", "PATTERN", "NAME", "MIDDLEWARES", "SOURCE"}, strings.Fields(lines[0]))
+		require.Equal(t, []string{"GET", "/home", "->", "/", "0"}, strings.Fields(lines[4])[:5])
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		request := getRequest(method.GET, "/debug/routes")
+		resp := r.RoutesHandler()(request)
+		var listed []RouteInfo
+		require.NoError(t, json.Unmarshal([]byte(readbody(t, resp.Expose().Stream)), &listed))
+		require.Equal(t, r.Routes(), listed)
+	})
+}
+
+func withoutSource(t *testing.T, info RouteInfo) RouteInfo {
+	require.Contains(t, info.Source, "routes_test.go:")
+	info.Source = ""
+	return info
+}
@@ -101,6 +101,14 @@ func (r *Router) Proppatch(path string, handler Handler, middlewares ...Middlewa
 	return r
 }
 
+// Any registers the handler for every method, including extension ones, which has no own
+// handler registered on the same path. HEAD requests are still served by the GET handler first,
+// if presented.
+func (r *Router) Any(path string, handler Handler, middlewares ...Middleware) *Router {
+	r.Route(anyMethod, path, handler, middlewares...)
+	return r
+}
+
 // File is a shortcut handler for single file endpoints.
 func File(filename string) Handler {
 	return func(request *http.Request) *http.Response {
@@ -18,9 +18,14 @@ type (
 	methodLUT     [method.Count + 1]Handler
 )
 
+// anyMethod is the slot of the method LUT, holding the handler registered via Any. As the parser
+// never produces method.Unknown, it's otherwise always empty.
+const anyMethod = method.Unknown
+
 type endpoint struct {
 	methods methodLUT
 	allow   string
+	pattern string
 }
 
 type (
@@ -37,10 +42,20 @@ func (r routesMap) Add(path string, m method.Method, handler Handler) {
 	entry := r[p]
 	entry.methods[m] = handler
 	entry.allow = getAllowString(entry.methods)
+	entry.pattern = path
 	r[p] = entry
 }
 
 func getAllowString(methods methodLUT) (allowed string) {
+	if methods[anyMethod] != nil {
+		// the endpoint accepts every method
+		for _, m := range method.List {
+			methods[m] = methods[anyMethod]
+		}
+
+		methods[anyMethod] = nil
+	}
+
 	definedMethods := make([]string, 0, method.Count)
 
 	for i, handler := range methods {
@@ -0,0 +1,115 @@
+package webdav
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// FileSystem is a writable hierarchical file system served over WebDAV. Names are slash-separated
+// and always absolute, e.g. /docs/report.txt, with / denoting the root collection. Errors must
+// be comparable against fs.ErrNotExist, fs.ErrExist and fs.ErrPermission where applicable.
+type FileSystem interface {
+	Stat(name string) (fs.FileInfo, error)
+	// ReadDir lists the members of the collection.
+	ReadDir(name string) ([]fs.FileInfo, error)
+	// Open opens the file for reading.
+	Open(name string) (io.ReadCloser, error)
+	// Create opens the file for writing, creating it if it doesn't exist and truncating otherwise.
+	Create(name string) (io.WriteCloser, error)
+	// Mkdir creates a collection. The parent collection must exist.
+	Mkdir(name string) error
+	// RemoveAll removes the resource along with all its members.
+	RemoveAll(name string) error
+	// Rename moves the resource. The destination doesn't exist at the moment of the call.
+	Rename(oldName, newName string) error
+}
+
+// Dir is the FileSystem of the operating system, rooted at the directory. Resources outside
+// the directory can't be referred, however symbolic links inside it are followed.
+type Dir string
+
+var _ FileSystem = Dir("")
+
+func (d Dir) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(d.resolve(name))
+}
+
+func (d Dir) ReadDir(name string) ([]fs.FileInfo, error) {
+	entries, err := os.ReadDir(d.resolve(name))
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]fs.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		var info fs.FileInfo
+		if entry.Type()&fs.ModeSymlink != 0 {
+			info, err = d.Stat(path.Join(name, entry.Name()))
+		} else {
+			info, err = entry.Info()
+		}
+
+		if errors.Is(err, fs.ErrNotExist) {
+			// either removed meanwhile or a dangling symbolic link
+			continue
+		} else if err != nil {
+			info = unreadable{name: entry.Name(), err: err}
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+func (d Dir) Open(name string) (io.ReadCloser, error) {
+	return os.Open(d.resolve(name))
+}
+
+func (d Dir) Create(name string) (io.WriteCloser, error) {
+	return os.OpenFile(d.resolve(name), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+}
+
+func (d Dir) Mkdir(name string) error {
+	return os.Mkdir(d.resolve(name), 0o755)
+}
+
+func (d Dir) RemoveAll(name string) error {
+	if clean(name) == "/" {
+		return fs.ErrPermission
+	}
+
+	return os.RemoveAll(d.resolve(name))
+}
+
+func (d Dir) Rename(oldName, newName string) error {
+	return os.Rename(d.resolve(oldName), d.resolve(newName))
+}
+
+func (d Dir) resolve(name string) string {
+	return filepath.Join(string(d), filepath.FromSlash(clean(name)))
+}
+
+// clean returns the shortest absolute name, so it can't refer anything above the root.
+func clean(name string) string {
+	return path.Clean("/" + name)
+}
+
+// unreadable stands for a member which couldn't be stat-ed, so the error is reported for the
+// member alone instead of failing the whole listing.
+type unreadable struct {
+	name string
+	err  error
+}
+
+func (u unreadable) Name() string     { return u.name }
+func (unreadable) Size() int64        { return 0 }
+func (unreadable) Mode() fs.FileMode  { return 0 }
+func (unreadable) ModTime() time.Time { return time.Time{} }
+func (unreadable) IsDir() bool        { return false }
+func (unreadable) Sys() any           { return nil }
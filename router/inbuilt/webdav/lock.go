@@ -0,0 +1,287 @@
+package webdav
+
+import (
+	"crypto/rand"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// infiniteTimeout is the lifetime of locks requested with the Infinite timeout. They're still
+// capped by Params.LockTimeout.
+const infiniteTimeout = time.Duration(1<<63 - 1)
+
+type lock struct {
+	token string
+	// root is the name of the locked resource.
+	root      string
+	recursive bool
+	shared    bool
+	// owner is the raw XML content of the owner element, as submitted by the client.
+	owner   []byte
+	timeout time.Duration
+	expires time.Time
+}
+
+// covers reports whether the lock applies to the resource.
+func (l *lock) covers(name string) bool {
+	if l.recursive {
+		return within(name, l.root)
+	}
+
+	return name == l.root
+}
+
+// locks is an in-memory registry of write locks. Expired locks are purged lazily, as the
+// registry is accessed.
+type locks struct {
+	mu     sync.Mutex
+	tokens map[string]*lock
+	limit  time.Duration
+}
+
+func newLocks(limit time.Duration) *locks {
+	return &locks{
+		tokens: make(map[string]*lock),
+		limit:  limit,
+	}
+}
+
+// Create acquires a new lock, unless it conflicts with any of the existing ones. Exclusive
+// locks conflict with any other lock, while shared ones conflict with exclusive ones only.
+func (l *locks) Create(root string, recursive, shared bool, owner []byte, timeout time.Duration) (*lock, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.purge(now)
+
+	for _, held := range l.tokens {
+		if held.shared && shared {
+			continue
+		}
+
+		if held.covers(root) || (recursive && within(held.root, root)) {
+			return nil, false
+		}
+	}
+
+	timeout = l.cap(timeout)
+	newLock := &lock{
+		token:     newToken(),
+		root:      root,
+		recursive: recursive,
+		shared:    shared,
+		owner:     owner,
+		timeout:   timeout,
+		expires:   now.Add(timeout),
+	}
+	l.tokens[newLock.token] = newLock
+
+	return newLock, true
+}
+
+// Refresh prolongs the first lock covering the resource out of ones the tokens refer.
+func (l *locks) Refresh(name string, tokens []string, timeout time.Duration) (*lock, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.purge(now)
+
+	for _, token := range tokens {
+		if held, found := l.tokens[token]; found && held.covers(name) {
+			held.timeout = l.cap(timeout)
+			held.expires = now.Add(held.timeout)
+			return held, true
+		}
+	}
+
+	return nil, false
+}
+
+// Unlock releases the lock, if it covers the resource.
+func (l *locks) Unlock(name, token string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.purge(time.Now())
+
+	held, found := l.tokens[token]
+	if !found || !held.covers(name) {
+		return false
+	}
+
+	delete(l.tokens, token)
+	return true
+}
+
+// Confirm reports whether the resource can be modified, i.e. every exclusive lock covering it
+// is referred by one of the tokens. Shared locks are held by different principals, so a single
+// one out of those covering the same resource is enough. If recursive is set, locks of the
+// resource members are considered as well.
+func (l *locks) Confirm(name string, recursive bool, tokens []string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.purge(time.Now())
+
+	// shared maps the resources covered by shared locks to whether any of them is submitted
+	shared := make(map[string]bool)
+	for token, held := range l.tokens {
+		var resource string
+		switch {
+		case held.covers(name):
+			resource = name
+		case recursive && within(held.root, name):
+			resource = held.root
+		default:
+			continue
+		}
+
+		submitted := slices.Contains(tokens, token)
+		if !held.shared {
+			if !submitted {
+				return false
+			}
+
+			continue
+		}
+
+		shared[resource] = shared[resource] || submitted
+	}
+
+	for _, submitted := range shared {
+		if !submitted {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Discover returns all the locks covering the resource.
+func (l *locks) Discover(name string) []lock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.purge(time.Now())
+
+	var found []lock
+	for _, held := range l.tokens {
+		if held.covers(name) {
+			found = append(found, *held)
+		}
+	}
+
+	return found
+}
+
+// Remove releases all the locks rooted at the resource or any of its members.
+func (l *locks) Remove(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for token, held := range l.tokens {
+		if within(held.root, name) {
+			delete(l.tokens, token)
+		}
+	}
+}
+
+func (l *locks) purge(now time.Time) {
+	for token, held := range l.tokens {
+		if now.After(held.expires) {
+			delete(l.tokens, token)
+		}
+	}
+}
+
+func (l *locks) cap(timeout time.Duration) time.Duration {
+	if timeout <= 0 || timeout > l.limit {
+		return l.limit
+	}
+
+	return timeout
+}
+
+func newToken() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	// version 4, variant 10 (RFC 9562)
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", id[:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
+
+// parseTimeout parses the Timeout header value, which is a list of preferred timeouts. The
+// first recognized one is picked. Zero is returned if none of them is, therefore leaving
+// the decision to the server.
+func parseTimeout(value string) time.Duration {
+	for _, option := range strings.Split(value, ",") {
+		option = strings.TrimSpace(option)
+		if strings.EqualFold(option, "Infinite") {
+			return infiniteTimeout
+		}
+
+		if len(option) > len("Second-") && strings.EqualFold(option[:len("Second-")], "Second-") {
+			seconds, err := strconv.ParseUint(option[len("Second-"):], 10, 32)
+			if err == nil && seconds > 0 {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	return 0
+}
+
+func formatTimeout(timeout time.Duration) string {
+	if timeout == infiniteTimeout {
+		return "Infinite"
+	}
+
+	return "Second-" + strconv.FormatInt(int64(timeout/time.Second), 10)
+}
+
+// submittedTokens collects the state tokens from the If header (RFC 4918, 10.4). Conditions
+// aren't evaluated, instead every mentioned lock token is considered submitted, which is
+// enough for clients holding their own locks.
+func submittedTokens(value string) (tokens []string) {
+	var inList bool
+	for len(value) > 0 {
+		switch value[0] {
+		case '(':
+			inList = true
+		case ')':
+			inList = false
+		case '<':
+			end := strings.IndexByte(value, '>')
+			if end == -1 {
+				return tokens
+			}
+
+			if inList {
+				tokens = append(tokens, value[1:end])
+			}
+
+			// otherwise, it's a resource tag
+			value = value[end:]
+		case '[':
+			// entity tags might contain angle brackets, so skip them entirely
+			end := strings.IndexByte(value, ']')
+			if end == -1 {
+				return tokens
+			}
+
+			value = value[end:]
+		}
+
+		value = value[1:]
+	}
+
+	return tokens
+}
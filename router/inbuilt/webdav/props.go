@@ -0,0 +1,145 @@
+package webdav
+
+import (
+	"cmp"
+	"encoding/xml"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Property is a dead property, which is an arbitrary piece of XML stored on behalf of clients.
+type Property struct {
+	XMLName xml.Name
+	// InnerXML is the raw content of the property element.
+	InnerXML []byte
+}
+
+// PropertyStore persists dead properties. Resources are referred by the same names as in the
+// FileSystem.
+type PropertyStore interface {
+	// Get returns all the properties of the resource.
+	Get(name string) ([]Property, error)
+	// Patch sets and removes the properties of the resource. Either all the changes are applied
+	// or none of them.
+	Patch(name string, set []Property, remove []xml.Name) error
+	// Copy duplicates the properties of a single resource, replacing all the existing properties
+	// of the destination.
+	Copy(from, to string) error
+	// Move transfers the properties of the resource along with all its members.
+	Move(from, to string) error
+	// Delete removes the properties of the resource along with all its members.
+	Delete(name string) error
+}
+
+// MemoryProperties returns a PropertyStore keeping the properties in memory, so they're lost
+// as the process exits.
+func MemoryProperties() PropertyStore {
+	return &memoryProperties{
+		props: make(map[string]map[xml.Name][]byte),
+	}
+}
+
+type memoryProperties struct {
+	mu    sync.Mutex
+	props map[string]map[xml.Name][]byte
+}
+
+func (m *memoryProperties) Get(name string) ([]Property, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	props := make([]Property, 0, len(m.props[name]))
+	for key, value := range m.props[name] {
+		props = append(props, Property{XMLName: key, InnerXML: value})
+	}
+
+	slices.SortFunc(props, func(a, b Property) int {
+		return cmp.Or(
+			cmp.Compare(a.XMLName.Space, b.XMLName.Space),
+			cmp.Compare(a.XMLName.Local, b.XMLName.Local),
+		)
+	})
+
+	return props, nil
+}
+
+func (m *memoryProperties) Patch(name string, set []Property, remove []xml.Name) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	props := m.props[name]
+	if props == nil {
+		props = make(map[xml.Name][]byte, len(set))
+		m.props[name] = props
+	}
+
+	for _, prop := range set {
+		props[prop.XMLName] = prop.InnerXML
+	}
+
+	for _, key := range remove {
+		delete(props, key)
+	}
+
+	return nil
+}
+
+func (m *memoryProperties) Copy(from, to string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.props, to)
+	if props, found := m.props[from]; found {
+		copied := make(map[xml.Name][]byte, len(props))
+		for key, value := range props {
+			copied[key] = value
+		}
+
+		m.props[to] = copied
+	}
+
+	return nil
+}
+
+func (m *memoryProperties) Move(from, to string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	moved := make(map[string]map[xml.Name][]byte)
+	for name, props := range m.props {
+		if within(name, from) {
+			moved[to+strings.TrimPrefix(name, from)] = props
+			delete(m.props, name)
+		}
+	}
+
+	for name, props := range moved {
+		m.props[name] = props
+	}
+
+	return nil
+}
+
+func (m *memoryProperties) Delete(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key := range m.props {
+		if within(key, name) {
+			delete(m.props, key)
+		}
+	}
+
+	return nil
+}
+
+// within reports whether the name is either the root itself or one of its members, no matter
+// how deep.
+func within(name, root string) bool {
+	if root == "/" {
+		return true
+	}
+
+	return name == root || (strings.HasPrefix(name, root) && name[len(root)] == '/')
+}
@@ -0,0 +1,876 @@
+// Package webdav implements a WebDAV server (RFC 4918) over a writable file system, so it can
+// be mounted as a network drive by OS file managers. Both compliance classes 1 and 2 are
+// supported, i.e. properties, collections and write locks.
+package webdav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"html"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/method"
+	"github.com/indigo-web/indigo/http/mime"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/router/inbuilt"
+)
+
+// DefaultLockTimeout is the maximal lifetime of a lock, unless set explicitly.
+const DefaultLockTimeout = time.Hour
+
+const (
+	allow = "OPTIONS, GET, HEAD, PUT, DELETE, MKCOL, COPY, MOVE, PROPFIND, PROPPATCH, LOCK, UNLOCK"
+	// timeFormat is the IMF-fixdate (RFC 9110, 5.6.7).
+	timeFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
+)
+
+// depthInfinity is the Depth header value, meaning the resource and all its members at any depth.
+const depthInfinity = -1
+
+type Params struct {
+	// Properties stores dead properties. If nil, they're kept in memory.
+	Properties PropertyStore
+	// LockTimeout is the maximal lifetime of a lock unless it's refreshed. Clients may request
+	// shorter timeouts, but never longer ones. Defaults to DefaultLockTimeout.
+	LockTimeout time.Duration
+	// InfiniteDepth allows PROPFIND requests of infinite depth, including ones without the
+	// Depth header. They're rejected by default, as a single request makes the whole tree to
+	// be walked (RFC 4918, 9.1).
+	InfiniteDepth bool
+}
+
+// Mount serves the file system at the prefix.
+func Mount(r *inbuilt.Router, prefix string, fs FileSystem, params ...Params) *inbuilt.Router {
+	handler := Handler(fs, params...)
+	r.Any(prefix+"/:path...", handler)
+	if len(prefix) > 0 {
+		r.Any(prefix, handler)
+	}
+
+	return r
+}
+
+// Handler returns a handler serving the file system. It must be registered at a route ending
+// with the greedy :path... wildcard, which holds the resource name, e.g. /dav/:path...
+func Handler(fs FileSystem, params ...Params) inbuilt.Handler {
+	var p Params
+	if len(params) > 0 {
+		p = params[0]
+	}
+
+	if p.Properties == nil {
+		p.Properties = MemoryProperties()
+	}
+
+	if p.LockTimeout <= 0 {
+		p.LockTimeout = DefaultLockTimeout
+	}
+
+	h := &handler{
+		fs:            fs,
+		props:         p.Properties,
+		locks:         newLocks(p.LockTimeout),
+		infiniteDepth: p.InfiniteDepth,
+	}
+
+	return h.serve
+}
+
+type handler struct {
+	fs            FileSystem
+	props         PropertyStore
+	locks         *locks
+	infiniteDepth bool
+}
+
+func (h *handler) serve(request *http.Request) *http.Response {
+	// the base is the path the file system is mounted at. It's needed in order to produce hrefs
+	// and to resolve destinations of COPY and MOVE
+	rel := request.Vars.Value("path")
+	name := clean(rel)
+	base := strings.TrimSuffix(request.Path[:len(request.Path)-len(rel)], "/")
+
+	switch request.Method {
+	case method.OPTIONS:
+		return request.Respond().
+			Header("Allow", allow).
+			Header("DAV", "1, 2").
+			Header("MS-Author-Via", "DAV")
+	case method.GET, method.HEAD:
+		return h.get(request, name, base)
+	case method.PUT:
+		return h.put(request, name)
+	case method.DELETE:
+		return h.delete(request, name)
+	case method.MKCOL:
+		return h.mkcol(request, name)
+	case method.COPY:
+		return h.copyMove(request, name, base, false)
+	case method.MOVE:
+		return h.copyMove(request, name, base, true)
+	case method.PROPFIND:
+		return h.propfind(request, name, base)
+	case method.PROPPATCH:
+		return h.proppatch(request, name, base)
+	case method.LOCK:
+		return h.lock(request, name, base)
+	case method.UNLOCK:
+		return h.unlock(request, name)
+	default:
+		return http.
+			Error(request, status.ErrMethodNotAllowed).
+			Header("Allow", allow)
+	}
+}
+
+func (h *handler) get(request *http.Request, name, base string) *http.Response {
+	info, err := h.fs.Stat(name)
+	if err != nil {
+		return fail(request, err)
+	}
+
+	if info.IsDir() {
+		return h.list(request, name, base)
+	}
+
+	file, err := h.fs.Open(name)
+	if err != nil {
+		return fail(request, err)
+	}
+
+	return request.Respond().
+		Header("ETag", etag(info)).
+		Header("Last-Modified", info.ModTime().UTC().Format(timeFormat)).
+		ContentType(mime.Guess(name, mime.OctetStream)).
+		Stream(file, info.Size())
+}
+
+// list renders a minimal index of the collection, so it can be browsed without a WebDAV client.
+func (h *handler) list(request *http.Request, name, base string) *http.Response {
+	infos, err := h.readDir(name)
+	if err != nil {
+		return fail(request, err)
+	}
+
+	var buff bytes.Buffer
+	title := html.EscapeString(name)
+	buff.WriteString(`<!DOCTYPE html><html><head><meta charset="utf-8"><title>Index of `)
+	buff.WriteString(title)
+	buff.WriteString("</title></head><body><h1>Index of ")
+	buff.WriteString(title)
+	buff.WriteString("</h1><ul>")
+	if name != "/" {
+		buff.WriteString(`<li><a href="../">../</a></li>`)
+	}
+
+	for _, info := range infos {
+		display := info.Name()
+		if info.IsDir() {
+			display += "/"
+		}
+
+		buff.WriteString(`<li><a href="`)
+		buff.WriteString(html.EscapeString(href(base, path.Join(name, info.Name()), info.IsDir())))
+		buff.WriteString(`">`)
+		buff.WriteString(html.EscapeString(display))
+		buff.WriteString("</a></li>")
+	}
+
+	buff.WriteString("</ul></body></html>")
+
+	return request.Respond().
+		ContentType(mime.HTML, mime.UTF8).
+		Bytes(buff.Bytes())
+}
+
+func (h *handler) put(request *http.Request, name string) *http.Response {
+	if !h.confirm(request, name, false) {
+		return http.Error(request, status.ErrLocked)
+	}
+
+	info, err := h.fs.Stat(name)
+	switch {
+	case err == nil && info.IsDir():
+		return http.Error(request, status.ErrMethodNotAllowed).Header("Allow", allow)
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return fail(request, err)
+	case err != nil && !h.isCollection(path.Dir(name)):
+		return http.Error(request, status.ErrConflict)
+	}
+
+	created := err != nil
+
+	file, err := h.fs.Create(name)
+	if err != nil {
+		return fail(request, err)
+	}
+
+	_, err = io.Copy(file, request.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fail(request, err)
+	}
+
+	if created {
+		return http.Code(request, status.Created)
+	}
+
+	return http.Code(request, status.NoContent)
+}
+
+func (h *handler) delete(request *http.Request, name string) *http.Response {
+	if name == "/" {
+		return http.Error(request, status.ErrForbidden)
+	}
+
+	if depth, ok := parseDepth(request, depthInfinity); !ok || depth != depthInfinity {
+		return http.Error(request, status.ErrBadRequest)
+	}
+
+	if !h.confirm(request, name, true) {
+		return http.Error(request, status.ErrLocked)
+	}
+
+	if _, err := h.fs.Stat(name); err != nil {
+		return fail(request, err)
+	}
+
+	if err := h.remove(name); err != nil {
+		return fail(request, err)
+	}
+
+	return http.Code(request, status.NoContent)
+}
+
+func (h *handler) mkcol(request *http.Request, name string) *http.Response {
+	if request.ContentLength > 0 || request.Chunked {
+		// extended MKCOL isn't supported
+		return http.Error(request, status.ErrUnsupportedMediaType)
+	}
+
+	if !h.confirm(request, name, false) {
+		return http.Error(request, status.ErrLocked)
+	}
+
+	if _, err := h.fs.Stat(name); err == nil {
+		return http.Error(request, status.ErrMethodNotAllowed).Header("Allow", allow)
+	}
+
+	if !h.isCollection(path.Dir(name)) {
+		return http.Error(request, status.ErrConflict)
+	}
+
+	if err := h.fs.Mkdir(name); err != nil {
+		return fail(request, err)
+	}
+
+	return http.Code(request, status.Created)
+}
+
+func (h *handler) copyMove(request *http.Request, name, base string, move bool) *http.Response {
+	dst, err := destination(request, base)
+	if err != nil {
+		return http.Error(request, err)
+	}
+
+	overwrite := true
+	switch request.Headers.Value("overwrite") {
+	case "", "T", "t":
+	case "F", "f":
+		overwrite = false
+	default:
+		return http.Error(request, status.ErrBadRequest)
+	}
+
+	depth, ok := parseDepth(request, depthInfinity)
+	if !ok || depth == 1 || (move && depth != depthInfinity) {
+		return http.Error(request, status.ErrBadRequest)
+	}
+
+	// neither resource may contain the other one, as overwriting the destination would
+	// otherwise destroy the source first
+	if within(dst, name) || within(name, dst) || (move && name == "/") {
+		return http.Error(request, status.ErrForbidden)
+	}
+
+	info, err := h.fs.Stat(name)
+	if err != nil {
+		return fail(request, err)
+	}
+
+	if (move && !h.confirm(request, name, true)) || !h.confirm(request, dst, true) {
+		return http.Error(request, status.ErrLocked)
+	}
+
+	_, err = h.fs.Stat(dst)
+	exists := err == nil
+	switch {
+	case exists && !overwrite:
+		return http.Error(request, status.ErrPreconditionFailed)
+	case exists:
+		if err = h.remove(dst); err != nil {
+			return fail(request, err)
+		}
+	case !h.isCollection(path.Dir(dst)):
+		return http.Error(request, status.ErrConflict)
+	}
+
+	if move {
+		if err = h.fs.Rename(name, dst); err == nil {
+			err = h.props.Move(name, dst)
+			// locks aren't moved along with the resource (RFC 4918, 7.5)
+			h.locks.Remove(name)
+		}
+	} else {
+		err = h.copy(name, dst, info, depth == depthInfinity)
+	}
+
+	if err != nil {
+		return fail(request, err)
+	}
+
+	if exists {
+		return http.Code(request, status.NoContent)
+	}
+
+	return http.Code(request, status.Created)
+}
+
+func (h *handler) copy(src, dst string, info fs.FileInfo, recursive bool) error {
+	if !info.IsDir() {
+		if err := h.copyFile(src, dst); err != nil {
+			return err
+		}
+
+		return h.props.Copy(src, dst)
+	}
+
+	if err := h.fs.Mkdir(dst); err != nil {
+		return err
+	}
+
+	if err := h.props.Copy(src, dst); err != nil || !recursive {
+		return err
+	}
+
+	members, err := h.readDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		err = h.copy(path.Join(src, member.Name()), path.Join(dst, member.Name()), member, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *handler) copyFile(src, dst string) error {
+	from, err := h.fs.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer from.Close()
+
+	to, err := h.fs.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(to, from)
+	if closeErr := to.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func (h *handler) propfind(request *http.Request, name, base string) *http.Response {
+	depth, ok := parseDepth(request, depthInfinity)
+	if !ok {
+		return http.Error(request, status.ErrBadRequest)
+	}
+
+	if depth == depthInfinity && !h.infiniteDepth {
+		return violation(request, status.Forbidden, "propfind-finite-depth")
+	}
+
+	body, err := request.Body.Bytes()
+	if err != nil {
+		return http.Error(request, err)
+	}
+
+	pf, err := parsePropfind(body)
+	if err != nil {
+		return http.Error(request, status.ErrBadRequest)
+	}
+
+	info, err := h.fs.Stat(name)
+	if err != nil {
+		return fail(request, err)
+	}
+
+	propstats, err := h.propstats(name, base, info, pf)
+	if err != nil {
+		return fail(request, err)
+	}
+
+	ms := newMultistatus()
+	ms.Response(href(base, name, info.IsDir()), propstats...)
+	if !info.IsDir() || depth == 0 {
+		return multiStatus(request, ms)
+	}
+
+	members, err := h.readDir(name)
+	if err != nil {
+		return fail(request, err)
+	}
+
+	h.walk(name, members, depth, func(name string, info fs.FileInfo, err error) {
+		if err != nil {
+			ms.Status(href(base, name, info.IsDir()), errorCode(err))
+			return
+		}
+
+		propstats, err := h.propstats(name, base, info, pf)
+		if err != nil {
+			ms.Status(href(base, name, info.IsDir()), errorCode(err))
+			return
+		}
+
+		ms.Response(href(base, name, info.IsDir()), propstats...)
+	})
+
+	return multiStatus(request, ms)
+}
+
+// walk calls the callback for the members of the collection and for theirs, down to the depth.
+// Members which can't be accessed are passed along with the error, so they're reported alone
+// instead of failing the whole walk.
+func (h *handler) walk(name string, members []fs.FileInfo, depth int, fn func(string, fs.FileInfo, error)) {
+	if depth > 0 {
+		depth--
+	}
+
+	for _, member := range members {
+		memberName := path.Join(name, member.Name())
+		if u, ok := member.(unreadable); ok {
+			fn(memberName, member, u.err)
+			continue
+		}
+
+		if !member.IsDir() || depth == 0 {
+			fn(memberName, member, nil)
+			continue
+		}
+
+		nested, err := h.readDir(memberName)
+		fn(memberName, member, err)
+		if err == nil {
+			h.walk(memberName, nested, depth, fn)
+		}
+	}
+}
+
+func (h *handler) propstats(name, base string, info fs.FileInfo, pf propfind) ([]propstat, error) {
+	dead, err := h.props.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	props := append(h.liveProps(name, base, info), dead...)
+
+	switch {
+	case pf.propname:
+		for i := range props {
+			props[i].InnerXML = nil
+		}
+
+		fallthrough
+	case pf.allprop:
+		return []propstat{{code: status.OK, props: props}}, nil
+	}
+
+	found := propstat{code: status.OK}
+	missing := propstat{code: status.NotFound}
+	for _, requested := range pf.props {
+		i := slices.IndexFunc(props, func(prop Property) bool {
+			return prop.XMLName == requested
+		})
+		if i == -1 {
+			missing.props = append(missing.props, Property{XMLName: requested})
+		} else {
+			found.props = append(found.props, props[i])
+		}
+	}
+
+	return []propstat{found, missing}, nil
+}
+
+// protected enlists properties computed by the server, which therefore can't be altered.
+var protected = []string{
+	"creationdate", "displayname", "getcontentlength", "getcontenttype", "getetag",
+	"getlastmodified", "lockdiscovery", "resourcetype", "supportedlock",
+}
+
+func (h *handler) liveProps(name, base string, info fs.FileInfo) []Property {
+	var resourceType []byte
+	if info.IsDir() {
+		resourceType = []byte("<D:collection/>")
+	}
+
+	var displayName bytes.Buffer
+	escape(&displayName, info.Name())
+
+	props := []Property{
+		{XMLName: davName("resourcetype"), InnerXML: resourceType},
+		{XMLName: davName("displayname"), InnerXML: displayName.Bytes()},
+		{XMLName: davName("getlastmodified"), InnerXML: []byte(info.ModTime().UTC().Format(timeFormat))},
+	}
+
+	if !info.IsDir() {
+		var contentType bytes.Buffer
+		escape(&contentType, mime.Guess(name, mime.OctetStream))
+
+		props = append(props,
+			Property{XMLName: davName("getcontentlength"), InnerXML: []byte(strconv.FormatInt(info.Size(), 10))},
+			Property{XMLName: davName("getcontenttype"), InnerXML: contentType.Bytes()},
+			Property{XMLName: davName("getetag"), InnerXML: []byte(etag(info))},
+		)
+	}
+
+	var discovery bytes.Buffer
+	for _, l := range h.locks.Discover(name) {
+		writeActiveLock(&discovery, l, base)
+	}
+
+	return append(props,
+		Property{
+			XMLName: davName("supportedlock"),
+			InnerXML: []byte(
+				"<D:lockentry><D:lockscope><D:exclusive/></D:lockscope><D:locktype><D:write/></D:locktype></D:lockentry>" +
+					"<D:lockentry><D:lockscope><D:shared/></D:lockscope><D:locktype><D:write/></D:locktype></D:lockentry>",
+			),
+		},
+		Property{XMLName: davName("lockdiscovery"), InnerXML: discovery.Bytes()},
+	)
+}
+
+func (h *handler) proppatch(request *http.Request, name, base string) *http.Response {
+	if !h.confirm(request, name, false) {
+		return http.Error(request, status.ErrLocked)
+	}
+
+	info, err := h.fs.Stat(name)
+	if err != nil {
+		return fail(request, err)
+	}
+
+	body, err := request.Body.Bytes()
+	if err != nil {
+		return http.Error(request, err)
+	}
+
+	patches, err := parsePropertyUpdate(body)
+	if err != nil {
+		return http.Error(request, status.ErrBadRequest)
+	}
+
+	var (
+		set     []Property
+		remove  []xml.Name
+		applied = propstat{code: status.OK}
+		denied  = propstat{code: status.Forbidden}
+	)
+
+	for _, p := range patches {
+		key := p.prop.XMLName
+		if key.Space == davNS && slices.Contains(protected, key.Local) {
+			denied.props = append(denied.props, Property{XMLName: key})
+			continue
+		}
+
+		applied.props = append(applied.props, Property{XMLName: key})
+		// instructions are processed in the document order, so the latest one wins
+		set = slices.DeleteFunc(set, func(prop Property) bool { return prop.XMLName == key })
+		remove = slices.DeleteFunc(remove, func(n xml.Name) bool { return n == key })
+		if p.remove {
+			remove = append(remove, key)
+		} else {
+			set = append(set, p.prop)
+		}
+	}
+
+	if len(denied.props) > 0 {
+		// either all the instructions are applied or none of them
+		applied.code = status.FailedDependency
+	} else if err = h.props.Patch(name, set, remove); err != nil {
+		return fail(request, err)
+	}
+
+	ms := newMultistatus()
+	ms.Response(href(base, name, info.IsDir()), applied, denied)
+
+	return multiStatus(request, ms)
+}
+
+func (h *handler) lock(request *http.Request, name, base string) *http.Response {
+	depth, ok := parseDepth(request, depthInfinity)
+	if !ok || depth == 1 {
+		return http.Error(request, status.ErrBadRequest)
+	}
+
+	timeout := parseTimeout(request.Headers.Value("timeout"))
+
+	body, err := request.Body.Bytes()
+	if err != nil {
+		return http.Error(request, err)
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		// a body-less request refreshes an existing lock
+		tokens := submittedTokens(request.Headers.Value("if"))
+		l, found := h.locks.Refresh(name, tokens, timeout)
+		if !found {
+			return http.Error(request, status.ErrPreconditionFailed)
+		}
+
+		return lockDiscovery(request, *l, base)
+	}
+
+	info, err := parseLockInfo(body)
+	if err != nil {
+		return http.Error(request, status.ErrBadRequest)
+	}
+
+	_, err = h.fs.Stat(name)
+	created := err != nil
+	switch {
+	case created && !errors.Is(err, fs.ErrNotExist):
+		return fail(request, err)
+	case created && !h.isCollection(path.Dir(name)):
+		return http.Error(request, status.ErrConflict)
+	}
+
+	l, ok := h.locks.Create(name, depth == depthInfinity, info.shared, info.owner, timeout)
+	if !ok {
+		return http.Error(request, status.ErrLocked)
+	}
+
+	if created {
+		// locking an unmapped URL creates an empty resource (RFC 4918, 9.10.4)
+		file, err := h.fs.Create(name)
+		if err == nil {
+			err = file.Close()
+		}
+
+		if err != nil {
+			h.locks.Unlock(name, l.token)
+			return fail(request, err)
+		}
+	}
+
+	response := lockDiscovery(request, *l, base).Header("Lock-Token", "<"+l.token+">")
+	if created {
+		response.Code(status.Created)
+	}
+
+	return response
+}
+
+func (h *handler) unlock(request *http.Request, name string) *http.Response {
+	token := strings.TrimSpace(request.Headers.Value("lock-token"))
+	if len(token) < 2 || token[0] != '<' || token[len(token)-1] != '>' {
+		return http.Error(request, status.ErrBadRequest)
+	}
+
+	if !h.locks.Unlock(name, token[1:len(token)-1]) {
+		return http.Error(request, status.ErrConflict)
+	}
+
+	return http.Code(request, status.NoContent)
+}
+
+// confirm reports whether the request submitted tokens of all the locks preventing the
+// resource from being modified.
+func (h *handler) confirm(request *http.Request, name string, recursive bool) bool {
+	return h.locks.Confirm(name, recursive, submittedTokens(request.Headers.Value("if")))
+}
+
+// remove deletes the resource along with its properties and locks.
+func (h *handler) remove(name string) error {
+	if err := h.fs.RemoveAll(name); err != nil {
+		return err
+	}
+
+	h.locks.Remove(name)
+	return h.props.Delete(name)
+}
+
+func (h *handler) isCollection(name string) bool {
+	info, err := h.fs.Stat(name)
+	return err == nil && info.IsDir()
+}
+
+// readDir returns members of the collection sorted by their names.
+func (h *handler) readDir(name string) ([]fs.FileInfo, error) {
+	members, err := h.fs.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(members, func(a, b fs.FileInfo) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return members, nil
+}
+
+// destination resolves the Destination header into the resource name. Destinations outside
+// the mount point are rejected, as they can't be served by the same file system.
+func destination(request *http.Request, base string) (string, error) {
+	value := request.Headers.Value("destination")
+	if len(value) == 0 {
+		return "", status.ErrBadRequest
+	}
+
+	dst, err := url.Parse(value)
+	if err != nil {
+		return "", status.ErrBadRequest
+	}
+
+	if len(dst.Host) > 0 && !strings.EqualFold(dst.Host, request.Host()) {
+		return "", status.ErrBadGateway
+	}
+
+	rel, found := strings.CutPrefix(dst.Path, base)
+	if !found || (len(rel) > 0 && rel[0] != '/') {
+		return "", status.ErrBadGateway
+	}
+
+	return clean(rel), nil
+}
+
+// parseDepth parses the Depth header value. Depth 1 is returned as 1, therefore only 0, 1 and
+// depthInfinity are possible.
+func parseDepth(request *http.Request, otherwise int) (depth int, ok bool) {
+	switch value, found := request.Headers.Lookup("depth"); {
+	case !found:
+		return otherwise, true
+	case value == "0":
+		return 0, true
+	case value == "1":
+		return 1, true
+	case strings.EqualFold(value, "infinity"):
+		return depthInfinity, true
+	default:
+		return 0, false
+	}
+}
+
+func lockDiscovery(request *http.Request, l lock, base string) *http.Response {
+	var buff bytes.Buffer
+	buff.WriteString(xml.Header)
+	buff.WriteString(`<D:prop xmlns:D="DAV:"><D:lockdiscovery>`)
+	writeActiveLock(&buff, l, base)
+	buff.WriteString("</D:lockdiscovery></D:prop>")
+
+	return request.Respond().
+		ContentType(mime.XML, mime.UTF8).
+		Bytes(buff.Bytes())
+}
+
+func writeActiveLock(buff *bytes.Buffer, l lock, base string) {
+	scope, depth := "<D:exclusive/>", "0"
+	if l.shared {
+		scope = "<D:shared/>"
+	}
+
+	if l.recursive {
+		depth = "infinity"
+	}
+
+	buff.WriteString("<D:activelock><D:locktype><D:write/></D:locktype><D:lockscope>")
+	buff.WriteString(scope)
+	buff.WriteString("</D:lockscope><D:depth>")
+	buff.WriteString(depth)
+	buff.WriteString("</D:depth>")
+	if len(l.owner) > 0 {
+		buff.WriteString("<D:owner>")
+		buff.Write(l.owner)
+		buff.WriteString("</D:owner>")
+	}
+
+	buff.WriteString("<D:timeout>")
+	buff.WriteString(formatTimeout(l.timeout))
+	buff.WriteString("</D:timeout><D:locktoken><D:href>")
+	escape(buff, l.token)
+	buff.WriteString("</D:href></D:locktoken><D:lockroot><D:href>")
+	escape(buff, href(base, l.root, false))
+	buff.WriteString("</D:href></D:lockroot></D:activelock>")
+}
+
+// violation responds with the error element naming the violated precondition (RFC 4918, 16).
+func violation(request *http.Request, code status.Code, condition string) *http.Response {
+	return request.Respond().
+		Code(code).
+		ContentType(mime.XML, mime.UTF8).
+		String(xml.Header + `<D:error xmlns:D="DAV:"><D:` + condition + `/></D:error>`)
+}
+
+func multiStatus(request *http.Request, ms *multistatus) *http.Response {
+	return request.Respond().
+		Code(status.MultiStatus).
+		ContentType(mime.XML, mime.UTF8).
+		Bytes(ms.Bytes())
+}
+
+// href returns the percent-encoded path of the resource. Collections are denoted by
+// the trailing slash.
+func href(base, name string, collection bool) string {
+	p := base + name
+	if collection && !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+
+	return (&url.URL{Path: p}).EscapedPath()
+}
+
+func etag(info fs.FileInfo) string {
+	return `"` + strconv.FormatInt(info.ModTime().UnixNano(), 16) +
+		"-" + strconv.FormatInt(info.Size(), 16) + `"`
+}
+
+func fail(request *http.Request, err error) *http.Response {
+	return http.Code(request, errorCode(err))
+}
+
+// errorCode maps the file system error to the status code.
+func errorCode(err error) status.Code {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return status.NotFound
+	case errors.Is(err, fs.ErrPermission):
+		return status.Forbidden
+	case errors.Is(err, fs.ErrExist):
+		return status.Conflict
+	default:
+		return status.InternalServerError
+	}
+}
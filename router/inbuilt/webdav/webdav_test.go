@@ -0,0 +1,511 @@
+package webdav
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/method"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/internal/construct"
+	"github.com/indigo-web/indigo/router"
+	"github.com/indigo-web/indigo/router/inbuilt"
+	"github.com/indigo-web/indigo/transport/dummy"
+	"github.com/stretchr/testify/require"
+)
+
+type result struct {
+	code    status.Code
+	headers map[string]string
+	body    string
+}
+
+func newServer(t *testing.T, params ...Params) (router.Router, string) {
+	root := t.TempDir()
+	r := inbuilt.New()
+	Mount(r, "/dav", Dir(root), params...)
+
+	return r.Build(), root
+}
+
+// do passes the request through the router. Headers are passed as key-value pairs.
+func do(t *testing.T, r router.Router, m method.Method, path, body string, headers ...string) result {
+	request := construct.Request(config.Default(), dummy.NewNopClient())
+	request.Method = m
+	request.Path = path
+	request.ContentLength = len(body)
+	request.Body = http.NewBody(dummy.NewMockClient([]byte(body)))
+	request.Body.Reset(request)
+	request.Headers.Add("Host", "localhost")
+	for i := 0; i < len(headers); i += 2 {
+		request.Headers.Add(headers[i], headers[i+1])
+	}
+
+	fields := r.OnRequest(request).Expose()
+	res := result{
+		code:    fields.Code,
+		headers: make(map[string]string),
+	}
+
+	for _, header := range fields.Headers {
+		res.headers[header.Key] = header.Value
+	}
+
+	if fields.Stream != nil {
+		data, err := io.ReadAll(fields.Stream)
+		require.NoError(t, err)
+		res.body = string(data)
+		if closer, ok := fields.Stream.(io.Closer); ok {
+			require.NoError(t, closer.Close())
+		}
+	}
+
+	return res
+}
+
+func writeFile(t *testing.T, root, name, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0o644))
+}
+
+func readFile(t *testing.T, root, name string) string {
+	data, err := os.ReadFile(filepath.Join(root, name))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestBasic(t *testing.T) {
+	r, root := newServer(t)
+
+	t.Run("options", func(t *testing.T) {
+		resp := do(t, r, method.OPTIONS, "/dav", "")
+		require.Equal(t, status.OK, resp.code)
+		require.Equal(t, "1, 2", resp.headers["DAV"])
+		require.Contains(t, resp.headers["Allow"], "PROPFIND")
+	})
+
+	t.Run("put and get", func(t *testing.T) {
+		resp := do(t, r, method.PUT, "/dav/hello.txt", "Hello, world!")
+		require.Equal(t, status.Created, resp.code)
+		require.Equal(t, "Hello, world!", readFile(t, root, "hello.txt"))
+
+		resp = do(t, r, method.PUT, "/dav/hello.txt", "Bye!")
+		require.Equal(t, status.NoContent, resp.code)
+
+		resp = do(t, r, method.GET, "/dav/hello.txt", "")
+		require.Equal(t, status.OK, resp.code)
+		require.Equal(t, "Bye!", resp.body)
+		require.NotEmpty(t, resp.headers["ETag"])
+	})
+
+	t.Run("put without parent", func(t *testing.T) {
+		resp := do(t, r, method.PUT, "/dav/missing/hello.txt", "")
+		require.Equal(t, status.Conflict, resp.code)
+	})
+
+	t.Run("mkcol", func(t *testing.T) {
+		require.Equal(t, status.Created, do(t, r, method.MKCOL, "/dav/docs", "").code)
+		require.Equal(t, status.MethodNotAllowed, do(t, r, method.MKCOL, "/dav/docs", "").code)
+		require.Equal(t, status.Conflict, do(t, r, method.MKCOL, "/dav/a/b", "").code)
+		require.Equal(t, status.UnsupportedMediaType, do(t, r, method.MKCOL, "/dav/c", "<x/>").code)
+
+		info, err := os.Stat(filepath.Join(root, "docs"))
+		require.NoError(t, err)
+		require.True(t, info.IsDir())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		writeFile(t, root, "trash/file.txt", "")
+		require.Equal(t, status.NoContent, do(t, r, method.DELETE, "/dav/trash", "").code)
+		require.Equal(t, status.NotFound, do(t, r, method.DELETE, "/dav/trash", "").code)
+		require.Equal(t, status.Forbidden, do(t, r, method.DELETE, "/dav", "").code)
+	})
+
+	t.Run("traversal", func(t *testing.T) {
+		resp := do(t, r, method.GET, "/dav/../../etc/passwd", "")
+		require.Equal(t, status.NotFound, resp.code)
+	})
+
+	t.Run("not allowed method", func(t *testing.T) {
+		resp := do(t, r, method.POST, "/dav/hello.txt", "")
+		require.Equal(t, status.MethodNotAllowed, resp.code)
+	})
+}
+
+func TestPropfind(t *testing.T) {
+	r, root := newServer(t)
+	writeFile(t, root, "a.txt", "hello")
+	writeFile(t, root, "dir/b.txt", "")
+	writeFile(t, root, "dir/sub/c.txt", "")
+
+	t.Run("depth 0", func(t *testing.T) {
+		resp := do(t, r, method.PROPFIND, "/dav/", "", "Depth", "0")
+		require.Equal(t, status.MultiStatus, resp.code)
+		require.Equal(t, 1, strings.Count(resp.body, "<D:response>"))
+		require.Contains(t, resp.body, "<D:href>/dav/</D:href>")
+		require.Contains(t, resp.body, "<D:resourcetype><D:collection/></D:resourcetype>")
+	})
+
+	t.Run("depth 1", func(t *testing.T) {
+		resp := do(t, r, method.PROPFIND, "/dav", "", "Depth", "1")
+		require.Equal(t, status.MultiStatus, resp.code)
+		require.Equal(t, 3, strings.Count(resp.body, "<D:response>"))
+		require.Contains(t, resp.body, "<D:href>/dav/a.txt</D:href>")
+		require.Contains(t, resp.body, "<D:href>/dav/dir/</D:href>")
+		require.Contains(t, resp.body, "<D:getcontentlength>5</D:getcontentlength>")
+		require.Contains(t, resp.body, "<D:getcontenttype>application/octet-stream</D:getcontenttype>")
+	})
+
+	t.Run("depth infinity", func(t *testing.T) {
+		for _, headers := range [][]string{{"Depth", "infinity"}, nil} {
+			resp := do(t, r, method.PROPFIND, "/dav/dir", "", headers...)
+			require.Equal(t, status.Forbidden, resp.code)
+			require.Contains(t, resp.body, `<D:error xmlns:D="DAV:"><D:propfind-finite-depth/></D:error>`)
+		}
+
+		r, root := newServer(t, Params{InfiniteDepth: true})
+		writeFile(t, root, "dir/b.txt", "")
+		writeFile(t, root, "dir/sub/c.txt", "")
+		resp := do(t, r, method.PROPFIND, "/dav/dir", "")
+		require.Equal(t, status.MultiStatus, resp.code)
+		require.Equal(t, 4, strings.Count(resp.body, "<D:response>"))
+		require.Contains(t, resp.body, "<D:href>/dav/dir/sub/c.txt</D:href>")
+	})
+
+	t.Run("unreadable member", func(t *testing.T) {
+		r, root := newServer(t)
+		writeFile(t, root, "dir/a.txt", "")
+		require.NoError(t, os.Symlink("loop", filepath.Join(root, "dir", "loop")))
+
+		resp := do(t, r, method.PROPFIND, "/dav/dir", "", "Depth", "1")
+		require.Equal(t, status.MultiStatus, resp.code)
+		require.Equal(t, 3, strings.Count(resp.body, "<D:response>"))
+		require.Contains(t, resp.body, "<D:href>/dav/dir/a.txt</D:href>")
+		require.Contains(t, resp.body,
+			"<D:response><D:href>/dav/dir/loop</D:href>"+
+				"<D:status>HTTP/1.1 500 Internal Server Error</D:status></D:response>")
+	})
+
+	t.Run("prop", func(t *testing.T) {
+		body := `<?xml version="1.0"?>
+			<propfind xmlns="DAV:"><prop><getcontentlength/><quota xmlns="urn:x"/></prop></propfind>`
+		resp := do(t, r, method.PROPFIND, "/dav/a.txt", body, "Depth", "0")
+		require.Equal(t, status.MultiStatus, resp.code)
+		require.Contains(t, resp.body,
+			"<D:propstat><D:prop><D:getcontentlength>5</D:getcontentlength></D:prop>"+
+				"<D:status>HTTP/1.1 200 OK</D:status></D:propstat>")
+		require.Contains(t, resp.body,
+			`<D:propstat><D:prop><quota xmlns="urn:x"/></D:prop>`+
+				"<D:status>HTTP/1.1 404 Not Found</D:status></D:propstat>")
+		require.NotContains(t, resp.body, "getetag")
+	})
+
+	t.Run("propname", func(t *testing.T) {
+		body := `<D:propfind xmlns:D="DAV:"><D:propname/></D:propfind>`
+		resp := do(t, r, method.PROPFIND, "/dav/a.txt", body, "Depth", "0")
+		require.Equal(t, status.MultiStatus, resp.code)
+		require.Contains(t, resp.body, "<D:getcontentlength/>")
+		require.NotContains(t, resp.body, ">5<")
+	})
+
+	t.Run("missing resource", func(t *testing.T) {
+		resp := do(t, r, method.PROPFIND, "/dav/nothing", "", "Depth", "0")
+		require.Equal(t, status.NotFound, resp.code)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		resp := do(t, r, method.PROPFIND, "/dav/a.txt", "<propfind", "Depth", "0")
+		require.Equal(t, status.BadRequest, resp.code)
+
+		resp = do(t, r, method.PROPFIND, "/dav/a.txt", "", "Depth", "2")
+		require.Equal(t, status.BadRequest, resp.code)
+	})
+}
+
+func TestProppatch(t *testing.T) {
+	r, root := newServer(t)
+	writeFile(t, root, "a.txt", "")
+
+	set := `<D:propertyupdate xmlns:D="DAV:" xmlns:Z="urn:z">
+		<D:set><D:prop><Z:author><Z:name>Pavlo</Z:name></Z:author><Z:rating>5</Z:rating></D:prop></D:set>
+		</D:propertyupdate>`
+	resp := do(t, r, method.PROPPATCH, "/dav/a.txt", set)
+	require.Equal(t, status.MultiStatus, resp.code)
+	require.Contains(t, resp.body, `<author xmlns="urn:z"/><rating xmlns="urn:z"/>`)
+	require.Contains(t, resp.body, "HTTP/1.1 200 OK")
+
+	resp = do(t, r, method.PROPFIND, "/dav/a.txt", "", "Depth", "0")
+	require.Contains(t, resp.body, `<author xmlns="urn:z"><name xmlns="urn:z">Pavlo</name></author>`)
+	require.Contains(t, resp.body, `<rating xmlns="urn:z">5</rating>`)
+
+	t.Run("protected", func(t *testing.T) {
+		body := `<propertyupdate xmlns="DAV:"><remove><prop><rating xmlns="urn:z"/></prop></remove>
+			<set><prop><getetag>"x"</getetag></prop></set></propertyupdate>`
+		resp := do(t, r, method.PROPPATCH, "/dav/a.txt", body)
+		require.Equal(t, status.MultiStatus, resp.code)
+		require.Contains(t, resp.body, "HTTP/1.1 403 Forbidden")
+		require.Contains(t, resp.body, "HTTP/1.1 424 Failed Dependency")
+
+		resp = do(t, r, method.PROPFIND, "/dav/a.txt", "", "Depth", "0")
+		require.Contains(t, resp.body, `<rating xmlns="urn:z">5</rating>`)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		body := `<propertyupdate xmlns="DAV:"><remove><prop><rating xmlns="urn:z"/></prop></remove></propertyupdate>`
+		resp := do(t, r, method.PROPPATCH, "/dav/a.txt", body)
+		require.Equal(t, status.MultiStatus, resp.code)
+
+		resp = do(t, r, method.PROPFIND, "/dav/a.txt", "", "Depth", "0")
+		require.NotContains(t, resp.body, "rating")
+		require.Contains(t, resp.body, "Pavlo")
+	})
+
+	t.Run("follow moves", func(t *testing.T) {
+		resp := do(t, r, method.MOVE, "/dav/a.txt", "", "Destination", "http://localhost/dav/b.txt")
+		require.Equal(t, status.Created, resp.code)
+
+		resp = do(t, r, method.PROPFIND, "/dav/b.txt", "", "Depth", "0")
+		require.Contains(t, resp.body, "Pavlo")
+
+		resp = do(t, r, method.COPY, "/dav/b.txt", "", "Destination", "/dav/c.txt")
+		require.Equal(t, status.Created, resp.code)
+		resp = do(t, r, method.PROPFIND, "/dav/c.txt", "", "Depth", "0")
+		require.Contains(t, resp.body, "Pavlo")
+
+		resp = do(t, r, method.DELETE, "/dav/c.txt", "")
+		require.Equal(t, status.NoContent, resp.code)
+		writeFile(t, root, "c.txt", "")
+		resp = do(t, r, method.PROPFIND, "/dav/c.txt", "", "Depth", "0")
+		require.NotContains(t, resp.body, "Pavlo")
+	})
+}
+
+func TestCopyMove(t *testing.T) {
+	r, root := newServer(t)
+	writeFile(t, root, "src/a.txt", "a")
+	writeFile(t, root, "src/nested/b.txt", "b")
+	writeFile(t, root, "existing.txt", "old")
+
+	t.Run("copy collection", func(t *testing.T) {
+		resp := do(t, r, method.COPY, "/dav/src", "", "Destination", "/dav/copy")
+		require.Equal(t, status.Created, resp.code)
+		require.Equal(t, "b", readFile(t, root, "copy/nested/b.txt"))
+		require.Equal(t, "a", readFile(t, root, "src/a.txt"))
+	})
+
+	t.Run("shallow copy", func(t *testing.T) {
+		resp := do(t, r, method.COPY, "/dav/src", "", "Destination", "/dav/shallow", "Depth", "0")
+		require.Equal(t, status.Created, resp.code)
+		entries, err := os.ReadDir(filepath.Join(root, "shallow"))
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		resp := do(t, r, method.COPY, "/dav/src/a.txt", "",
+			"Destination", "/dav/existing.txt", "Overwrite", "F")
+		require.Equal(t, status.PreconditionFailed, resp.code)
+		require.Equal(t, "old", readFile(t, root, "existing.txt"))
+
+		resp = do(t, r, method.COPY, "/dav/src/a.txt", "", "Destination", "/dav/existing.txt")
+		require.Equal(t, status.NoContent, resp.code)
+		require.Equal(t, "a", readFile(t, root, "existing.txt"))
+	})
+
+	t.Run("move", func(t *testing.T) {
+		resp := do(t, r, method.MOVE, "/dav/copy", "", "Destination", "/dav/moved")
+		require.Equal(t, status.Created, resp.code)
+		require.Equal(t, "b", readFile(t, root, "moved/nested/b.txt"))
+		_, err := os.Stat(filepath.Join(root, "copy"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("bad destinations", func(t *testing.T) {
+		resp := do(t, r, method.MOVE, "/dav/src", "", "Destination", "/dav/src/inner")
+		require.Equal(t, status.Forbidden, resp.code)
+
+		for _, m := range []method.Method{method.COPY, method.MOVE} {
+			resp = do(t, r, m, "/dav/src/nested", "", "Destination", "/dav/src", "Overwrite", "T")
+			require.Equal(t, status.Forbidden, resp.code, m.String())
+			require.Equal(t, "b", readFile(t, root, "src/nested/b.txt"), m.String())
+		}
+
+		resp = do(t, r, method.COPY, "/dav/src", "", "Destination", "/dav/missing/inner")
+		require.Equal(t, status.Conflict, resp.code)
+
+		resp = do(t, r, method.COPY, "/dav/src", "", "Destination", "http://example.com/dav/x")
+		require.Equal(t, status.BadGateway, resp.code)
+
+		resp = do(t, r, method.COPY, "/dav/src", "", "Destination", "/elsewhere/x")
+		require.Equal(t, status.BadGateway, resp.code)
+
+		resp = do(t, r, method.MOVE, "/dav/src", "", "Destination", "/dav/x", "Depth", "0")
+		require.Equal(t, status.BadRequest, resp.code)
+
+		resp = do(t, r, method.COPY, "/dav/src", "")
+		require.Equal(t, status.BadRequest, resp.code)
+	})
+}
+
+const lockBody = `<?xml version="1.0" encoding="utf-8"?>
+<D:lockinfo xmlns:D="DAV:">
+	<D:lockscope><D:%s/></D:lockscope>
+	<D:locktype><D:write/></D:locktype>
+	<D:owner><D:href>mailto:pavlo@example.com</D:href></D:owner>
+</D:lockinfo>`
+
+func lockToken(t *testing.T, resp result) string {
+	token := resp.headers["Lock-Token"]
+	require.True(t, strings.HasPrefix(token, "<urn:uuid:"), token)
+	return token
+}
+
+func TestLock(t *testing.T) {
+	exclusive := strings.Replace(lockBody, "%s", "exclusive", 1)
+	shared := strings.Replace(lockBody, "%s", "shared", 1)
+
+	t.Run("exclusive", func(t *testing.T) {
+		r, root := newServer(t)
+		writeFile(t, root, "dir/a.txt", "")
+		writeFile(t, root, "b.txt", "")
+
+		resp := do(t, r, method.LOCK, "/dav/dir", exclusive, "Timeout", "Second-60")
+		require.Equal(t, status.OK, resp.code)
+		require.Contains(t, resp.body, "<D:timeout>Second-60</D:timeout>")
+		require.Contains(t, resp.body, "<D:depth>infinity</D:depth>")
+		require.Contains(t, resp.body, `<D:owner><href xmlns="DAV:">mailto:pavlo@example.com</href></D:owner>`)
+		require.Contains(t, resp.body, "<D:lockroot><D:href>/dav/dir</D:href></D:lockroot>")
+		token := lockToken(t, resp)
+
+		require.Equal(t, status.Locked, do(t, r, method.LOCK, "/dav/dir/a.txt", shared).code)
+		require.Equal(t, status.Locked, do(t, r, method.PUT, "/dav/dir/a.txt", "x").code)
+		require.Equal(t, status.Locked, do(t, r, method.DELETE, "/dav/dir", "").code)
+		require.Equal(t, status.Locked, do(t, r, method.MOVE, "/dav/dir/a.txt", "", "Destination", "/dav/b.txt").code)
+		require.Equal(t, status.Locked, do(t, r, method.COPY, "/dav/b.txt", "", "Destination", "/dav/dir/b.txt").code)
+
+		resp = do(t, r, method.PUT, "/dav/dir/a.txt", "x", "If", "(<urn:uuid:nonexisting>)")
+		require.Equal(t, status.Locked, resp.code)
+		resp = do(t, r, method.PUT, "/dav/dir/a.txt", "x", "If", "</dav/dir> ("+token+")")
+		require.Equal(t, status.NoContent, resp.code)
+		require.Equal(t, "x", readFile(t, root, "dir/a.txt"))
+
+		resp = do(t, r, method.PROPFIND, "/dav/dir/a.txt", "", "Depth", "0")
+		require.Contains(t, resp.body, "<D:locktoken><D:href>"+strings.Trim(token, "<>")+"</D:href></D:locktoken>")
+
+		require.Equal(t, status.BadRequest, do(t, r, method.UNLOCK, "/dav/dir", "").code)
+		require.Equal(t, status.Conflict, do(t, r, method.UNLOCK, "/dav/dir", "", "Lock-Token", "<urn:uuid:x>").code)
+		require.Equal(t, status.NoContent, do(t, r, method.UNLOCK, "/dav/dir/a.txt", "", "Lock-Token", token).code)
+		require.Equal(t, status.NoContent, do(t, r, method.PUT, "/dav/dir/a.txt", "y").code)
+	})
+
+	t.Run("shared", func(t *testing.T) {
+		r, root := newServer(t)
+		writeFile(t, root, "a.txt", "")
+
+		first := do(t, r, method.LOCK, "/dav/a.txt", shared, "Depth", "0")
+		require.Equal(t, status.OK, first.code)
+		second := do(t, r, method.LOCK, "/dav/a.txt", shared, "Depth", "0")
+		require.Equal(t, status.OK, second.code)
+		require.NotEqual(t, lockToken(t, first), lockToken(t, second))
+		require.Equal(t, status.Locked, do(t, r, method.LOCK, "/dav/a.txt", exclusive).code)
+
+		// any of the holders can modify the resource by submitting their own lock
+		require.Equal(t, status.Locked, do(t, r, method.PUT, "/dav/a.txt", "x").code)
+		resp := do(t, r, method.PUT, "/dav/a.txt", "x", "If", "("+lockToken(t, first)+")")
+		require.Equal(t, status.NoContent, resp.code)
+		resp = do(t, r, method.PUT, "/dav/a.txt", "y", "If", "("+lockToken(t, second)+")")
+		require.Equal(t, status.NoContent, resp.code)
+		require.Equal(t, "y", readFile(t, root, "a.txt"))
+		resp = do(t, r, method.PUT, "/dav/a.txt", "", "If", "(<urn:uuid:x>)")
+		require.Equal(t, status.Locked, resp.code)
+	})
+
+	t.Run("shared members", func(t *testing.T) {
+		l := newLocks(time.Minute)
+		first, ok := l.Create("/dir/a", false, true, nil, 0)
+		require.True(t, ok)
+		second, ok := l.Create("/dir/a", false, true, nil, 0)
+		require.True(t, ok)
+		member, ok := l.Create("/dir/b", false, true, nil, 0)
+		require.True(t, ok)
+
+		require.True(t, l.Confirm("/dir/a", false, []string{second.token}))
+		// every locked member must be confirmed by one of its own locks
+		require.False(t, l.Confirm("/dir", true, []string{first.token, second.token}))
+		require.True(t, l.Confirm("/dir", true, []string{first.token, member.token}))
+
+		exclusive, ok := l.Create("/dir/c", false, false, nil, 0)
+		require.True(t, ok)
+		require.False(t, l.Confirm("/dir", true, []string{first.token, member.token}))
+		require.True(t, l.Confirm("/dir", true, []string{first.token, member.token, exclusive.token}))
+	})
+
+	t.Run("unmapped", func(t *testing.T) {
+		r, root := newServer(t)
+
+		resp := do(t, r, method.LOCK, "/dav/new.txt", exclusive)
+		require.Equal(t, status.Created, resp.code)
+		require.Empty(t, readFile(t, root, "new.txt"))
+
+		resp = do(t, r, method.LOCK, "/dav/missing/new.txt", exclusive)
+		require.Equal(t, status.Conflict, resp.code)
+	})
+
+	t.Run("refresh", func(t *testing.T) {
+		r, root := newServer(t, Params{LockTimeout: 2 * time.Minute})
+		writeFile(t, root, "a.txt", "")
+
+		resp := do(t, r, method.LOCK, "/dav/a.txt", exclusive, "Timeout", "Infinite")
+		require.Equal(t, status.OK, resp.code)
+		require.Contains(t, resp.body, "<D:timeout>Second-120</D:timeout>")
+		token := lockToken(t, resp)
+
+		resp = do(t, r, method.LOCK, "/dav/a.txt", "", "Timeout", "Second-30", "If", "("+token+")")
+		require.Equal(t, status.OK, resp.code)
+		require.Contains(t, resp.body, "<D:timeout>Second-30</D:timeout>")
+
+		resp = do(t, r, method.LOCK, "/dav/a.txt", "", "If", "(<urn:uuid:x>)")
+		require.Equal(t, status.PreconditionFailed, resp.code)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		l := newLocks(time.Minute)
+		held, ok := l.Create("/a", false, false, nil, time.Second)
+		require.True(t, ok)
+		require.False(t, l.Confirm("/a", false, nil))
+
+		held.expires = time.Now().Add(-time.Second)
+		require.True(t, l.Confirm("/a", false, nil))
+		require.Empty(t, l.Discover("/a"))
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		r, _ := newServer(t)
+		body := `<D:lockinfo xmlns:D="DAV:"><D:lockscope><D:exclusive/></D:lockscope></D:lockinfo>`
+		require.Equal(t, status.BadRequest, do(t, r, method.LOCK, "/dav/a.txt", body).code)
+		require.Equal(t, status.BadRequest, do(t, r, method.LOCK, "/dav/a.txt", exclusive, "Depth", "1").code)
+	})
+}
+
+func TestSubmittedTokens(t *testing.T) {
+	for value, want := range map[string][]string{
+		"":                                     nil,
+		"(<urn:uuid:a>)":                       {"urn:uuid:a"},
+		"</dav/x> (<urn:uuid:a> [\"<etag>\"])": {"urn:uuid:a"},
+		"(Not <urn:uuid:a>) (<urn:uuid:b>)":    {"urn:uuid:a", "urn:uuid:b"},
+	} {
+		require.Equal(t, want, submittedTokens(value), value)
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	require.Equal(t, time.Duration(0), parseTimeout(""))
+	require.Equal(t, 10*time.Second, parseTimeout("Second-10"))
+	require.Equal(t, 10*time.Second, parseTimeout("Extended, second-10, Infinite"))
+	require.Equal(t, infiniteTimeout, parseTimeout("Infinite, Second-10"))
+}
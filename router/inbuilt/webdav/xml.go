@@ -0,0 +1,415 @@
+package webdav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/indigo-web/indigo/http/status"
+)
+
+const davNS = "DAV:"
+
+var errMalformedXML = errors.New("malformed XML body")
+
+func davName(local string) xml.Name {
+	return xml.Name{Space: davNS, Local: local}
+}
+
+type propfind struct {
+	allprop  bool
+	propname bool
+	// props are the requested properties, used if neither allprop nor propname is set.
+	props []xml.Name
+}
+
+// parsePropfind parses the PROPFIND request body. An empty body is treated as allprop.
+func parsePropfind(body []byte) (pf propfind, err error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return propfind{allprop: true}, nil
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(body))
+	if err = root(d, "propfind"); err != nil {
+		return pf, err
+	}
+
+	for {
+		start, err := child(d)
+		switch {
+		case err == io.EOF:
+			if !pf.allprop && !pf.propname && pf.props == nil {
+				return pf, errMalformedXML
+			}
+
+			return pf, nil
+		case err != nil:
+			return pf, err
+		}
+
+		switch start.Name {
+		case davName("allprop"):
+			pf.allprop = true
+			err = d.Skip()
+		case davName("propname"):
+			pf.propname = true
+			err = d.Skip()
+		case davName("prop"):
+			pf.props, err = childNames(d)
+		default:
+			// e.g. include, which extends allprop with properties returned anyway
+			err = d.Skip()
+		}
+
+		if err != nil {
+			return pf, err
+		}
+	}
+}
+
+// patch is a single instruction of the PROPPATCH request.
+type patch struct {
+	remove bool
+	prop   Property
+}
+
+// parsePropertyUpdate parses the PROPPATCH request body, preserving the order of instructions.
+func parsePropertyUpdate(body []byte) (patches []patch, err error) {
+	d := xml.NewDecoder(bytes.NewReader(body))
+	if err = root(d, "propertyupdate"); err != nil {
+		return nil, err
+	}
+
+	for {
+		start, err := child(d)
+		switch {
+		case err == io.EOF:
+			if len(patches) == 0 {
+				return nil, errMalformedXML
+			}
+
+			return patches, nil
+		case err != nil:
+			return nil, err
+		}
+
+		remove := start.Name == davName("remove")
+		if !remove && start.Name != davName("set") {
+			if err = d.Skip(); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if patches, err = parseInstruction(d, remove, patches); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func parseInstruction(d *xml.Decoder, remove bool, patches []patch) ([]patch, error) {
+	for {
+		start, err := child(d)
+		switch {
+		case err == io.EOF:
+			return patches, nil
+		case err != nil:
+			return nil, err
+		case start.Name != davName("prop"):
+			if err = d.Skip(); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		for {
+			prop, err := child(d)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+
+			p := patch{remove: remove, prop: Property{XMLName: prop.Name}}
+			if remove {
+				err = d.Skip()
+			} else {
+				p.prop.InnerXML, err = innerXML(d)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			patches = append(patches, p)
+		}
+	}
+}
+
+type lockInfo struct {
+	shared bool
+	owner  []byte
+}
+
+// parseLockInfo parses the LOCK request body. Only write locks are supported.
+func parseLockInfo(body []byte) (info lockInfo, err error) {
+	d := xml.NewDecoder(bytes.NewReader(body))
+	if err = root(d, "lockinfo"); err != nil {
+		return info, err
+	}
+
+	var hasScope, hasType bool
+
+	for {
+		start, err := child(d)
+		switch {
+		case err == io.EOF:
+			if !hasScope || !hasType {
+				return info, errMalformedXML
+			}
+
+			return info, nil
+		case err != nil:
+			return info, err
+		}
+
+		switch start.Name {
+		case davName("lockscope"):
+			var names []xml.Name
+			names, err = childNames(d)
+			if len(names) != 1 {
+				return info, errMalformedXML
+			}
+
+			switch names[0] {
+			case davName("exclusive"):
+			case davName("shared"):
+				info.shared = true
+			default:
+				return info, errMalformedXML
+			}
+
+			hasScope = true
+		case davName("locktype"):
+			var names []xml.Name
+			names, err = childNames(d)
+			if len(names) != 1 || names[0] != davName("write") {
+				return info, errMalformedXML
+			}
+
+			hasType = true
+		case davName("owner"):
+			info.owner, err = innerXML(d)
+		default:
+			err = d.Skip()
+		}
+
+		if err != nil {
+			return info, err
+		}
+	}
+}
+
+// root consumes the prolog and the root element, which must be the one from the DAV: namespace.
+func root(d *xml.Decoder, local string) error {
+	start, err := child(d)
+	if err != nil {
+		return errMalformedXML
+	}
+
+	if start.Name != davName(local) {
+		return errMalformedXML
+	}
+
+	return nil
+}
+
+// child returns the next child element of the current one. io.EOF is returned as the current
+// element is closed.
+func child(d *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return xml.StartElement{}, errMalformedXML
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			return t, nil
+		case xml.EndElement:
+			return xml.StartElement{}, io.EOF
+		}
+	}
+}
+
+// childNames returns names of all the children of the current element, skipping their content.
+func childNames(d *xml.Decoder) (names []xml.Name, err error) {
+	names = []xml.Name{}
+
+	for {
+		start, err := child(d)
+		switch {
+		case err == io.EOF:
+			return names, nil
+		case err != nil:
+			return nil, err
+		}
+
+		names = append(names, start.Name)
+		if err = d.Skip(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// innerXML returns the content of the current element. It's re-encoded rather than copied
+// verbatim, so namespace prefixes declared by the outer elements don't get lost.
+func innerXML(d *xml.Decoder) ([]byte, error) {
+	var (
+		buff  bytes.Buffer
+		enc   = xml.NewEncoder(&buff)
+		depth int
+	)
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return nil, errMalformedXML
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			attrs := t.Attr[:0]
+			for _, attr := range t.Attr {
+				// namespace declarations are generated by the encoder itself
+				if attr.Name.Space != "xmlns" && attr.Name.Local != "xmlns" {
+					attrs = append(attrs, attr)
+				}
+			}
+
+			t.Attr = attrs
+			token = t
+		case xml.EndElement:
+			if depth == 0 {
+				if err = enc.Flush(); err != nil {
+					return nil, err
+				}
+
+				return buff.Bytes(), nil
+			}
+
+			depth--
+		case xml.ProcInst, xml.Directive:
+			continue
+		}
+
+		if err = enc.EncodeToken(token); err != nil {
+			return nil, errMalformedXML
+		}
+	}
+}
+
+// multistatus builds the multi-status response body.
+type multistatus struct {
+	buff bytes.Buffer
+}
+
+type propstat struct {
+	code  status.Code
+	props []Property
+}
+
+func newMultistatus() *multistatus {
+	m := new(multistatus)
+	m.buff.WriteString(xml.Header)
+	m.buff.WriteString(`<D:multistatus xmlns:D="DAV:">`)
+
+	return m
+}
+
+// Response adds a response element describing the resource. Propstats without properties
+// are omitted.
+func (m *multistatus) Response(href string, propstats ...propstat) {
+	m.buff.WriteString("<D:response><D:href>")
+	escape(&m.buff, href)
+	m.buff.WriteString("</D:href>")
+
+	for _, ps := range propstats {
+		if len(ps.props) == 0 {
+			continue
+		}
+
+		m.buff.WriteString("<D:propstat><D:prop>")
+		for _, prop := range ps.props {
+			writeElement(&m.buff, prop.XMLName, prop.InnerXML)
+		}
+
+		m.buff.WriteString("</D:prop>")
+		writeStatus(&m.buff, ps.code)
+		m.buff.WriteString("</D:propstat>")
+	}
+
+	m.buff.WriteString("</D:response>")
+}
+
+// Status adds a response element carrying only the status of the resource.
+func (m *multistatus) Status(href string, code status.Code) {
+	m.buff.WriteString("<D:response><D:href>")
+	escape(&m.buff, href)
+	m.buff.WriteString("</D:href>")
+	writeStatus(&m.buff, code)
+	m.buff.WriteString("</D:response>")
+}
+
+func (m *multistatus) Bytes() []byte {
+	m.buff.WriteString("</D:multistatus>")
+	return m.buff.Bytes()
+}
+
+func writeStatus(buff *bytes.Buffer, code status.Code) {
+	buff.WriteString("<D:status>HTTP/1.1 ")
+	buff.WriteString(strconv.Itoa(int(code)))
+	buff.WriteByte(' ')
+	buff.WriteString(status.String(code))
+	buff.WriteString("</D:status>")
+}
+
+// writeElement writes the element with the raw content. Elements from the DAV: namespace use
+// the D: prefix, declared by the root element, while others declare their namespace inline.
+func writeElement(buff *bytes.Buffer, name xml.Name, inner []byte) {
+	buff.WriteByte('<')
+	if name.Space == davNS {
+		buff.WriteString("D:")
+	}
+
+	buff.WriteString(name.Local)
+	if name.Space != davNS && len(name.Space) > 0 {
+		buff.WriteString(` xmlns="`)
+		escape(buff, name.Space)
+		buff.WriteByte('"')
+	}
+
+	if len(inner) == 0 {
+		buff.WriteString("/>")
+		return
+	}
+
+	buff.WriteByte('>')
+	buff.Write(inner)
+	buff.WriteString("</")
+	if name.Space == davNS {
+		buff.WriteString("D:")
+	}
+
+	buff.WriteString(name.Local)
+	buff.WriteByte('>')
+}
+
+func escape(buff *bytes.Buffer, text string) {
+	_ = xml.EscapeText(buff, []byte(text))
+}
@@ -0,0 +1,157 @@
+// Package nethttp bridges indigo and the standard net/http package in both directions: handlers
+// and middlewares written for net/http can be registered in the inbuilt router, and any indigo
+// router can be mounted into a net/http server (or tested via net/http/httptest).
+package nethttp
+
+import (
+	"context"
+	stdhttp "net/http"
+	"net/url"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/proto"
+	"github.com/indigo-web/indigo/router/inbuilt"
+)
+
+// Handler wraps the net/http handler into the inbuilt one. The handler runs in a separate
+// goroutine, so its output can be streamed: the response is sent as soon as the handler
+// either flushes or writes more than fits into the buffer. Otherwise, it's sent as the
+// handler returns, sized. Hijacking the connection is supported as well.
+//
+// Panics occurred before the response is sent are re-raised in the calling goroutine, so
+// they can be caught by the Recover middleware. Later ones just abort the response.
+func Handler(h stdhttp.Handler) inbuilt.Handler {
+	return func(request *http.Request) *http.Response {
+		return serve(h, request, request.Ctx)
+	}
+}
+
+// HandlerFunc is like Handler, but for plain functions.
+func HandlerFunc(fn func(stdhttp.ResponseWriter, *stdhttp.Request)) inbuilt.Handler {
+	return Handler(stdhttp.HandlerFunc(fn))
+}
+
+type nextKey struct{}
+
+// nextCall is the rest of the inbuilt middlewares chain, passed through the context.
+type nextCall struct {
+	next    inbuilt.Handler
+	request *http.Request
+	path    string
+}
+
+// Middleware wraps the net/http middleware into the inbuilt one. The middleware is constructed
+// once. Changes it makes to the request path, headers and context are visible to the rest of the
+// chain, however the context is restored as the chain returns, as it's per-connection in indigo.
+func Middleware(mw func(stdhttp.Handler) stdhttp.Handler) inbuilt.Middleware {
+	h := mw(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		call := r.Context().Value(nextKey{}).(*nextCall)
+		request := call.request
+
+		if r.URL.Path != call.path {
+			request.Path = r.URL.Path
+		}
+
+		request.Headers.Clear()
+		request.Headers.Add("Host", r.Host)
+		for key, values := range r.Header {
+			for _, value := range values {
+				request.Headers.Add(key, value)
+			}
+		}
+
+		ctx := request.Ctx
+		request.Ctx = r.Context()
+		response := call.next(request)
+		request.Ctx = ctx
+
+		write(w, request, response)
+	}))
+
+	return func(next inbuilt.Handler, request *http.Request) *http.Response {
+		call := &nextCall{next: next, request: request}
+		return serve(h, request, context.WithValue(request.Ctx, nextKey{}, call))
+	}
+}
+
+func serve(h stdhttp.Handler, request *http.Request, ctx context.Context) *http.Response {
+	r := newStdRequest(request, ctx)
+	if call, ok := ctx.Value(nextKey{}).(*nextCall); ok && call.request == request {
+		// remember the original path in order to detect whether the middleware altered it
+		call.path = r.URL.Path
+	}
+
+	w := newResponseWriter(request)
+	go w.serve(h, r)
+
+	return w.wait()
+}
+
+// newStdRequest converts the request into the net/http one. The URL is taken from the raw
+// request-target, so it's exactly what the client sent, unaffected by the routing.
+func newStdRequest(request *http.Request, ctx context.Context) *stdhttp.Request {
+	target := request.RequestURI()
+	u, err := url.ParseRequestURI(target)
+	if err != nil {
+		u = &url.URL{Path: request.Path, RawQuery: request.Query}
+	}
+
+	header := make(stdhttp.Header, request.Headers.Len())
+	for key, value := range request.Headers.Pairs() {
+		header.Add(key, value)
+	}
+	// net/http keeps the host in a dedicated field
+	delete(header, "Host")
+
+	r := &stdhttp.Request{
+		Method:     request.Method.String(),
+		URL:        u,
+		Proto:      request.Protocol.String(),
+		Header:     header,
+		Host:       request.Host(),
+		RequestURI: target,
+		TLS:        request.TLS,
+		Body:       stdhttp.NoBody,
+	}
+
+	r.ProtoMajor, r.ProtoMinor = version(request.Protocol)
+
+	if request.Remote != nil {
+		r.RemoteAddr = request.Remote.String()
+	}
+
+	if request.ContentLength > 0 || request.Chunked {
+		r.Body = body{request.Body}
+		r.ContentLength = int64(request.ContentLength)
+	}
+
+	if request.Chunked {
+		r.ContentLength = -1
+		r.TransferEncoding = []string{"chunked"}
+	}
+
+	return r.WithContext(ctx)
+}
+
+func version(protocol proto.Protocol) (major, minor int) {
+	switch protocol {
+	case proto.HTTP10:
+		return 1, 0
+	case proto.HTTP2:
+		return 2, 0
+	case proto.HTTP3:
+		return 3, 0
+	default:
+		return 1, 1
+	}
+}
+
+// body exposes the request body as an io.ReadCloser. Closing is no-op, as the server discards
+// the rest of the body by itself.
+type body struct {
+	*http.Body
+}
+
+func (body) Close() error {
+	return nil
+}
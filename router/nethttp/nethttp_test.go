@@ -0,0 +1,321 @@
+package nethttp
+
+import (
+	"bufio"
+	"io"
+	"math"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/cookie"
+	"github.com/indigo-web/indigo/http/method"
+	"github.com/indigo-web/indigo/http/mime"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/internal/construct"
+	"github.com/indigo-web/indigo/router/inbuilt"
+	"github.com/indigo-web/indigo/transport/dummy"
+	"github.com/stretchr/testify/require"
+)
+
+func getRequest(m method.Method, target, body string) *http.Request {
+	request := construct.Request(config.Default(), dummy.NewMockClient())
+	request.Method = m
+	request.Target = target
+	request.Path, request.Query, _ = strings.Cut(target, "?")
+	request.ContentLength = len(body)
+	request.Body = http.NewBody(dummy.NewMockClient([]byte(body)))
+	request.Body.Reset(request)
+	request.Headers.Add("Host", "example.com")
+
+	return request
+}
+
+func readBody(t *testing.T, response *http.Response) string {
+	stream := response.Expose().Stream
+	if stream == nil {
+		return ""
+	}
+
+	data, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	if c, ok := stream.(io.Closer); ok {
+		require.NoError(t, c.Close())
+	}
+
+	return string(data)
+}
+
+func header(response *http.Response, key string) (values []string) {
+	for _, h := range response.Expose().Headers {
+		if h.Key == key {
+			values = append(values, h.Value)
+		}
+	}
+
+	return values
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("request", func(t *testing.T) {
+		handler := HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			require.Equal(t, "POST", r.Method)
+			require.Equal(t, "/hello", r.URL.Path)
+			require.Equal(t, "name=world", r.URL.RawQuery)
+			require.Equal(t, "/hello?name=world", r.RequestURI)
+			require.Equal(t, "example.com", r.Host)
+			require.Equal(t, "HTTP/1.1", r.Proto)
+			require.Equal(t, "bar", r.Header.Get("X-Foo"))
+			require.Empty(t, r.Header.Get("Host"))
+			require.Equal(t, int64(len("Hello!")), r.ContentLength)
+
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.Equal(t, "Hello!", string(body))
+
+			w.Header().Set("X-Custom", "1")
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(stdhttp.StatusCreated)
+			_, _ = io.WriteString(w, "done")
+		})
+
+		request := getRequest(method.POST, "/hello?name=world", "Hello!")
+		request.Headers.Add("x-foo", "bar")
+		response := handler(request)
+		require.Equal(t, status.Created, response.Expose().Code)
+		require.Equal(t, []string{"1"}, header(response, "X-Custom"))
+		require.Equal(t, []string{"text/plain"}, header(response, "Content-Type"))
+		require.Equal(t, int64(4), response.Expose().StreamSize)
+		require.Equal(t, "done", readBody(t, response))
+	})
+
+	t.Run("content type sniffing", func(t *testing.T) {
+		handler := HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			_, _ = io.WriteString(w, "<!DOCTYPE html><html></html>")
+		})
+
+		response := handler(getRequest(method.GET, "/", ""))
+		require.Equal(t, status.OK, response.Expose().Code)
+		require.Equal(t, []string{"text/html; charset=utf-8"}, header(response, "Content-Type"))
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		handler := HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "data: first\n\n")
+			require.NoError(t, stdhttp.NewResponseController(w).Flush())
+			_, _ = io.WriteString(w, "data: second\n\n")
+		})
+
+		response := handler(getRequest(method.GET, "/events", ""))
+		fields := response.Expose()
+		require.Equal(t, int64(-1), fields.StreamSize)
+		require.False(t, fields.Buffered)
+		require.Equal(t, "data: first\n\ndata: second\n\n", readBody(t, response))
+	})
+
+	t.Run("large body", func(t *testing.T) {
+		payload := strings.Repeat("a", 3*bufferSize)
+		handler := HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+			_, _ = io.WriteString(w, payload)
+		})
+
+		response := handler(getRequest(method.GET, "/", ""))
+		require.Equal(t, int64(len(payload)), response.Expose().StreamSize)
+		require.Empty(t, header(response, "Content-Length"))
+		require.Equal(t, payload, readBody(t, response))
+	})
+
+	t.Run("abandoned stream", func(t *testing.T) {
+		returned := make(chan struct{})
+		handler := HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			defer close(returned)
+			for {
+				if _, err := io.WriteString(w, strings.Repeat("a", 1024)); err != nil {
+					return
+				}
+
+				stdhttp.NewResponseController(w).Flush()
+			}
+		})
+
+		response := handler(getRequest(method.GET, "/", ""))
+		stream := response.Expose().Stream.(io.ReadCloser)
+		_, err := stream.Read(make([]byte, 10))
+		require.NoError(t, err)
+		require.NoError(t, stream.Close())
+		// closing the stream must wait for the handler to return
+		select {
+		case <-returned:
+		default:
+			t.Fatal("handler is still running")
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		handler := HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			panic("oops")
+		})
+
+		require.PanicsWithValue(t, "oops", func() {
+			handler(getRequest(method.GET, "/", ""))
+		})
+	})
+
+	t.Run("hijack", func(t *testing.T) {
+		handler := HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			conn, rw, err := stdhttp.NewResponseController(w).Hijack()
+			require.NoError(t, err)
+			require.NotNil(t, conn)
+			_, err = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\n")
+			require.NoError(t, err)
+			require.NoError(t, rw.Flush())
+
+			_, err = w.Write([]byte("too late"))
+			require.ErrorIs(t, err, stdhttp.ErrHijacked)
+		})
+
+		request := getRequest(method.GET, "/ws", "")
+		handler(request)
+		require.True(t, request.Hijacked())
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	mw := Middleware(func(next stdhttp.Handler) stdhttp.Handler {
+		return stdhttp.StripPrefix("/api", stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			r.Header.Set("X-Request-Id", "42")
+			w.Header().Set("X-Middleware", "yes")
+			next.ServeHTTP(w, r)
+		}))
+	})
+
+	handler := func(request *http.Request) *http.Response {
+		return request.Respond().
+			Header("X-Path", request.Path).
+			String(request.Headers.Value("x-request-id"))
+	}
+
+	request := getRequest(method.GET, "/api/users", "")
+	response := mw(handler, request)
+	require.Equal(t, status.OK, response.Expose().Code)
+	require.Equal(t, []string{"yes"}, header(response, "X-Middleware"))
+	require.Equal(t, []string{"/users"}, header(response, "X-Path"))
+	require.Equal(t, "42", readBody(t, response))
+
+	t.Run("short circuit", func(t *testing.T) {
+		deny := Middleware(func(next stdhttp.Handler) stdhttp.Handler {
+			return stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+				stdhttp.Error(w, "go away", stdhttp.StatusForbidden)
+			})
+		})
+
+		response := deny(handler, getRequest(method.GET, "/", ""))
+		require.Equal(t, status.Forbidden, response.Expose().Code)
+		require.Equal(t, "go away\n", readBody(t, response))
+	})
+}
+
+func TestRouter(t *testing.T) {
+	r := inbuilt.New()
+	r.Get("/hello", func(request *http.Request) *http.Response {
+		return request.Respond().
+			ContentType(mime.Plain, mime.UTF8).
+			Cookie(cookie.New("session", "abc")).
+			String("Hello, " + request.Params.Value("name"))
+	})
+	r.Post("/echo", func(request *http.Request) *http.Response {
+		body, err := request.Body.String()
+		if err != nil {
+			return http.Error(request, err)
+		}
+
+		return request.Respond().
+			Code(status.Accepted).
+			Header("X-Remote", request.Remote.String()).
+			String(body)
+	})
+	r.Get("/stream", func(request *http.Request) *http.Response {
+		return request.Respond().Stream(strings.NewReader("streamed"), -1).Buffered(false)
+	})
+	r.Get("/hijack", func(request *http.Request) *http.Response {
+		client, err := request.Hijack()
+		if err != nil {
+			return http.Error(request, err)
+		}
+
+		_, _ = client.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked"))
+		return request.Respond()
+	})
+
+	handler := Router(r.Build())
+
+	t.Run("recorder", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/hello?name=world", nil))
+		require.Equal(t, stdhttp.StatusOK, recorder.Code)
+		require.Equal(t, "Hello, world", recorder.Body.String())
+		require.Equal(t, "text/plain; charset=utf8", recorder.Header().Get("Content-Type"))
+		require.Equal(t, "session=abc", recorder.Header().Get("Set-Cookie"))
+
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/nothing", nil))
+		require.Equal(t, stdhttp.StatusNotFound, recorder.Code)
+
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("DELETE", "/hello", nil))
+		require.Equal(t, stdhttp.StatusMethodNotAllowed, recorder.Code)
+		require.Equal(t, "GET, HEAD", recorder.Header().Get("Allow"))
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	t.Run("body", func(t *testing.T) {
+		resp, err := stdhttp.Post(server.URL+"/echo", "text/plain", strings.NewReader("ping"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, stdhttp.StatusAccepted, resp.StatusCode)
+		require.Equal(t, "ping", string(body))
+		require.True(t, strings.HasPrefix(resp.Header.Get("X-Remote"), "127.0.0.1:"))
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		resp, err := stdhttp.Get(server.URL + "/stream")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "streamed", string(body))
+		require.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+	})
+
+	t.Run("hijack", func(t *testing.T) {
+		resp, err := stdhttp.Get(server.URL + "/hijack")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(bufio.NewReader(resp.Body))
+		require.NoError(t, err)
+		require.Equal(t, "hijacked", string(body))
+	})
+
+	t.Run("unlimited body", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Body.MaxSize = math.MaxUint64
+
+		recorder := httptest.NewRecorder()
+		Router(r.Build(), cfg).ServeHTTP(recorder, httptest.NewRequest("POST", "/echo", strings.NewReader("ping")))
+		require.Equal(t, stdhttp.StatusAccepted, recorder.Code)
+		require.Equal(t, "ping", recorder.Body.String())
+	})
+}
@@ -0,0 +1,314 @@
+package nethttp
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math"
+	"net"
+	stdhttp "net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/cookie"
+	"github.com/indigo-web/indigo/http/method"
+	"github.com/indigo-web/indigo/http/mime"
+	"github.com/indigo-web/indigo/http/proto"
+	"github.com/indigo-web/indigo/http/status"
+	"github.com/indigo-web/indigo/internal/construct"
+	"github.com/indigo-web/indigo/router"
+	"github.com/indigo-web/indigo/transport"
+)
+
+// Router exposes the router as a net/http handler, so it can be mounted into an existing
+// net/http server or tested via net/http/httptest. The config defaults to config.Default(),
+// however only the body-related settings matter.
+//
+// Note that net/http takes care of the protocol itself, therefore neither request bodies are
+// decoded nor responses are compressed, and the connection can be hijacked only if the
+// underlying ResponseWriter supports it (HTTP/2 doesn't).
+func Router(r router.Router, cfg ...*config.Config) stdhttp.Handler {
+	c := config.Default()
+	if len(cfg) > 0 {
+		c = cfg[0]
+	}
+
+	return stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, req *stdhttp.Request) {
+		client := newClient(w, req)
+		request := construct.Request(c, client)
+		body := req.Body
+		if c.Body.MaxSize <= math.MaxInt64 {
+			// bigger values (normally math.MaxUint64) disable the limit
+			body = stdhttp.MaxBytesReader(w, body, int64(c.Body.MaxSize))
+		}
+
+		request.Body = http.NewBody(&fetcher{
+			body: body,
+			buff: make([]byte, c.NET.ReadBufferSize),
+		})
+		request.Body.Reset(request)
+		fill(request, req)
+
+		var response *http.Response
+		if request.Method == method.Unknown {
+			response = r.OnError(request, status.ErrMethodNotImplemented)
+		} else {
+			response = r.OnRequest(request)
+		}
+
+		if request.Hijacked() {
+			// the connection is closed as the handler returns, the same way the server does
+			_ = client.Close()
+			return
+		}
+
+		if response == nil {
+			response = http.Respond(request)
+		}
+
+		write(w, request, response)
+	})
+}
+
+// fill populates the request from the net/http one.
+func fill(request *http.Request, r *stdhttp.Request) {
+	request.Method = method.Parse(r.Method)
+	request.Path = r.URL.Path
+	if len(request.Path) == 0 {
+		request.Path = "/"
+	}
+
+	request.Target = r.RequestURI
+	request.Query = r.URL.RawQuery
+	request.Authority = r.URL.Host
+	request.Protocol = proto.Parse(uint8(r.ProtoMajor), uint8(r.ProtoMinor))
+	if request.Protocol == proto.Unknown {
+		request.Protocol = proto.HTTP11
+	}
+
+	for key, values := range r.URL.Query() {
+		for _, value := range values {
+			request.Params.Add(key, value)
+		}
+	}
+
+	request.Headers.Add("Host", r.Host)
+	for key, values := range r.Header {
+		for _, value := range values {
+			request.Headers.Add(key, value)
+		}
+	}
+
+	request.ContentLength = int(max(r.ContentLength, 0))
+	request.Chunked = r.ContentLength == -1
+	request.TransferEncoding = r.TransferEncoding
+	request.ContentType = r.Header.Get("Content-Type")
+	request.Connection = r.Header.Get("Connection")
+	request.AcceptEncoding = tokens(r.Header.Values("Accept-Encoding"))
+
+	request.TLS = r.TLS
+	if r.TLS != nil {
+		request.Env.Encryption = r.TLS.Version
+	}
+
+	request.Ctx = r.Context()
+}
+
+// tokens splits the comma-separated values into tokens, dropping their parameters and
+// the identity one.
+func tokens(values []string) (toks []string) {
+	for _, value := range values {
+		for _, token := range strings.Split(value, ",") {
+			token, _, _ = strings.Cut(token, ";")
+			token = strings.TrimSpace(token)
+			if len(token) > 0 && !strings.EqualFold(token, "identity") {
+				toks = append(toks, token)
+			}
+		}
+	}
+
+	return toks
+}
+
+// write sends the response via the ResponseWriter.
+func write(w stdhttp.ResponseWriter, request *http.Request, response *http.Response) {
+	// the fields are copied, as the response is reused by the request. Writing the body might
+	// therefore cause it to be cleared, if the writer is the one from Handler.
+	fields := *response.Expose()
+	header := w.Header()
+
+	for _, h := range fields.Headers {
+		value := h.Value
+		if fields.Charset != mime.Unset && strings.EqualFold(h.Key, "Content-Type") {
+			value += "; charset=" + string(fields.Charset)
+		}
+
+		header.Add(h.Key, value)
+	}
+
+	for _, c := range fields.Cookies {
+		header.Add("Set-Cookie", string(cookie.Append(nil, c)))
+	}
+
+	if fields.Stream == nil || fields.StreamSize == 0 {
+		// net/http sets the zero Content-Length by itself, if the status code allows a body
+		w.WriteHeader(int(fields.Code))
+		closeStream(fields.Stream)
+		return
+	}
+
+	if fields.StreamSize > 0 {
+		header.Set("Content-Length", strconv.FormatInt(fields.StreamSize, 10))
+	}
+
+	w.WriteHeader(int(fields.Code))
+	defer closeStream(fields.Stream)
+
+	if request.Method == method.HEAD {
+		return
+	}
+
+	dst := io.Writer(w)
+	if !fields.Buffered {
+		dst = flushWriter{w, stdhttp.NewResponseController(w)}
+	}
+
+	_, _ = io.Copy(dst, fields.Stream)
+}
+
+func closeStream(stream io.Reader) {
+	if c, ok := stream.(io.Closer); ok {
+		_ = c.Close()
+	}
+}
+
+// flushWriter flushes every write immediately.
+type flushWriter struct {
+	w          io.Writer
+	controller *stdhttp.ResponseController
+}
+
+func (f flushWriter) Write(b []byte) (int, error) {
+	n, err := f.w.Write(b)
+	if err != nil {
+		return n, err
+	}
+
+	if err = f.controller.Flush(); errors.Is(err, stdhttp.ErrNotSupported) {
+		err = nil
+	}
+
+	return n, err
+}
+
+// fetcher feeds the body of the net/http request.
+type fetcher struct {
+	body io.Reader
+	buff []byte
+}
+
+func (f *fetcher) Fetch() ([]byte, error) {
+	n, err := f.body.Read(f.buff)
+	var tooLarge *stdhttp.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		err = status.ErrBodyTooLarge
+	}
+
+	return f.buff[:n], err
+}
+
+var _ transport.Client = new(client)
+
+// client is the transport of requests served via net/http. The connection itself is
+// accessible only after hijacking, which happens as soon as the router asks for it.
+type client struct {
+	w       stdhttp.ResponseWriter
+	remote  net.Addr
+	conn    net.Conn
+	reader  *bufio.Reader
+	pending []byte
+	buff    []byte
+}
+
+func newClient(w stdhttp.ResponseWriter, r *stdhttp.Request) *client {
+	remote := &net.TCPAddr{}
+	if addr, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		remote = net.TCPAddrFromAddrPort(addr)
+	}
+
+	return &client{
+		w:      w,
+		remote: remote,
+	}
+}
+
+func (c *client) Read() ([]byte, error) {
+	if len(c.pending) > 0 {
+		pending := c.pending
+		c.pending = nil
+
+		return pending, nil
+	}
+
+	if err := c.hijack(); err != nil {
+		return nil, err
+	}
+
+	n, err := c.reader.Read(c.buff)
+	return c.buff[:n], err
+}
+
+func (c *client) Pushback(takeback []byte) {
+	c.pending = takeback
+}
+
+func (c *client) Pending() []byte {
+	return c.pending
+}
+
+func (c *client) Write(b []byte) (int, error) {
+	if err := c.hijack(); err != nil {
+		return 0, err
+	}
+
+	return c.conn.Write(b)
+}
+
+// Conn returns the hijacked connection, or nil if the ResponseWriter doesn't support hijacking.
+func (c *client) Conn() net.Conn {
+	_ = c.hijack()
+	return c.conn
+}
+
+func (c *client) Remote() net.Addr {
+	return c.remote
+}
+
+func (c *client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+
+	return c.conn.Close()
+}
+
+func (c *client) Release() {}
+
+func (c *client) hijack() error {
+	if c.conn != nil {
+		return nil
+	}
+
+	conn, rw, err := stdhttp.NewResponseController(c.w).Hijack()
+	if err != nil {
+		return err
+	}
+
+	c.conn, c.reader = conn, rw.Reader
+	c.buff = make([]byte, 4096)
+
+	return nil
+}
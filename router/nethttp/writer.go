@@ -0,0 +1,255 @@
+package nethttp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	stdhttp "net/http"
+	"strconv"
+	"time"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/status"
+)
+
+// bufferSize is the amount of data buffered before the response is sent. Handlers writing
+// no more than that and never flushing get sized responses.
+const bufferSize = 32 * 1024
+
+var (
+	errAborted   = errors.New("handler aborted the response")
+	errCommitted = errors.New("cannot hijack the connection after the response is sent")
+)
+
+type outcome struct {
+	response *http.Response
+	panic    any
+}
+
+// responseWriter implements the stdhttp.ResponseWriter for handlers running in a separate
+// goroutine. Until the response is committed, the body is buffered, afterward it's streamed
+// through the pipe.
+type responseWriter struct {
+	request     *http.Request
+	header      stdhttp.Header
+	code        int
+	wroteHeader bool
+	hijacked    bool
+	buff        []byte
+	// pipe and stream are set as the response is committed
+	pipe   *io.PipeWriter
+	stream *bufio.Writer
+	// ready delivers the response to the calling goroutine
+	ready chan outcome
+	// done is closed as the handler returns
+	done chan struct{}
+}
+
+func newResponseWriter(request *http.Request) *responseWriter {
+	return &responseWriter{
+		request: request,
+		header:  make(stdhttp.Header),
+		code:    stdhttp.StatusOK,
+		ready:   make(chan outcome, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+func (w *responseWriter) serve(h stdhttp.Handler, r *stdhttp.Request) {
+	defer close(w.done)
+	defer func() {
+		v := recover()
+		switch {
+		case v == nil:
+			w.finish()
+		case w.stream != nil:
+			// the response is already on its way, so the only thing left is to abort it
+			_ = w.pipe.CloseWithError(errAborted)
+		default:
+			w.ready <- outcome{panic: v}
+		}
+	}()
+
+	h.ServeHTTP(w, r)
+}
+
+func (w *responseWriter) wait() *http.Response {
+	o := <-w.ready
+	if o.panic != nil {
+		panic(o.panic)
+	}
+
+	return o.response
+}
+
+func (w *responseWriter) finish() {
+	switch {
+	case w.hijacked:
+		// the server doesn't intrude into hijacked connections, so the response is ignored
+		w.ready <- outcome{response: w.request.Respond()}
+	case w.stream != nil:
+		err := w.stream.Flush()
+		_ = w.pipe.CloseWithError(err)
+	default:
+		if !w.wroteHeader {
+			w.WriteHeader(stdhttp.StatusOK)
+		}
+
+		w.ready <- outcome{response: w.response().Bytes(w.buff)}
+	}
+}
+
+func (w *responseWriter) Header() stdhttp.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader || w.hijacked {
+		return
+	}
+
+	if code < 200 {
+		// informational responses aren't supported
+		return
+	}
+
+	w.code = code
+	w.wroteHeader = true
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.hijacked {
+		return 0, stdhttp.ErrHijacked
+	}
+
+	if !w.wroteHeader {
+		w.WriteHeader(stdhttp.StatusOK)
+	}
+
+	if w.stream != nil {
+		return w.stream.Write(b)
+	}
+
+	w.buff = append(w.buff, b...)
+	if len(w.buff) > bufferSize {
+		if err := w.commit(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+func (w *responseWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// FlushError is like Flush, but reports the error. It's used by the stdhttp.ResponseController.
+func (w *responseWriter) FlushError() error {
+	if w.hijacked {
+		return stdhttp.ErrHijacked
+	}
+
+	if !w.wroteHeader {
+		w.WriteHeader(stdhttp.StatusOK)
+	}
+
+	if w.stream == nil {
+		if err := w.commit(); err != nil {
+			return err
+		}
+	}
+
+	return w.stream.Flush()
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.hijacked {
+		return nil, nil, stdhttp.ErrHijacked
+	}
+
+	if w.stream != nil {
+		return nil, nil, errCommitted
+	}
+
+	client, err := w.request.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w.hijacked = true
+	conn := client.Conn()
+	if err = conn.SetDeadline(time.Time{}); err != nil {
+		return nil, nil, err
+	}
+
+	// pipelined data might already be read from the connection
+	pending := bytes.NewReader(bytes.Clone(client.Pending()))
+	rw := bufio.NewReadWriter(
+		bufio.NewReader(io.MultiReader(pending, conn)),
+		bufio.NewWriter(conn),
+	)
+
+	return conn, rw, nil
+}
+
+// commit sends the response with the headers written so far and switches to streaming.
+func (w *responseWriter) commit() error {
+	reader, writer := io.Pipe()
+	w.pipe = writer
+	w.stream = bufio.NewWriterSize(writer, bufferSize)
+
+	size := int64(-1)
+	if value := w.header.Get("Content-Length"); len(value) > 0 {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil && n >= 0 {
+			size = n
+		}
+	}
+
+	response := w.response().
+		Stream(pipeReader{reader, w.done}, size).
+		Buffered(false)
+	w.ready <- outcome{response: response}
+
+	buff := w.buff
+	w.buff = nil
+	_, err := w.stream.Write(buff)
+
+	return err
+}
+
+// response builds the response with the status code and headers written by the handler.
+func (w *responseWriter) response() *http.Response {
+	response := w.request.Respond().Code(status.Code(w.code))
+	if _, found := w.header["Content-Type"]; !found && len(w.buff) > 0 {
+		response.Header("Content-Type", stdhttp.DetectContentType(w.buff))
+	}
+
+	for key, values := range w.header {
+		switch key {
+		case "Content-Length", "Transfer-Encoding":
+			// they're set by the server itself
+		default:
+			response.Header(key, values...)
+		}
+	}
+
+	return response
+}
+
+// pipeReader waits for the handler to return as it's closed. Otherwise, if the response is
+// abandoned (e.g. because the client disconnected), the handler would be left running
+// concurrently with the next request.
+type pipeReader struct {
+	*io.PipeReader
+	done <-chan struct{}
+}
+
+func (p pipeReader) Close() error {
+	err := p.PipeReader.Close()
+	<-p.done
+
+	return err
+}
@@ -2,32 +2,60 @@ package domain
 
 import "strings"
 
+// Normalize lowercases the domain and strips the www. prefix, the trailing dot of a fully
+// qualified name and the default port.
 func Normalize(domain string) string {
-	if strings.HasPrefix(domain, "www.") {
-		domain = domain[len("www."):]
+	host, port := Split(domain)
+	if len(port) == 0 {
+		return host
+	}
+
+	return host + ":" + port
+}
+
+// Split normalizes the domain the same way Normalize does, but returns the host and the port
+// separately, avoiding the concatenation. The port is empty if it was either omitted or the
+// default one.
+func Split(domain string) (host, port string) {
+	host, port = SplitPort(domain)
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	host = strings.TrimPrefix(host, "www.")
+
+	switch port {
+	case "80", "443":
+		// trim only default ports. Non-default must always be presented
+		port = ""
+	}
+
+	return host, port
+}
+
+// SplitPort separates the port from the host. Unlike net.SplitHostPort, a missing port isn't
+// an error, and colons preceding the last dot aren't considered, so host patterns with
+// variables (e.g. :tenant.example.com) are handled correctly.
+func SplitPort(domain string) (host, port string) {
+	if strings.HasSuffix(domain, "]") {
+		// IPv6 address without port
+		return domain, ""
 	}
 
 	for i := len(domain) - 1; i >= 0; i-- {
-		if domain[i] == '.' {
-			break
-		} else if domain[i] == ':' {
-			switch port := domain[i+1:]; port {
-			case "80", "443":
-				// trim only default ports. Non-default must always be presented
-				domain = domain[:i]
+		switch domain[i] {
+		case '.', ']':
+			return domain, ""
+		case ':':
+			if i == 0 {
+				return domain, ""
 			}
 
-			break
+			return domain[:i], domain[i+1:]
 		}
 	}
 
-	return domain
+	return domain, ""
 }
 
 func TrimPort(domain string) string {
-	if colon := strings.IndexByte(domain, ':'); colon != -1 {
-		return domain[:colon]
-	}
-
-	return domain
+	host, _ := SplitPort(domain)
+	return host
 }
@@ -26,7 +26,31 @@ func TestNormalize(t *testing.T) {
 
 	t.Run("ip address", func(t *testing.T) {
 		require.Equal(t, "1.1.1.1", Normalize("1.1.1.1:80"))
+		require.Equal(t, "[::1]:8080", Normalize("[::1]:8080"))
+		require.Equal(t, "[::1]", Normalize("[::1]"))
 	})
+
+	t.Run("case and trailing dot", func(t *testing.T) {
+		require.Equal(t, "foo.example.com", Normalize("Foo.Example.COM."))
+		require.Equal(t, "foo.example.com", Normalize("WWW.foo.example.com.:443"))
+	})
+}
+
+func TestSplitPort(t *testing.T) {
+	for _, tc := range []struct {
+		domain, host, port string
+	}{
+		{"localhost:8080", "localhost", "8080"},
+		{"localhost", "localhost", ""},
+		{":tenant.example.com", ":tenant.example.com", ""},
+		{":tenant.example.com:*", ":tenant.example.com", "*"},
+		{"[::1]:443", "[::1]", "443"},
+		{"[::1]", "[::1]", ""},
+	} {
+		host, port := SplitPort(tc.domain)
+		require.Equal(t, tc.host, host, tc.domain)
+		require.Equal(t, tc.port, port, tc.domain)
+	}
 }
 
 func TestTrimPort(t *testing.T) {
@@ -0,0 +1,113 @@
+package virtual
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/indigo-web/indigo/kv"
+	"github.com/indigo-web/indigo/router/virtual/internal/domain"
+)
+
+// anyPort is the port of a pattern, matching requests regardless of their port.
+const anyPort = "*"
+
+// pattern is a parsed host pattern. Labels are stored leftmost first, each being either
+// a literal, a :name variable capturing exactly one label, or * capturing one or more labels.
+// The latter is allowed only as the leftmost label.
+type pattern struct {
+	labels []string
+	port   string
+}
+
+func parsePattern(host string) (pattern, error) {
+	name, port := domain.Split(host)
+	p := pattern{
+		labels: strings.Split(name, "."),
+		port:   port,
+	}
+
+	for i, label := range p.labels {
+		switch {
+		case len(label) == 0:
+			return p, fmt.Errorf("empty label in host pattern %q", host)
+		case label == "*" && i > 0:
+			return p, fmt.Errorf("* must be the leftmost label in host pattern %q", host)
+		case label == ":":
+			return p, fmt.Errorf("unnamed variable in host pattern %q", host)
+		}
+	}
+
+	return p, nil
+}
+
+// Static reports whether the pattern matches exactly one host.
+func (p pattern) Static() bool {
+	if p.port == anyPort {
+		return false
+	}
+
+	for _, label := range p.labels {
+		if label == "*" || label[0] == ':' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Match reports whether the host and port, as returned by domain.Split, match the pattern.
+// Captured labels are stored into vars only on success, so nil vars can be passed to just
+// check the host.
+func (p pattern) Match(name, port string, vars *kv.Storage) bool {
+	if p.port != anyPort && p.port != port {
+		return false
+	}
+
+	if !p.match(name, nil) {
+		return false
+	}
+
+	if vars != nil {
+		p.match(name, vars)
+	}
+
+	return true
+}
+
+func (p pattern) match(name string, vars *kv.Storage) bool {
+	for i := len(p.labels) - 1; i >= 0; i-- {
+		label := p.labels[i]
+		if label == "*" {
+			if len(name) == 0 {
+				return false
+			}
+
+			capture(vars, label, name)
+			return true
+		}
+
+		dot := strings.LastIndexByte(name, '.')
+		segment := name[dot+1:]
+		if len(segment) == 0 {
+			return false
+		}
+
+		if label[0] == ':' {
+			capture(vars, label[1:], segment)
+		} else if label != segment {
+			return false
+		}
+
+		name = name[:max(dot, 0)]
+	}
+
+	return len(name) == 0
+}
+
+func capture(vars *kv.Storage, key, value string) {
+	if vars != nil {
+		// Set instead of Add, as the router might be consulted multiple times per request,
+		// e.g. by both OnRequest and OnError
+		vars.Set(key, value)
+	}
+}
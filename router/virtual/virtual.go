@@ -1,16 +1,19 @@
 package virtual
 
 import (
+	"fmt"
+
 	"github.com/indigo-web/indigo/http"
 	"github.com/indigo-web/indigo/http/status"
 	"github.com/indigo-web/indigo/internal/strutil"
 	"github.com/indigo-web/indigo/router"
 	"github.com/indigo-web/indigo/router/virtual/internal/domain"
+	"github.com/indigo-web/indigo/transport/certs"
 )
 
 type virtualFabric struct {
-	Domain string
-	Router router.Builder
+	Pattern pattern
+	Router  router.Builder
 }
 
 var _ router.Builder = new(Router)
@@ -18,43 +21,92 @@ var _ router.Builder = new(Router)
 type Router struct {
 	routers       []virtualFabric
 	defaultRouter router.Builder
+	certs         *certs.Manager
 }
 
 // New returns a new instance of the virtual Router
 func New() *Router {
-	return &Router{}
+	return &Router{
+		certs: certs.New(),
+	}
 }
 
 // Host adds a new virtual router. If 0.0.0.0 is passed,
-// the router will be set as a default one
+// the router will be set as a default one.
+//
+// The host might also be a pattern. A label in form of :name matches exactly one label of the
+// requested host, storing it into request.Vars by the name. The leftmost label might be *,
+// matching one or more labels, which are stored by the * key. For example, :tenant.example.com
+// matches acme.example.com, whereas *.example.com also matches eu.acme.example.com, but not
+// example.com itself.
+//
+// Hosts without port match requests to the default ports (80 and 443) only. Specify the port
+// explicitly in order to match it, or use * to match any port, e.g. localhost:*. Exact hosts
+// take precedence over patterns, which are tried in the order of registration. Panics if the
+// pattern is malformed.
 func (r *Router) Host(host string, other router.Builder) *Router {
 	host = domain.Normalize(host)
 	if domain.TrimPort(host) == "0.0.0.0" {
 		return r.Default(other)
 	}
 
+	p, err := parsePattern(host)
+	if err != nil {
+		panic(err)
+	}
+
 	r.routers = append(r.routers, virtualFabric{
-		Domain: host,
-		Router: other,
+		Pattern: p,
+		Router:  other,
 	})
 	return r
 }
 
+// HostTLS adds a new virtual router, along with the certificate for the host. The certificate
+// is loaded from the PEM-encoded files, which are reloaded by the certificate manager. If the
+// host is a pattern, the certificate is served for the names it's issued for. Panics if the
+// certificate cannot be loaded.
+func (r *Router) HostTLS(host, certFile, keyFile string, other router.Builder) *Router {
+	name := domain.TrimPort(host)
+	var names []string
+	if p, err := parsePattern(host); err == nil && p.Static() {
+		names = append(names, name)
+	}
+
+	if err := r.certs.Load(certFile, keyFile, names...); err != nil {
+		panic(fmt.Errorf("could not load TLS certificate for %s: %w", name, err))
+	}
+
+	return r.Host(host, other)
+}
+
+// Certificates returns the certificate manager, holding certificates of hosts added via
+// HostTLS. Pass it to indigo.ManagedTLS in order to serve them.
+func (r *Router) Certificates() *certs.Manager {
+	return r.certs
+}
+
 // Default sets the default router to route requests, Host header value of which aren't
 // matched.
-// Note: only requests with 0 or 1 Host header values may be passed into the default router.
-// If there are more than 1 value, the request will be refused
+// Note: requests with more than 1 distinct Host header value are refused with 400 Bad Request
+// and never reach the default router
 func (r *Router) Default(def router.Builder) *Router {
 	r.defaultRouter = def
 	return r
 }
 
 func (r *Router) Build() router.Router {
-	routers := make([]virtualRouter, len(r.routers))
-	for i, fabric := range r.routers {
-		routers[i] = virtualRouter{
-			Domain: fabric.Domain,
-			Router: fabric.Router.Build(),
+	var routers, patterns []virtualRouter
+	for _, fabric := range r.routers {
+		virtRouter := virtualRouter{
+			Pattern: fabric.Pattern,
+			Router:  fabric.Router.Build(),
+		}
+
+		if fabric.Pattern.Static() {
+			routers = append(routers, virtRouter)
+		} else {
+			patterns = append(patterns, virtRouter)
 		}
 	}
 
@@ -64,14 +116,15 @@ func (r *Router) Build() router.Router {
 	}
 
 	return &runtimeRouter{
-		routers:       routers,
+		// exact hosts are tried first
+		routers:       append(routers, patterns...),
 		defaultRouter: defaultRouter,
 	}
 }
 
 type virtualRouter struct {
-	Domain string
-	Router router.Router
+	Pattern pattern
+	Router  router.Router
 }
 
 var _ router.Router = new(runtimeRouter)
@@ -105,16 +158,33 @@ func (r *runtimeRouter) OnError(request *http.Request, err error) *http.Response
 // the error response all together. So be careful to always check the nilness of the returned
 // router first
 func (r *runtimeRouter) getRouter(request *http.Request) (router.Router, *http.Response) {
-	host, found := request.Headers.Lookup("Host")
-	if !found {
+	host := request.Host()
+	if len(host) == 0 && !request.Headers.Has("host") {
 		return r.defaultRouter, http.Code(request, status.BadRequest)
 	}
 
+	if len(request.Authority) == 0 && conflictingHosts(request, host) {
+		return nil, http.Code(request, status.BadRequest)
+	}
+
+	name, port := domain.Split(host)
 	for _, virtRouter := range r.routers {
-		if strutil.CmpFoldSafe(virtRouter.Domain, host) {
+		if virtRouter.Pattern.Match(name, port, request.Vars) {
 			return virtRouter.Router, nil
 		}
 	}
 
 	return r.defaultRouter, http.Code(request, status.MisdirectedRequest)
 }
+
+// conflictingHosts reports whether there are multiple Host header values, differing from
+// the first one.
+func conflictingHosts(request *http.Request, first string) bool {
+	for host := range request.Headers.Values("host") {
+		if !strutil.CmpFoldSafe(host, first) {
+			return true
+		}
+	}
+
+	return false
+}
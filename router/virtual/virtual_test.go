@@ -1,10 +1,21 @@
 package virtual
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/indigo-web/indigo/config"
 	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/http/method"
 	"github.com/indigo-web/indigo/http/status"
 	"github.com/indigo-web/indigo/internal/construct"
 	"github.com/indigo-web/indigo/router/inbuilt"
@@ -57,9 +68,131 @@ func TestVirtualRouter(t *testing.T) {
 
 		require.True(t, requestIs(r.OnRequest(newRequest("pavlo.ooo")), OK))
 		require.True(t, requestIs(r.OnRequest(newRequest("localhost")), status.MisdirectedRequest))
-		// Currently disabled the check whether there is more than 1 host
-		//require.True(t, requestIs(r.OnRequest(newRequest("pavlo.ooo", "localhost")), status.BadRequest))
+		require.True(t, requestIs(r.OnRequest(newRequest("pavlo.ooo", "localhost")), status.BadRequest))
+		require.True(t, requestIs(r.OnRequest(newRequest("pavlo.ooo", "Pavlo.ooo")), OK))
 	})
+
+	t.Run("request host normalization", func(t *testing.T) {
+		r := New().
+			Host("pavlo.ooo", inbuilt.New()).
+			Build()
+
+		require.True(t, requestIs(r.OnRequest(newRequest("Pavlo.OOO")), OK))
+		require.True(t, requestIs(r.OnRequest(newRequest("pavlo.ooo:443")), OK))
+		require.True(t, requestIs(r.OnRequest(newRequest("www.pavlo.ooo.")), OK))
+		require.True(t, requestIs(r.OnRequest(newRequest("pavlo.ooo:8080")), status.MisdirectedRequest))
+	})
+
+	t.Run("ports", func(t *testing.T) {
+		r := New().
+			Host("localhost:8080", inbuilt.New()).
+			Host("example.com:*", inbuilt.New()).
+			Build()
+
+		require.True(t, requestIs(r.OnRequest(newRequest("localhost:8080")), OK))
+		require.True(t, requestIs(r.OnRequest(newRequest("localhost:9090")), status.MisdirectedRequest))
+		require.True(t, requestIs(r.OnRequest(newRequest("localhost")), status.MisdirectedRequest))
+		require.True(t, requestIs(r.OnRequest(newRequest("example.com:9090")), OK))
+		require.True(t, requestIs(r.OnRequest(newRequest("example.com")), OK))
+	})
+
+	t.Run("host variables", func(t *testing.T) {
+		r := New().
+			Host(":tenant.example.com", inbuilt.New()).
+			Build()
+
+		request := newRequest("acme.example.com")
+		require.True(t, requestIs(r.OnRequest(request), OK))
+		require.Equal(t, "acme", request.Vars.Value("tenant"))
+		require.Equal(t, 1, request.Vars.Len())
+
+		request = newRequest("eu.acme.example.com")
+		require.True(t, requestIs(r.OnRequest(request), status.MisdirectedRequest))
+		require.True(t, request.Vars.Empty())
+		require.True(t, requestIs(r.OnRequest(newRequest("example.com")), status.MisdirectedRequest))
+	})
+
+	t.Run("wildcard subdomain", func(t *testing.T) {
+		r := New().
+			Host("api.example.com", inbuilt.New().Get("/", http.Respond)).
+			Host("*.example.com", inbuilt.New()).
+			Build()
+
+		request := newRequest("eu.acme.example.com")
+		require.True(t, requestIs(r.OnRequest(request), OK))
+		require.Equal(t, "eu.acme", request.Vars.Value("*"))
+		require.True(t, requestIs(r.OnRequest(newRequest("example.com")), status.MisdirectedRequest))
+
+		// exact hosts take precedence regardless of the registration order
+		request = newRequest("api.example.com")
+		request.Method, request.Path = method.GET, "/"
+		require.True(t, requestIs(r.OnRequest(request), status.OK))
+		require.True(t, request.Vars.Empty())
+	})
+
+	t.Run("malformed pattern", func(t *testing.T) {
+		require.Panics(t, func() {
+			New().Host("api.*.example.com", inbuilt.New())
+		})
+		require.Panics(t, func() {
+			New().Host("api..example.com", inbuilt.New())
+		})
+	})
+
+	t.Run("absolute form", func(t *testing.T) {
+		r := New().
+			Host(":tenant.example.com", inbuilt.New().Get("/hello", http.Respond)).
+			Build()
+
+		// the Host header must be ignored, even if conflicting
+		request := newRequest("localhost", "example.com")
+		request.Method, request.Path = method.GET, "/hello"
+		request.Authority = "acme.example.com"
+		require.True(t, requestIs(r.OnRequest(request), status.OK))
+		require.Equal(t, "acme", request.Vars.Value("tenant"))
+
+		// no Host header is required in this case
+		request = newRequest()
+		request.Authority = "acme.example.com:443"
+		require.True(t, requestIs(r.OnRequest(request), OK))
+	})
+
+	t.Run("host with certificate", func(t *testing.T) {
+		certFile, keyFile := writeCert(t, "pavlo.ooo")
+		v := New().HostTLS("pavlo.ooo:443", certFile, keyFile, inbuilt.New())
+		r := v.Build()
+		require.True(t, requestIs(r.OnRequest(newRequest("pavlo.ooo")), OK))
+
+		cert, err := v.Certificates().GetCertificate(&tls.ClientHelloInfo{ServerName: "pavlo.ooo"})
+		require.NoError(t, err)
+		require.NotNil(t, cert)
+
+		require.Panics(t, func() {
+			New().HostTLS("pavlo.ooo", "nonexistent.crt", "nonexistent.key", inbuilt.New())
+		})
+	})
+}
+
+func writeCert(t *testing.T, name string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{name},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	return certFile, keyFile
 }
 
 func requestIs(resp *http.Response, code status.Code) bool {
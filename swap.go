@@ -0,0 +1,68 @@
+package indigo
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/indigo-web/indigo/http"
+	"github.com/indigo-web/indigo/router"
+	"github.com/indigo-web/indigo/router/inbuilt"
+)
+
+var (
+	ErrNotServing    = errors.New("the application isn't serving yet")
+	ErrBuildPanicked = errors.New("router build panicked")
+)
+
+// Swap builds the router and atomically replaces the one the application is serving with.
+// Requests being processed at the moment are finished by the previous router, while all the
+// consequent ones are served by the new one. If the build panics, the previous router is kept
+// and the error wrapping ErrBuildPanicked is returned. If nil is passed, empty inbuilt router
+// will be used.
+//
+// Must be called only while serving. It's safe to call it from within a handler.
+func (a *App) Swap(r router.Builder) error {
+	if a.router.current.Load() == nil {
+		return ErrNotServing
+	}
+
+	built, err := build(r)
+	if err != nil {
+		return err
+	}
+
+	a.router.current.Store(&built)
+	return nil
+}
+
+// build builds the router, recovering from panics.
+func build(r router.Builder) (built router.Router, err error) {
+	if r == nil {
+		r = inbuilt.New()
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("%w: %v", ErrBuildPanicked, v)
+		}
+	}()
+
+	return r.Build(), nil
+}
+
+var _ router.Router = new(swappable)
+
+// swappable is the router the transports are spawned with. It delegates to the current one,
+// which is replaced by App.Swap.
+type swappable struct {
+	current atomic.Pointer[router.Router]
+}
+
+func (s *swappable) OnRequest(request *http.Request) *http.Response {
+	return (*s.current.Load()).OnRequest(request)
+}
+
+func (s *swappable) OnError(request *http.Request, err error) *http.Response {
+	return (*s.current.Load()).OnError(request, err)
+}
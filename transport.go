@@ -1,6 +1,7 @@
 package indigo
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/tls"
@@ -20,23 +21,146 @@ import (
 	"github.com/indigo-web/indigo/internal/codecutil"
 	"github.com/indigo-web/indigo/router"
 	"github.com/indigo-web/indigo/transport"
-	"golang.org/x/crypto/acme/autocert"
+	"github.com/indigo-web/indigo/transport/certs"
 )
 
+// SpawnFunc sets up serving of connections accepted by the transport. It returns the callback
+// serving a single connection, or nil if the transport serves connections by its own means.
+type SpawnFunc = func(cfg *config.Config, r router.Router, c []codec.Codec) func(net.Conn)
+
 type Transport struct {
 	addr          string
 	inner         transport.Transport
-	spawnCallback func(cfg *config.Config, r router.Router, c []codec.Codec) func(net.Conn)
+	spawnCallback SpawnFunc
+	// companions returns auxiliary transports, bound along with this one. They're bound to
+	// their own addresses, which might depend on the address of the transport.
+	companions func(addr string) []Transport
+}
+
+// NewTransport wraps a custom transport. It's intended for packages providing transports of
+// their own, e.g. http3.
+func NewTransport(inner transport.Transport, spawn SpawnFunc) Transport {
+	return Transport{
+		inner:         inner,
+		spawnCallback: spawn,
+	}
+}
+
+// Inner returns the underlying transport.
+func (t Transport) Inner() transport.Transport {
+	return t.inner
+}
+
+// Companion adds auxiliary transports, bound along with this one as soon as its address is
+// known. Companions with no address set are bound to the same one.
+func (t Transport) Companion(companions func(addr string) []Transport) Transport {
+	previous := t.companions
+	t.companions = func(addr string) []Transport {
+		if previous == nil {
+			return companions(addr)
+		}
+
+		return append(previous(addr), companions(addr)...)
+	}
+
+	return t
+}
+
+// WrapRouter wraps the application router for connections served by the transport.
+func (t Transport) WrapRouter(wrap func(router.Router) router.Router) Transport {
+	spawn := t.spawnCallback
+	t.spawnCallback = func(cfg *config.Config, r router.Router, c []codec.Codec) func(net.Conn) {
+		return spawn(cfg, wrap(r), c)
+	}
+
+	return t
 }
 
 func TCP() Transport {
+	return newPlainTransport(transport.NewTCP())
+}
+
+// Unix returns a transport over Unix domain sockets, therefore the address passed to App.Listen
+// is a path to the socket file. Socket files left by processes which didn't shut down gracefully
+// are removed automatically.
+func Unix(optionalParams ...transport.UnixParams) Transport {
+	var params transport.UnixParams
+	if len(optionalParams) > 0 {
+		params = optionalParams[0]
+	}
+
+	return newPlainTransport(transport.NewUnix(params))
+}
+
+// Sharded opens multiple SO_REUSEPORT listeners on the same address, each having its own
+// accept loop. By default, there are as many shards as CPU cores.
+func Sharded(optionalParams ...transport.ShardedParams) Transport {
+	var params transport.ShardedParams
+	if len(optionalParams) > 0 {
+		params = optionalParams[0]
+	}
+
+	return newPlainTransport(transport.NewSharded(params))
+}
+
+// Epoll returns a transport, serving connections by a fixed pool of workers instead of
+// a goroutine per connection, so idle keep-alive connections are cheap. Available only on
+// Linux and only for plain TCP.
+func Epoll(optionalParams ...transport.EpollParams) Transport {
+	var params transport.EpollParams
+	if len(optionalParams) > 0 {
+		params = optionalParams[0]
+	}
+
+	inner := transport.NewEpoll(params)
+
 	return Transport{
-		inner: transport.NewTCP(),
+		inner: inner,
+		spawnCallback: func(cfg *config.Config, r router.Router, c []codec.Codec) func(net.Conn) {
+			acceptString := codecutil.AcceptEncoding(c)
+			inner.Sessions(func(conn net.Conn) transport.Session {
+				return serve.HTTP1Session(cfg, conn, nil, r, codecutil.NewCache(c, acceptString))
+			})
+
+			// connections are served via sessions instead
+			return nil
+		},
+	}
+}
+
+// Systemd adopts a listener passed by systemd (or any other service manager supporting the
+// socket activation protocol). The address passed to App.Listen is the name of the listener,
+// which is set by FileDescriptorName= and defaults to the socket unit name, e.g.:
+//
+//	app.Listen("app.socket", indigo.Systemd())
+func Systemd() Transport {
+	return newPlainTransport(transport.NewInherited())
+}
+
+// ClientAuth applies the client authentication policy (mutual TLS). Verified client certificates
+// are available to handlers via http.Request.TLS. Panics if the transport isn't a TLS one or the
+// certificate revocation list cannot be loaded.
+func (t Transport) ClientAuth(auth transport.ClientAuth) Transport {
+	inner, ok := t.inner.(*transport.TLS)
+	if !ok {
+		panic("client authentication is supported only by TLS transports")
+	}
+
+	if err := inner.ClientAuth(auth); err != nil {
+		panic(fmt.Errorf("cannot apply client authentication policy: %w", err))
+	}
+
+	return t
+}
+
+func newPlainTransport(inner transport.Transport) Transport {
+	return Transport{
+		inner: inner,
 		spawnCallback: func(cfg *config.Config, r router.Router, c []codec.Codec) func(net.Conn) {
 			acceptString := codecutil.AcceptEncoding(c)
 
 			return func(conn net.Conn) {
-				serve.HTTP1(cfg, conn, 0, r, codecutil.NewCache(c, acceptString))
+				serve.HTTP1(cfg, conn, nil, r, codecutil.NewCache(c, acceptString))
 			}
 		},
 	}
@@ -50,6 +174,18 @@ func TLS(certs ...tls.Certificate) Transport {
 	return newTLSTransport(&tls.Config{Certificates: certs})
 }
 
+// ManagedTLS returns a TLS transport, picking certificates from the manager by the server
+// name the client indicates. Certificates might be added or replaced while serving, e.g. via
+// certs.Manager.Watch, which reloads renewed certificates from disk:
+//
+//	m := certs.New()
+//	_ = m.Load("example.com.crt", "example.com.key")
+//	defer m.Watch(time.Minute)()
+//	app.Listen(":443", indigo.ManagedTLS(m))
+func ManagedTLS(m *certs.Manager) Transport {
+	return newTLSTransport(m.TLSConfig())
+}
+
 // Autocert tries to automatically issue a certificate for the given domains.
 // If operation succeeds, those will be (hopefully) saved into the default cache
 // directory, which depends on the OS. If you want to specify the cache directory,
@@ -63,16 +199,10 @@ func Autocert(domains ...string) Transport {
 // directory. It's recommended to use Autocert if there are no explicit needs to set
 // custom cache directory.
 func AutocertWithCache(cache string, domains ...string) Transport {
-	m := &autocert.Manager{
-		Prompt: autocert.AcceptTOS,
-		Cache:  autocert.DirCache(cache),
-	}
-
-	if len(domains) > 0 {
-		m.HostPolicy = autocert.HostWhitelist(domains...)
-	}
-
-	return newTLSTransport(&tls.Config{GetCertificate: m.GetCertificate})
+	return AutocertWith(AutocertParams{
+		Domains: domains,
+		Cache:   cache,
+	})
 }
 
 // LocalCert issues a self-signed certificate for local TLS-secured connections.
@@ -110,8 +240,18 @@ func newTLSTransport(cfg *tls.Config) Transport {
 			acceptString := codecutil.AcceptEncoding(c)
 
 			return func(conn net.Conn) {
-				ver := conn.(*tls.Conn).ConnectionState().Version
-				serve.HTTP1(cfg, conn, ver, r, codecutil.NewCache(c, acceptString))
+				tlsConn := conn.(*tls.Conn)
+				// the handshake is otherwise lazily done on the first read, however the state
+				// must be known in advance
+				ctx, cancel := context.WithTimeout(context.Background(), cfg.NET.ReadTimeout)
+				err := tlsConn.HandshakeContext(ctx)
+				cancel()
+				if err != nil {
+					return
+				}
+
+				state := tlsConn.ConnectionState()
+				serve.HTTP1(cfg, conn, &state, r, codecutil.NewCache(c, acceptString))
 			}
 		},
 	}
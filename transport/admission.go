@@ -0,0 +1,224 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/internal/bufpool"
+	"github.com/indigo-web/indigo/internal/iputil"
+)
+
+var (
+	ErrTooManyConnections      = errors.New("too many simultaneous connections")
+	ErrTooManyConnectionsPerIP = errors.New("too many simultaneous connections from a single IP")
+	ErrFiltered                = errors.New("connection refused by the filter")
+	ErrMemoryBudgetExceeded    = errors.New("connection buffers exceed the memory budget")
+)
+
+const (
+	// rejectWriteTimeout limits the time spent on writing the rejection response.
+	rejectWriteTimeout = time.Second
+	// budgetPollPeriod controls how often the memory usage is rechecked while waiting for
+	// the budget.
+	budgetPollPeriod = 10 * time.Millisecond
+)
+
+var serviceUnavailable = []byte(
+	"HTTP/1.1 503 Service Unavailable\r\nContent-Length: 0\r\nConnection: close\r\n\r\n",
+)
+
+// AllowList returns a filter accepting connections only from addresses, covered by any of the
+// passed addresses or CIDR ranges. Panics if any of them is malformed.
+func AllowList(cidrs ...string) func(net.Addr) bool {
+	prefixes := mustParsePrefixes(cidrs)
+
+	return func(remote net.Addr) bool {
+		addr, ok := iputil.FromAddr(remote)
+		return ok && iputil.Contains(prefixes, addr)
+	}
+}
+
+// DenyList returns a filter refusing connections from addresses, covered by any of the passed
+// addresses or CIDR ranges. Panics if any of them is malformed.
+func DenyList(cidrs ...string) func(net.Addr) bool {
+	prefixes := mustParsePrefixes(cidrs)
+
+	return func(remote net.Addr) bool {
+		addr, ok := iputil.FromAddr(remote)
+		return !ok || !iputil.Contains(prefixes, addr)
+	}
+}
+
+func mustParsePrefixes(cidrs []string) []netip.Prefix {
+	prefixes, err := iputil.ParsePrefixes(cidrs)
+	if err != nil {
+		panic(fmt.Errorf("bad address range: %w", err))
+	}
+
+	return prefixes
+}
+
+// Admission enforces config.NETConnections limits. It's exposed for transports implemented
+// outside the package.
+type Admission struct {
+	*admission
+}
+
+func NewAdmission(cfg config.NETConnections) Admission {
+	return Admission{newAdmission(cfg)}
+}
+
+// admission enforces config.NETConnections limits.
+type admission struct {
+	cfg   config.NETConnections
+	slots chan struct{}
+	mu    sync.Mutex
+	perIP map[netip.Addr]int
+	inUse func() int64
+}
+
+func newAdmission(cfg config.NETConnections) *admission {
+	a := &admission{cfg: cfg, inUse: bufpool.InUse}
+
+	if cfg.Max > 0 {
+		a.slots = make(chan struct{}, cfg.Max)
+	}
+
+	if cfg.PerIP > 0 {
+		a.perIP = make(map[netip.Addr]int)
+	}
+
+	return a
+}
+
+// Admit decides whether the connection can be served. If it can, the returned release function
+// must be called after the connection is done.
+func (a *admission) Admit(remote net.Addr) (release func(), err error) {
+	if a.cfg.Filter != nil && !a.cfg.Filter(remote) {
+		return nil, ErrFiltered
+	}
+
+	if !a.withinBudget() {
+		return nil, ErrMemoryBudgetExceeded
+	}
+
+	addr, hasAddr := iputil.FromAddr(remote)
+	hasAddr = hasAddr && a.perIP != nil
+	if hasAddr && !a.acquireIP(addr) {
+		return nil, ErrTooManyConnectionsPerIP
+	}
+
+	if !a.acquireSlot() {
+		if hasAddr {
+			a.releaseIP(addr)
+		}
+
+		return nil, ErrTooManyConnections
+	}
+
+	return func() {
+		if a.slots != nil {
+			<-a.slots
+		}
+
+		if hasAddr {
+			a.releaseIP(addr)
+		}
+	}, nil
+}
+
+// Reject reports the rejection and answers the connection, if the reason implies it.
+func (a *admission) Reject(conn net.Conn, reason error) {
+	a.Notify(conn.RemoteAddr(), reason)
+
+	if reason != ErrFiltered {
+		_ = conn.SetWriteDeadline(time.Now().Add(rejectWriteTimeout))
+		_, _ = conn.Write(serviceUnavailable)
+	}
+}
+
+// Notify reports the rejection without answering the connection.
+func (a *admission) Notify(remote net.Addr, reason error) {
+	if a.cfg.OnReject != nil {
+		a.cfg.OnReject(remote, reason)
+	}
+}
+
+func (a *admission) acquireSlot() bool {
+	if a.slots == nil {
+		return true
+	}
+
+	select {
+	case a.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if a.cfg.QueueTimeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(a.cfg.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case a.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (a *admission) withinBudget() bool {
+	if a.cfg.MemoryBudget <= 0 || a.inUse() < a.cfg.MemoryBudget {
+		return true
+	}
+
+	if a.cfg.QueueTimeout <= 0 {
+		return false
+	}
+
+	deadline := time.Now().Add(a.cfg.QueueTimeout)
+	ticker := time.NewTicker(budgetPollPeriod)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if a.inUse() < a.cfg.MemoryBudget {
+			return true
+		}
+
+		if now.After(deadline) {
+			return false
+		}
+	}
+
+	return false
+}
+
+func (a *admission) acquireIP(addr netip.Addr) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.perIP[addr] >= a.cfg.PerIP {
+		return false
+	}
+
+	a.perIP[addr]++
+	return true
+}
+
+func (a *admission) releaseIP(addr netip.Addr) {
+	a.mu.Lock()
+	if a.perIP[addr] <= 1 {
+		delete(a.perIP, addr)
+	} else {
+		a.perIP[addr]--
+	}
+	a.mu.Unlock()
+}
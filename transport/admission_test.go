@@ -0,0 +1,149 @@
+package transport
+
+import (
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/stretchr/testify/require"
+)
+
+func tcpAddr(ip string) net.Addr {
+	return &net.TCPAddr{IP: net.ParseIP(ip), Port: 12345}
+}
+
+func TestAdmission(t *testing.T) {
+	t.Run("max connections", func(t *testing.T) {
+		a := newAdmission(config.NETConnections{Max: 2})
+		release1, err := a.Admit(tcpAddr("1.1.1.1"))
+		require.NoError(t, err)
+		_, err = a.Admit(tcpAddr("1.1.1.2"))
+		require.NoError(t, err)
+		_, err = a.Admit(tcpAddr("1.1.1.3"))
+		require.ErrorIs(t, err, ErrTooManyConnections)
+
+		release1()
+		_, err = a.Admit(tcpAddr("1.1.1.3"))
+		require.NoError(t, err)
+	})
+
+	t.Run("queue", func(t *testing.T) {
+		a := newAdmission(config.NETConnections{Max: 1, QueueTimeout: time.Second})
+		release, err := a.Admit(tcpAddr("1.1.1.1"))
+		require.NoError(t, err)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			release()
+		}()
+
+		_, err = a.Admit(tcpAddr("1.1.1.2"))
+		require.NoError(t, err)
+
+		a = newAdmission(config.NETConnections{Max: 1, QueueTimeout: 50 * time.Millisecond})
+		_, err = a.Admit(tcpAddr("1.1.1.1"))
+		require.NoError(t, err)
+		_, err = a.Admit(tcpAddr("1.1.1.2"))
+		require.ErrorIs(t, err, ErrTooManyConnections)
+	})
+
+	t.Run("memory budget", func(t *testing.T) {
+		var inUse atomic.Int64
+		inUse.Store(2048)
+		a := newAdmission(config.NETConnections{MemoryBudget: 1024})
+		a.inUse = inUse.Load
+		_, err := a.Admit(tcpAddr("1.1.1.1"))
+		require.ErrorIs(t, err, ErrMemoryBudgetExceeded)
+
+		a = newAdmission(config.NETConnections{MemoryBudget: 1024, QueueTimeout: time.Second})
+		a.inUse = inUse.Load
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			inUse.Store(512)
+		}()
+
+		_, err = a.Admit(tcpAddr("1.1.1.1"))
+		require.NoError(t, err)
+	})
+
+	t.Run("per IP", func(t *testing.T) {
+		a := newAdmission(config.NETConnections{PerIP: 1, Max: 2})
+		release, err := a.Admit(tcpAddr("1.1.1.1"))
+		require.NoError(t, err)
+		_, err = a.Admit(tcpAddr("1.1.1.1"))
+		require.ErrorIs(t, err, ErrTooManyConnectionsPerIP)
+		_, err = a.Admit(tcpAddr("::ffff:1.1.1.1"))
+		require.ErrorIs(t, err, ErrTooManyConnectionsPerIP)
+		_, err = a.Admit(tcpAddr("2.2.2.2"))
+		require.NoError(t, err)
+		_, err = a.Admit(tcpAddr("3.3.3.3"))
+		require.ErrorIs(t, err, ErrTooManyConnections)
+		require.Len(t, a.perIP, 2, "the per-IP slot must be released on overall rejection")
+
+		release()
+		require.Len(t, a.perIP, 1)
+		_, err = a.Admit(tcpAddr("1.1.1.1"))
+		require.NoError(t, err)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		a := newAdmission(config.NETConnections{Filter: AllowList("10.0.0.0/8", "::1")})
+		_, err := a.Admit(tcpAddr("10.20.30.40"))
+		require.NoError(t, err)
+		_, err = a.Admit(tcpAddr("::1"))
+		require.NoError(t, err)
+		_, err = a.Admit(tcpAddr("11.0.0.1"))
+		require.ErrorIs(t, err, ErrFiltered)
+
+		a = newAdmission(config.NETConnections{Filter: DenyList("192.168.0.0/16")})
+		_, err = a.Admit(tcpAddr("192.168.1.1"))
+		require.ErrorIs(t, err, ErrFiltered)
+		_, err = a.Admit(tcpAddr("192.169.1.1"))
+		require.NoError(t, err)
+	})
+}
+
+func TestTCPAdmission(t *testing.T) {
+	const addr = "localhost:16250"
+
+	cfg := config.Default().NET
+	cfg.AcceptLoopInterruptPeriod = 50 * time.Millisecond
+	cfg.Connections.Max = 1
+	rejected := make(chan error, 1)
+	cfg.Connections.OnReject = func(_ net.Addr, reason error) {
+		rejected <- reason
+	}
+
+	tcp := NewTCP()
+	require.NoError(t, tcp.Bind(addr))
+	release := make(chan struct{})
+	served := make(chan struct{}, 1)
+	go func() {
+		_ = tcp.Listen(cfg, func(net.Conn) {
+			served <- struct{}{}
+			<-release
+		})
+	}()
+
+	first, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer first.Close()
+	<-served
+
+	second, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer second.Close()
+	require.ErrorIs(t, <-rejected, ErrTooManyConnections)
+
+	response, err := io.ReadAll(second)
+	require.NoError(t, err)
+	require.Equal(t, string(serviceUnavailable), string(response))
+
+	close(release)
+	tcp.Stop()
+	tcp.Wait()
+	tcp.Close()
+}
@@ -0,0 +1,23 @@
+package transport
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// pinToCPU binds the calling OS thread to the CPU core. The caller must lock the goroutine
+// to its thread beforehand.
+func pinToCPU(cpu int) error {
+	const wordBits = 64
+	var set [1024 / wordBits]uint64
+	set[(cpu/wordBits)%len(set)] = 1 << (cpu % wordBits)
+
+	_, _, errno := syscall.RawSyscall(
+		syscall.SYS_SCHED_SETAFFINITY, 0, uintptr(unsafe.Sizeof(set)), uintptr(unsafe.Pointer(&set)),
+	)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package transport
+
+// pinToCPU is a no-op, as thread affinity is supported only on Linux.
+func pinToCPU(int) error {
+	return nil
+}
@@ -0,0 +1,280 @@
+// Package certs implements a certificate manager, selecting certificates by the server name
+// indicated by clients (SNI) and reloading those loaded from files as soon as they change.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrNoCertificate = errors.New("no certificate for the server name")
+	ErrNoNames       = errors.New("no names to register the certificate for")
+)
+
+type entry struct {
+	cert atomic.Pointer[tls.Certificate]
+	// the fields below are set only for certificates loaded from files
+	mu                sync.Mutex
+	certFile, keyFile string
+	certStat, keyStat fileStat
+}
+
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+// Manager maps server names to certificates. Names might be wildcards, e.g. *.example.com,
+// covering exactly one label. Exact names take precedence over wildcards. The first added
+// certificate serves clients with no or unknown server name, unless the default one is set
+// explicitly.
+//
+// Manager is safe for concurrent use, so certificates can be added and replaced while serving.
+type Manager struct {
+	mu      sync.Mutex
+	entries []*entry
+	// names is replaced as a whole on every change, so lookups never need a lock
+	names   atomic.Pointer[map[string]*entry]
+	def     atomic.Pointer[entry]
+	onError func(error)
+}
+
+func New() *Manager {
+	m := new(Manager)
+	m.names.Store(&map[string]*entry{})
+
+	return m
+}
+
+// Add registers the certificate for the names. If no names are passed, they're taken from
+// the certificate itself.
+func (m *Manager) Add(cert tls.Certificate, names ...string) error {
+	e := new(entry)
+	e.cert.Store(&cert)
+
+	return m.register(e, names)
+}
+
+// Load loads the certificate from the PEM-encoded files and registers it for the names. If
+// no names are passed, they're taken from the certificate itself. The files are reloaded by
+// Reload and Watch.
+func (m *Manager) Load(certFile, keyFile string, names ...string) error {
+	e := &entry{certFile: certFile, keyFile: keyFile}
+	if _, err := e.reload(); err != nil {
+		return err
+	}
+
+	return m.register(e, names)
+}
+
+// Default sets the certificate serving clients with no or unknown server name.
+func (m *Manager) Default(cert tls.Certificate) {
+	e := new(entry)
+	e.cert.Store(&cert)
+	m.def.Store(e)
+}
+
+// OnError sets the callback, notified about failed reloads. In this case, the previous
+// certificate remains in use and the reload is retried later.
+func (m *Manager) OnError(cb func(error)) {
+	m.mu.Lock()
+	m.onError = cb
+	m.mu.Unlock()
+}
+
+func (m *Manager) register(e *entry, names []string) error {
+	if len(names) == 0 {
+		var err error
+		if names, err = certNames(e.cert.Load()); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old := *m.names.Load()
+	updated := make(map[string]*entry, len(old)+len(names))
+	for name, other := range old {
+		updated[name] = other
+	}
+
+	for _, name := range names {
+		updated[normalize(name)] = e
+	}
+
+	m.entries = append(m.entries, e)
+	m.names.Store(&updated)
+	m.def.CompareAndSwap(nil, e)
+
+	return nil
+}
+
+// GetCertificate picks the certificate for the handshake. It's meant to be used as
+// tls.Config.GetCertificate.
+func (m *Manager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if e := m.lookup(normalize(hello.ServerName)); e != nil {
+		return e.cert.Load(), nil
+	}
+
+	if def := m.def.Load(); def != nil {
+		return def.cert.Load(), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrNoCertificate, hello.ServerName)
+}
+
+func (m *Manager) lookup(name string) *entry {
+	if len(name) == 0 {
+		return nil
+	}
+
+	names := *m.names.Load()
+	if e, found := names[name]; found {
+		return e
+	}
+
+	if dot := strings.IndexByte(name, '.'); dot != -1 {
+		return names["*"+name[dot:]]
+	}
+
+	return nil
+}
+
+// TLSConfig returns a TLS configuration, serving certificates of the manager.
+func (m *Manager) TLSConfig() *tls.Config {
+	return &tls.Config{GetCertificate: m.GetCertificate}
+}
+
+// Reload reloads all the certificates loaded from files, which were modified since the last
+// time. Failed reloads don't affect the others, the first error is returned.
+func (m *Manager) Reload() error {
+	m.mu.Lock()
+	entries, onError := m.entries, m.onError
+	m.mu.Unlock()
+
+	var first error
+
+	for _, e := range entries {
+		if len(e.certFile) == 0 {
+			continue
+		}
+
+		if _, err := e.reload(); err != nil {
+			if onError != nil {
+				onError(err)
+			}
+
+			if first == nil {
+				first = err
+			}
+		}
+	}
+
+	return first
+}
+
+// Watch reloads modified certificates every period until stopped. Errors are reported to
+// the OnError callback.
+func (m *Manager) Watch(period time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(period)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				_ = m.Reload()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			close(done)
+		})
+	}
+}
+
+// reload loads the files again if they were modified. The certificate is swapped only if
+// it loaded successfully, so a half-written pair never goes live.
+func (e *entry) reload() (reloaded bool, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	certStat, err := stat(e.certFile)
+	if err != nil {
+		return false, err
+	}
+
+	keyStat, err := stat(e.keyFile)
+	if err != nil {
+		return false, err
+	}
+
+	if e.cert.Load() != nil && certStat == e.certStat && keyStat == e.keyStat {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(e.certFile, e.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("load %s: %w", e.certFile, err)
+	}
+
+	e.cert.Store(&cert)
+	e.certStat, e.keyStat = certStat, keyStat
+
+	return true, nil
+}
+
+func stat(filename string) (fileStat, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return fileStat{}, err
+	}
+
+	return fileStat{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+func certNames(cert *tls.Certificate) ([]string, error) {
+	leaf := cert.Leaf
+	if leaf == nil {
+		if len(cert.Certificate) == 0 {
+			return nil, ErrNoNames
+		}
+
+		var err error
+		if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	names := leaf.DNSNames
+	if len(names) == 0 && len(leaf.Subject.CommonName) > 0 {
+		names = []string{leaf.Subject.CommonName}
+	}
+
+	if len(names) == 0 {
+		return nil, ErrNoNames
+	}
+
+	return names, nil
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -0,0 +1,106 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func generate(t *testing.T, org string, names ...string) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{org}},
+		DNSNames:     names,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func cert(t *testing.T, org string, names ...string) tls.Certificate {
+	c, err := tls.X509KeyPair(generate(t, org, names...))
+	require.NoError(t, err)
+	return c
+}
+
+func writePair(t *testing.T, dir, org string, names ...string) (certFile, keyFile string) {
+	certPEM, keyPEM := generate(t, org, names...)
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0600))
+	return certFile, keyFile
+}
+
+func org(t *testing.T, m *Manager, serverName string) string {
+	c, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(c.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.Organization[0]
+}
+
+func TestManager(t *testing.T) {
+	t.Run("SNI", func(t *testing.T) {
+		m := New()
+		require.NoError(t, m.Add(cert(t, "first", "example.com")))
+		require.NoError(t, m.Add(cert(t, "wildcard", "*.example.com")))
+		require.NoError(t, m.Add(cert(t, "exact"), "api.example.com"))
+
+		require.Equal(t, "first", org(t, m, "example.com"))
+		require.Equal(t, "first", org(t, m, "EXAMPLE.COM."))
+		require.Equal(t, "wildcard", org(t, m, "www.example.com"))
+		require.Equal(t, "exact", org(t, m, "api.example.com"))
+		require.Equal(t, "first", org(t, m, "deep.www.example.com"), "wildcards cover a single label")
+		require.Equal(t, "first", org(t, m, ""))
+
+		m.Default(cert(t, "default"))
+		require.Equal(t, "default", org(t, m, "unknown.org"))
+	})
+
+	t.Run("no certificate", func(t *testing.T) {
+		_, err := New().GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"})
+		require.ErrorIs(t, err, ErrNoCertificate)
+		require.ErrorIs(t, New().Add(cert(t, "unnamed")), ErrNoNames)
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile, keyFile := writePair(t, dir, "old", "example.com")
+		m := New()
+		require.NoError(t, m.Load(certFile, keyFile))
+		require.Equal(t, "old", org(t, m, "example.com"))
+
+		// a half-written pair must be ignored
+		require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0600))
+		require.Error(t, m.Reload())
+		require.Equal(t, "old", org(t, m, "example.com"))
+
+		writePair(t, dir, "new", "example.com")
+		stop := m.Watch(10 * time.Millisecond)
+		defer stop()
+
+		require.Eventually(t, func() bool {
+			return org(t, m, "example.com") == "new"
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
@@ -4,28 +4,37 @@ import (
 	"net"
 	"time"
 
+	"github.com/indigo-web/indigo/internal/bufpool"
 	"github.com/indigo-web/indigo/internal/timer"
 )
 
 type Client interface {
 	Read() ([]byte, error)
 	Pushback([]byte)
+	Pending() []byte
 	Write([]byte) (int, error)
 	Conn() net.Conn
 	Remote() net.Addr
 	Close() error
+	// Release returns the read buffer, unless it still holds pending data. The buffer is
+	// acquired again on the next read.
+	Release()
 }
 
 type client struct {
 	conn    net.Conn
 	buff    []byte
 	pending []byte
+	size    int
 	timeout time.Duration
 }
 
+// NewClient returns a client reading via the passed buffer. The buffer is owned by the client
+// afterward and is returned into the bufpool on release.
 func NewClient(conn net.Conn, timeout time.Duration, buff []byte) Client {
 	return &client{
 		buff:    buff,
+		size:    len(buff),
 		conn:    conn,
 		timeout: timeout,
 	}
@@ -45,10 +54,24 @@ func (c *client) Read() ([]byte, error) {
 		return nil, err
 	}
 
+	if c.buff == nil {
+		c.buff = bufpool.Get(c.size)[:c.size]
+	}
+
 	n, err := c.conn.Read(c.buff)
 	return c.buff[:n], err
 }
 
+// Release returns the read buffer into the bufpool, if no pending data refers to it.
+func (c *client) Release() {
+	if len(c.pending) > 0 || c.buff == nil {
+		return
+	}
+
+	bufpool.Put(c.buff)
+	c.buff = nil
+}
+
 // Pending returns data (if any) preserved via Pushback.
 func (c *client) Pending() []byte {
 	return c.pending
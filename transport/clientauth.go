@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrCertificateRevoked = errors.New("client certificate is revoked")
+
+// crlCheckPeriod limits how often the CRL file is checked for modifications.
+const crlCheckPeriod = time.Second
+
+// ClientAuth is the policy of authenticating clients by their certificates.
+type ClientAuth struct {
+	// Policy decides whether client certificates are requested, required and verified. See
+	// tls.ClientAuthType for possible values.
+	Policy tls.ClientAuthType
+	// CAs is the pool of certificate authorities, client certificates are verified against.
+	// Defaults to the system pool.
+	CAs *x509.CertPool
+	// CRL is the path to the certificate revocation list, either PEM or DER encoded. Clients
+	// presenting a revoked certificate are refused. The file is reloaded when modified. The list
+	// is trusted as is, as it's provided locally.
+	CRL string
+}
+
+// ClientAuth applies the client authentication policy. Must be called before Bind.
+func (t *TLS) ClientAuth(auth ClientAuth) error {
+	t.cfg.ClientAuth = auth.Policy
+	t.cfg.ClientCAs = auth.CAs
+
+	if len(auth.CRL) == 0 {
+		return nil
+	}
+
+	crl := &revocationList{file: auth.CRL}
+	if err := crl.reload(); err != nil {
+		return err
+	}
+
+	t.cfg.VerifyConnection = func(state tls.ConnectionState) error {
+		return crl.Check(state.PeerCertificates)
+	}
+
+	return nil
+}
+
+type revocationList struct {
+	file string
+
+	mu        sync.Mutex
+	modTime   time.Time
+	nextCheck time.Time
+	revoked   map[string]struct{}
+}
+
+// Check returns ErrCertificateRevoked if any of the certificates is revoked.
+func (r *revocationList) Check(chain []*x509.Certificate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := time.Now(); now.After(r.nextCheck) {
+		r.nextCheck = now.Add(crlCheckPeriod)
+		// if the list cannot be reloaded, keep on using the previous one
+		_ = r.reloadLocked()
+	}
+
+	for _, cert := range chain {
+		if _, found := r.revoked[revocationKey(cert.RawIssuer, cert.SerialNumber.Bytes())]; found {
+			return fmt.Errorf("%w: serial %s", ErrCertificateRevoked, cert.SerialNumber)
+		}
+	}
+
+	return nil
+}
+
+func (r *revocationList) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.reloadLocked()
+}
+
+func (r *revocationList) reloadLocked() error {
+	info, err := os.Stat(r.file)
+	if err != nil {
+		return err
+	}
+
+	if r.revoked != nil && info.ModTime().Equal(r.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(r.file)
+	if err != nil {
+		return err
+	}
+
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+
+	list, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return fmt.Errorf("parse CRL %s: %w", r.file, err)
+	}
+
+	revoked := make(map[string]struct{}, len(list.RevokedCertificateEntries))
+	for _, entry := range list.RevokedCertificateEntries {
+		revoked[revocationKey(list.RawIssuer, entry.SerialNumber.Bytes())] = struct{}{}
+	}
+
+	r.revoked, r.modTime = revoked, info.ModTime()
+
+	return nil
+}
+
+func revocationKey(issuer, serial []byte) string {
+	return string(issuer) + string(serial)
+}
@@ -0,0 +1,105 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientAuth(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issue := func(serial int64) *x509.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "client"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		return cert
+	}
+
+	writeCRL := func(path string, serials ...int64) {
+		var entries []x509.RevocationListEntry
+		for _, serial := range serials {
+			entries = append(entries, x509.RevocationListEntry{
+				SerialNumber:   big.NewInt(serial),
+				RevocationTime: time.Now(),
+			})
+		}
+
+		der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:                    big.NewInt(int64(len(serials))),
+			RevokedCertificateEntries: entries,
+		}, ca, caKey)
+		require.NoError(t, err)
+		data := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+		require.NoError(t, os.WriteFile(path, data, 0600))
+	}
+
+	t.Run("revocation", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ca.crl")
+		writeCRL(path, 2)
+		crl := &revocationList{file: path}
+		require.NoError(t, crl.reload())
+
+		require.NoError(t, crl.Check([]*x509.Certificate{issue(1)}))
+		require.ErrorIs(t, crl.Check([]*x509.Certificate{issue(2)}), ErrCertificateRevoked)
+
+		// the modification time might not change if written too fast
+		writeCRL(path, 1, 2)
+		require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+		crl.nextCheck = time.Time{}
+		require.ErrorIs(t, crl.Check([]*x509.Certificate{issue(1)}), ErrCertificateRevoked)
+	})
+
+	t.Run("policy", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ca.crl")
+		writeCRL(path)
+		pool := x509.NewCertPool()
+		pool.AddCert(ca)
+
+		tlsTransport := NewTLS(new(tls.Config))
+		require.NoError(t, tlsTransport.ClientAuth(ClientAuth{
+			Policy: tls.RequireAndVerifyClientCert,
+			CAs:    pool,
+			CRL:    path,
+		}))
+		require.Equal(t, tls.RequireAndVerifyClientCert, tlsTransport.cfg.ClientAuth)
+		require.NotNil(t, tlsTransport.cfg.VerifyConnection)
+
+		err := NewTLS(new(tls.Config)).ClientAuth(ClientAuth{CRL: filepath.Join(t.TempDir(), "none")})
+		require.Error(t, err)
+	})
+}
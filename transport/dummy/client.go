@@ -57,6 +57,12 @@ func (c *Client) Pushback(takeback []byte) {
 	c.pending = takeback
 }
 
+func (c *Client) Pending() []byte {
+	return c.pending
+}
+
+func (c *Client) Release() {}
+
 func (c *Client) Write(p []byte) (int, error) {
 	return c.conn.Write(p)
 }
@@ -116,6 +122,12 @@ func (n NopClient) Read() ([]byte, error) {
 
 func (n NopClient) Pushback([]byte) {}
 
+func (n NopClient) Pending() []byte {
+	return nil
+}
+
+func (n NopClient) Release() {}
+
 func (n NopClient) Write(b []byte) (int, error) {
 	return len(b), nil
 }
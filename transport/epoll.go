@@ -0,0 +1,36 @@
+package transport
+
+import (
+	"errors"
+	"net"
+	"runtime"
+)
+
+var ErrEpollUnsupported = errors.New("epoll transport is supported only on Linux")
+
+// Session is a connection served incrementally, as new data arrives.
+type Session interface {
+	// Resume processes the data arrived on the connection. It's called only when the connection
+	// is readable, so the first read never blocks. Returns false if the connection must be closed.
+	Resume() (keepAlive bool)
+	// Release frees the resources held by the session. It's called once the connection is closed.
+	Release()
+}
+
+// SessionFactory sets up a session for a freshly accepted connection.
+type SessionFactory func(conn net.Conn) Session
+
+type EpollParams struct {
+	// Workers is the number of goroutines processing connections with arrived data. As handlers
+	// might block (e.g. reading a request body or querying a database), it's reasonable to have
+	// more of them than CPU cores. Defaults to 8 per CPU core.
+	Workers int
+}
+
+func (e EpollParams) withDefaults() EpollParams {
+	if e.Workers <= 0 {
+		e.Workers = 8 * runtime.NumCPU()
+	}
+
+	return e
+}
@@ -0,0 +1,333 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/indigo-web/indigo/internal/timer"
+)
+
+const (
+	epollEvents = syscall.EPOLLIN | syscall.EPOLLRDHUP | syscall.EPOLLONESHOT
+	// epollSweepPeriod controls how often idle connections are checked for timeouts.
+	epollSweepPeriod = time.Second
+)
+
+type epollConn struct {
+	id      int32
+	fd      int
+	conn    net.Conn
+	session Session
+	release func()
+	// both fields are guarded by Epoll.mu
+	busy     bool
+	lastSeen time.Time
+}
+
+// Epoll is a transport serving connections by a fixed pool of workers instead of a goroutine
+// per connection. All connections are watched by epoll(7), and only those with arrived data are
+// dispatched to workers, so idle keep-alive connections cost no goroutine at all. Idle connections
+// are closed as soon as the transport stops, instead of waiting for the read timeout.
+//
+// Only plain TCP is supported, as TLS might keep already decrypted data in its own buffers, not
+// reflected by the socket readiness.
+type Epoll struct {
+	params   EpollParams
+	sessions SessionFactory
+	l        *net.TCPListener
+	epfd     int
+	stop     *atomic.Bool
+	wg       *sync.WaitGroup
+
+	mu     sync.Mutex
+	conns  map[int32]*epollConn
+	nextID int32
+}
+
+func NewEpoll(params EpollParams) *Epoll {
+	return &Epoll{
+		params: params.withDefaults(),
+		stop:   new(atomic.Bool),
+		wg:     new(sync.WaitGroup),
+		conns:  make(map[int32]*epollConn),
+	}
+}
+
+// Sessions sets the factory of sessions for new connections. Must be called before Listen.
+func (e *Epoll) Sessions(factory SessionFactory) {
+	e.sessions = factory
+}
+
+func (e *Epoll) Bind(addr string) error {
+	l, err := bindTCP(addr)
+	if err != nil {
+		return err
+	}
+
+	if err = e.setup(l); err != nil {
+		_ = l.Close()
+	}
+
+	return err
+}
+
+// File returns a duplicate of the listener's file descriptor.
+func (e *Epoll) File() (*os.File, error) {
+	return e.l.File()
+}
+
+// Adopt makes the transport serve the listener instead of binding a new one. Only TCP
+// listeners are supported.
+func (e *Epoll) Adopt(l net.Listener) error {
+	tcp, ok := l.(*net.TCPListener)
+	if !ok {
+		return fmt.Errorf("unsupported listener type %T", l)
+	}
+
+	return e.setup(tcp)
+}
+
+func (e *Epoll) setup(l *net.TCPListener) error {
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return os.NewSyscallError("epoll_create1", err)
+	}
+
+	e.l, e.epfd = l, epfd
+	// Listen is guaranteed to follow a successful Bind, therefore Wait will be able
+	// to wait for it even if stopped right away
+	e.wg.Add(1)
+
+	return nil
+}
+
+func (e *Epoll) Listen(cfg config.NET, _ func(conn net.Conn)) error {
+	defer e.wg.Done()
+
+	if e.sessions == nil {
+		return errors.New("epoll: no session factory set")
+	}
+
+	var (
+		admit   = newAdmission(cfg.Connections)
+		jobs    = make(chan *epollConn, e.params.Workers)
+		acceptc = make(chan error, 1)
+		workers sync.WaitGroup
+		pending sync.WaitGroup
+	)
+
+	for range e.params.Workers {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+
+			for c := range jobs {
+				e.resume(c)
+			}
+		}()
+	}
+
+	go func() {
+		acceptc <- e.accept(cfg, admit, &pending)
+	}()
+
+	err := e.poll(cfg, jobs)
+	e.stop.Store(true)
+	if aerr := <-acceptc; err == nil {
+		err = aerr
+	}
+
+	pending.Wait()
+	close(jobs)
+	workers.Wait()
+
+	// all the workers are done, so every connection left is an idle one
+	for _, c := range e.snapshot() {
+		e.close(c)
+	}
+
+	return err
+}
+
+func (e *Epoll) accept(cfg config.NET, admit *admission, pending *sync.WaitGroup) error {
+	for !e.stop.Load() {
+		err := e.l.SetDeadline(timer.Now().Add(cfg.AcceptLoopInterruptPeriod))
+		if err != nil {
+			return err
+		}
+
+		conn, err := e.l.AcceptTCP()
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				continue
+			}
+
+			return err
+		}
+
+		// admission might make the connection wait for a free slot, which must not block
+		// the accept loop
+		pending.Add(1)
+		go e.register(admit, conn, pending)
+	}
+
+	return nil
+}
+
+func (e *Epoll) register(admit *admission, conn *net.TCPConn, pending *sync.WaitGroup) {
+	defer pending.Done()
+
+	release, err := admit.Admit(conn.RemoteAddr())
+	if err != nil {
+		admit.Reject(conn, err)
+		_ = conn.Close()
+		return
+	}
+
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		_ = conn.Close()
+		release()
+		return
+	}
+
+	c := &epollConn{conn: conn, release: release, lastSeen: timer.Now()}
+	_ = raw.Control(func(fd uintptr) {
+		c.fd = int(fd)
+	})
+
+	e.mu.Lock()
+	// identifiers are used instead of descriptors as a key, because descriptors are reused
+	// by the system, therefore stale events might be dispatched to wrong connections
+	c.id = e.nextID
+	e.nextID++
+	e.conns[c.id] = c
+	e.mu.Unlock()
+
+	ev := syscall.EpollEvent{Events: epollEvents, Fd: c.id}
+	if err = syscall.EpollCtl(e.epfd, syscall.EPOLL_CTL_ADD, c.fd, &ev); err != nil {
+		e.close(c)
+	}
+}
+
+func (e *Epoll) poll(cfg config.NET, jobs chan<- *epollConn) error {
+	var (
+		events    = make([]syscall.EpollEvent, 128)
+		timeout   = int(min(cfg.AcceptLoopInterruptPeriod, epollSweepPeriod) / time.Millisecond)
+		nextSweep = timer.Now().Add(epollSweepPeriod)
+	)
+
+	for !e.stop.Load() {
+		n, err := syscall.EpollWait(e.epfd, events, timeout)
+		if err != nil && err != syscall.EINTR {
+			return os.NewSyscallError("epoll_wait", err)
+		}
+
+		for _, ev := range events[:max(n, 0)] {
+			e.mu.Lock()
+			c := e.conns[ev.Fd]
+			if c != nil {
+				c.busy = true
+			}
+			e.mu.Unlock()
+
+			if c != nil {
+				jobs <- c
+			}
+		}
+
+		if now := timer.Now(); now.After(nextSweep) {
+			e.sweep(now.Add(-cfg.ReadTimeout))
+			nextSweep = now.Add(epollSweepPeriod)
+		}
+	}
+
+	return nil
+}
+
+func (e *Epoll) resume(c *epollConn) {
+	if c.session == nil {
+		c.session = e.sessions(c.conn)
+	}
+
+	if !c.session.Resume() {
+		e.close(c)
+		return
+	}
+
+	e.mu.Lock()
+	c.busy = false
+	c.lastSeen = timer.Now()
+	e.mu.Unlock()
+
+	ev := syscall.EpollEvent{Events: epollEvents, Fd: c.id}
+	if err := syscall.EpollCtl(e.epfd, syscall.EPOLL_CTL_MOD, c.fd, &ev); err != nil {
+		e.close(c)
+	}
+}
+
+// sweep closes all the idle connections, which weren't active since the deadline.
+func (e *Epoll) sweep(deadline time.Time) {
+	var expired []*epollConn
+
+	e.mu.Lock()
+	for _, c := range e.conns {
+		if !c.busy && c.lastSeen.Before(deadline) {
+			c.busy = true
+			expired = append(expired, c)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, c := range expired {
+		e.close(c)
+	}
+}
+
+func (e *Epoll) snapshot() []*epollConn {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	conns := make([]*epollConn, 0, len(e.conns))
+	for _, c := range e.conns {
+		conns = append(conns, c)
+	}
+
+	return conns
+}
+
+func (e *Epoll) close(c *epollConn) {
+	e.mu.Lock()
+	delete(e.conns, c.id)
+	e.mu.Unlock()
+
+	// the descriptor must be removed from the interest list before it's closed, as
+	// otherwise it might be reused in between
+	_ = syscall.EpollCtl(e.epfd, syscall.EPOLL_CTL_DEL, c.fd, new(syscall.EpollEvent))
+	_ = c.conn.Close()
+	if c.session != nil {
+		c.session.Release()
+	}
+
+	c.release()
+}
+
+func (e *Epoll) Stop() {
+	e.stop.Store(true)
+}
+
+func (e *Epoll) Close() {
+	_ = e.l.Close()
+	_ = syscall.Close(e.epfd)
+}
+
+func (e *Epoll) Wait() {
+	e.wg.Wait()
+}
@@ -0,0 +1,168 @@
+package transport
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/stretchr/testify/require"
+)
+
+// echoSession answers every line with its uppercased version. Lines might be split
+// across reads, so the state is kept between resumptions.
+type echoSession struct {
+	conn    net.Conn
+	partial string
+}
+
+func (e *echoSession) Resume() bool {
+	buff := make([]byte, 64)
+	n, err := e.conn.Read(buff)
+	if err != nil {
+		return false
+	}
+
+	e.partial += string(buff[:n])
+	for {
+		line, rest, found := strings.Cut(e.partial, "\n")
+		if !found {
+			return true
+		}
+
+		e.partial = rest
+		if line == "bye" {
+			return false
+		}
+
+		if _, err = e.conn.Write([]byte(strings.ToUpper(line) + "\n")); err != nil {
+			return false
+		}
+	}
+}
+
+func (e *echoSession) Release() {}
+
+func TestEpoll(t *testing.T) {
+	const addr = "127.0.0.1:16270"
+
+	e := NewEpoll(EpollParams{Workers: 2})
+	e.Sessions(func(conn net.Conn) Session {
+		return &echoSession{conn: conn}
+	})
+	require.NoError(t, e.Bind(addr))
+
+	cfg := config.Default().NET
+	cfg.AcceptLoopInterruptPeriod = 50 * time.Millisecond
+	cfg.ReadTimeout = 200 * time.Millisecond
+	errch := make(chan error)
+	go func() {
+		errch <- e.Listen(cfg, nil)
+	}()
+
+	dial := func(t *testing.T) (net.Conn, *bufio.Reader) {
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		return conn, bufio.NewReader(conn)
+	}
+
+	t.Run("many connections", func(t *testing.T) {
+		// more connections than workers, all interleaved
+		conns := make([]net.Conn, 10)
+		readers := make([]*bufio.Reader, 10)
+		for i := range conns {
+			conns[i], readers[i] = dial(t)
+		}
+
+		for round := range 3 {
+			for i, conn := range conns {
+				_, err := conn.Write([]byte("hel"))
+				require.NoError(t, err)
+				_, err = conn.Write([]byte("lo\nworld\n"))
+				require.NoError(t, err)
+
+				for _, want := range []string{"HELLO\n", "WORLD\n"} {
+					line, err := readers[i].ReadString('\n')
+					require.NoError(t, err, round)
+					require.Equal(t, want, line)
+				}
+			}
+		}
+
+		for _, conn := range conns {
+			_, err := conn.Write([]byte("bye\n"))
+			require.NoError(t, err)
+			_, err = io.ReadAll(conn)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("idle timeout", func(t *testing.T) {
+		conn, _ := dial(t)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		_, err := conn.Read(make([]byte, 1))
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("stop closes idle connections", func(t *testing.T) {
+		conn, reader := dial(t)
+		_, err := conn.Write([]byte("ping\n"))
+		require.NoError(t, err)
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "PING\n", line)
+
+		e.Stop()
+		require.NoError(t, <-errch)
+		e.Wait()
+		e.Close()
+
+		_, err = conn.Read(make([]byte, 1))
+		require.ErrorIs(t, err, io.EOF)
+	})
+}
+
+func TestEpollHandoff(t *testing.T) {
+	const addr = "127.0.0.1:16271"
+
+	parent := NewEpoll(EpollParams{Workers: 1})
+	require.NoError(t, parent.Bind(addr))
+	file, err := parent.File()
+	require.NoError(t, err)
+	// the listener is still open via the duplicate, so it's never refused in between
+	parent.Close()
+
+	l, err := net.FileListener(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	e := NewEpoll(EpollParams{Workers: 1})
+	e.Sessions(func(conn net.Conn) Session {
+		return &echoSession{conn: conn}
+	})
+	require.NoError(t, e.Adopt(l))
+
+	cfg := config.Default().NET
+	cfg.AcceptLoopInterruptPeriod = 50 * time.Millisecond
+	errch := make(chan error)
+	go func() {
+		errch <- e.Listen(cfg, nil)
+	}()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("hello\nbye\n"))
+	require.NoError(t, err)
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "HELLO\n", line)
+	_ = conn.Close()
+
+	e.Stop()
+	require.NoError(t, <-errch)
+	e.Wait()
+	e.Close()
+}
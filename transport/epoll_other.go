@@ -0,0 +1,39 @@
+//go:build !linux
+
+package transport
+
+import (
+	"net"
+	"os"
+
+	"github.com/indigo-web/indigo/config"
+)
+
+// Epoll is a stub, as epoll is available only on Linux. Binding it always fails.
+type Epoll struct{}
+
+func NewEpoll(EpollParams) *Epoll {
+	return new(Epoll)
+}
+
+func (*Epoll) Sessions(SessionFactory) {}
+
+func (*Epoll) Bind(string) error {
+	return ErrEpollUnsupported
+}
+
+func (*Epoll) File() (*os.File, error) {
+	return nil, ErrEpollUnsupported
+}
+
+func (*Epoll) Adopt(net.Listener) error {
+	return ErrEpollUnsupported
+}
+
+func (*Epoll) Listen(config.NET, func(conn net.Conn)) error {
+	return ErrEpollUnsupported
+}
+
+func (*Epoll) Stop()  {}
+func (*Epoll) Close() {}
+func (*Epoll) Wait()  {}
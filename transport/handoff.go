@@ -0,0 +1,167 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	envHandoffFDs     = "INDIGO_LISTEN_FDS"
+	envHandoffFDNames = "INDIGO_LISTEN_FDNAMES"
+	envReadyFD        = "INDIGO_READY_FD"
+	// handoffSeparator separates names of handed off listeners. Colons, as used by systemd,
+	// aren't suitable, as names are addresses here.
+	handoffSeparator = "\n"
+)
+
+var (
+	ErrHandoffUnsupported = errors.New("transport doesn't support listener handoff")
+	ErrChildNotReady      = errors.New("child process exited before getting ready")
+	ErrChildTimeout       = errors.New("child process didn't get ready in time")
+)
+
+// Handoff is implemented by transports, whose listeners can be passed to another process.
+type Handoff interface {
+	// File returns a duplicate of the listener's file descriptor.
+	File() (*os.File, error)
+	// Adopt makes the transport serve the listener instead of binding a new one.
+	Adopt(l net.Listener) error
+}
+
+// MultiHandoff is implemented by transports serving multiple listeners on the same address,
+// all of which are passed to another process.
+type MultiHandoff interface {
+	// Files returns duplicates of file descriptors of all the listeners.
+	Files() ([]*os.File, error)
+	// AdoptAll makes the transport serve the listeners instead of binding new ones.
+	AdoptAll(ls []net.Listener) error
+}
+
+// Files returns duplicates of listeners of all the bound transports, named by their addresses.
+// Fails if any of the transports doesn't support handoff, as the new process wouldn't be
+// able to serve it otherwise.
+func (s *Supervisor) Files() (files []*os.File, names []string, err error) {
+	for _, t := range s.ts {
+		var tfiles []*os.File
+		switch h := t.t.(type) {
+		case MultiHandoff:
+			tfiles, err = h.Files()
+		case Handoff:
+			var file *os.File
+			file, err = h.File()
+			tfiles = []*os.File{file}
+		default:
+			err = ErrHandoffUnsupported
+		}
+
+		if err != nil {
+			closeFiles(files)
+			return nil, nil, fmt.Errorf("%s: %w", t.addr, err)
+		}
+
+		for _, file := range tfiles {
+			files = append(files, file)
+			names = append(names, t.addr)
+		}
+	}
+
+	return files, names, nil
+}
+
+// Spawn starts a new instance of the running executable with the same arguments and environment,
+// handing the files off to it. It returns as soon as the new process reports its readiness via
+// Ready. If it exits or doesn't get ready in time, it's killed and an error is returned. The files
+// are closed in any case.
+func Spawn(files []*os.File, names []string, timeout time.Duration) (*os.Process, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		closeFiles(files)
+		return nil, err
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	return spawn(cmd, files, names, timeout)
+}
+
+func spawn(cmd *exec.Cmd, files []*os.File, names []string, timeout time.Duration) (*os.Process, error) {
+	defer closeFiles(files)
+
+	ready, notify, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer ready.Close()
+
+	// passed files are enumerated starting from 3 in the child process
+	cmd.ExtraFiles = append(append(cmd.ExtraFiles[:0:0], files...), notify)
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env,
+		envHandoffFDs+"="+strconv.Itoa(len(files)),
+		envHandoffFDNames+"="+strings.Join(names, handoffSeparator),
+		envReadyFD+"="+strconv.Itoa(listenFDsStart+len(files)),
+	)
+
+	err = cmd.Start()
+	// the child holds its own copy, so the read end reports EOF as soon as it exits
+	_ = notify.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := ready.Read(make([]byte, 1))
+		result <- err
+	}()
+
+	select {
+	case err = <-result:
+		if err != nil {
+			err = ErrChildNotReady
+		}
+	case <-time.After(timeout):
+		err = ErrChildTimeout
+	}
+
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, err
+	}
+
+	return cmd.Process, nil
+}
+
+// Ready notifies the parent process, performing a graceful upgrade, that the process adopted
+// the listeners and is ready to serve. It's a no-op if the process wasn't spawned by Spawn.
+func Ready() error {
+	fd, err := strconv.Atoi(os.Getenv(envReadyFD))
+	if err != nil {
+		return nil
+	}
+
+	_ = os.Unsetenv(envReadyFD)
+	file := os.NewFile(uintptr(fd), "ready")
+	_, err = file.Write([]byte{1})
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+func closeFiles(files []*os.File) {
+	for _, file := range files {
+		_ = file.Close()
+	}
+}
@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	envHandoffChild   = "INDIGO_TEST_HANDOFF_CHILD"
+	envHandoffSharded = "INDIGO_TEST_HANDOFF_SHARDED"
+)
+
+// TestHandoffChild is run as the child process by TestHandoff.
+func TestHandoffChild(t *testing.T) {
+	addr := os.Getenv(envHandoffChild)
+	if len(addr) == 0 {
+		t.Skip("not a child process")
+	}
+
+	var tr Transport = NewTCP()
+	sharded := NewSharded(ShardedParams{Shards: 3})
+	if len(os.Getenv(envHandoffSharded)) > 0 {
+		tr = sharded
+	}
+
+	sup := NewSupervisor()
+	require.NoError(t, sup.Add(addr, tr, func(conn net.Conn) {
+		_, _ = conn.Write([]byte("hello from child"))
+		go sup.Stop()
+	}))
+	require.Empty(t, os.Getenv(envHandoffFDs), "the environment must be cleaned")
+	if tr == sharded {
+		// two shards are handed off, while the missing one is bound anew
+		require.Len(t, sharded.shards, 3)
+	}
+
+	require.NoError(t, Ready())
+	require.NoError(t, sup.Run(config.Default().NET))
+}
+
+func TestHandoff(t *testing.T) {
+	const addr = "127.0.0.1:16260"
+
+	spawnChild := func(files []*os.File, names []string, timeout time.Duration, env ...string) (*exec.Cmd, error) {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestHandoffChild$")
+		cmd.Env = append(append(os.Environ(), envHandoffChild+"="+addr), env...)
+		cmd.Stderr = os.Stderr
+		_, err := spawn(cmd, files, names, timeout)
+		return cmd, err
+	}
+
+	t.Run("take over", func(t *testing.T) {
+		sup := NewSupervisor()
+		require.NoError(t, sup.Add(addr, NewTCP(), func(net.Conn) {
+			require.Fail(t, "the parent must not serve")
+		}))
+		defer sup.close()
+
+		files, names, err := sup.Files()
+		require.NoError(t, err)
+		require.Equal(t, []string{addr}, names)
+
+		// the parent never runs the accept loop, so the connection below can be only
+		// accepted by the child
+		cmd, err := spawnChild(files, names, 10*time.Second)
+		require.NoError(t, err)
+
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		data, err := io.ReadAll(conn)
+		require.NoError(t, err)
+		require.Equal(t, "hello from child", string(data))
+		require.NoError(t, cmd.Wait())
+	})
+
+	t.Run("sharded", func(t *testing.T) {
+		sup := NewSupervisor()
+		require.NoError(t, sup.Add(addr, NewSharded(ShardedParams{Shards: 2}), func(net.Conn) {
+			require.Fail(t, "the parent must not serve")
+		}))
+		defer sup.close()
+
+		files, names, err := sup.Files()
+		require.NoError(t, err)
+		require.Equal(t, []string{addr, addr}, names)
+
+		cmd, err := spawnChild(files, names, 10*time.Second, envHandoffSharded+"=1")
+		require.NoError(t, err)
+
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		data, err := io.ReadAll(conn)
+		require.NoError(t, err)
+		require.Equal(t, "hello from child", string(data))
+		require.NoError(t, cmd.Wait())
+	})
+
+	t.Run("not ready", func(t *testing.T) {
+		cmd := exec.Command(os.Args[0], "-test.run=^$")
+		_, err := spawn(cmd, nil, nil, 10*time.Second)
+		require.ErrorIs(t, err, ErrChildNotReady)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		sup := NewSupervisor()
+		require.NoError(t, sup.Add(addr, newMock(time.Millisecond, nil, true), nil))
+		_, _, err := sup.Files()
+		require.ErrorIs(t, err, ErrHandoffUnsupported)
+	})
+}
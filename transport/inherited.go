@@ -0,0 +1,180 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	envListenPID     = "LISTEN_PID"
+	envListenFDs     = "LISTEN_FDS"
+	envListenFDNames = "LISTEN_FDNAMES"
+	// listenFDsStart is the first passed file descriptor, as preceding ones are occupied
+	// by standard streams.
+	listenFDsStart = 3
+	// unnamedListener is the name systemd uses for sockets without FileDescriptorName= set.
+	unnamedListener = "unknown"
+)
+
+var ErrNoInheritedListener = errors.New("no inherited listener by such name")
+
+type inheritedListener struct {
+	name string
+	l    net.Listener
+}
+
+var inherited struct {
+	once sync.Once
+	mu   sync.Mutex
+	ls   []inheritedListener
+	err  error
+}
+
+// Inherit claims a listener, passed by the service manager via the socket activation protocol
+// (see sd_listen_fds(3)), by its name. In case of systemd, it is set by FileDescriptorName=
+// and defaults to the socket unit name. Listeners handed off by the parent process during
+// a graceful upgrade (see Spawn) are named by addresses of their transports instead.
+//
+// Every listener can be claimed only once. The environment variables are unset after the first
+// call, so child processes won't mistakenly try to adopt the listeners as well.
+func Inherit(name string) (net.Listener, error) {
+	inherited.once.Do(func() {
+		inherited.ls, inherited.err = listenFDs()
+	})
+
+	inherited.mu.Lock()
+	defer inherited.mu.Unlock()
+
+	if inherited.err != nil {
+		return nil, inherited.err
+	}
+
+	for i, l := range inherited.ls {
+		if l.l != nil && l.name == name {
+			inherited.ls[i].l = nil
+			return l.l, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s: %w", name, ErrNoInheritedListener)
+}
+
+func listenFDs() ([]inheritedListener, error) {
+	names, err := parseListenEnv(
+		os.Getenv(envListenPID), os.Getenv(envListenFDs), os.Getenv(envListenFDNames), ":", os.Getpid(),
+	)
+	if err == nil && len(names) == 0 {
+		// no listeners from the service manager, but maybe there are ones from the parent
+		// process, performing a graceful upgrade. Those are meant exactly for us, as they're
+		// passed explicitly.
+		self := strconv.Itoa(os.Getpid())
+		names, err = parseListenEnv(
+			self, os.Getenv(envHandoffFDs), os.Getenv(envHandoffFDNames), handoffSeparator, os.Getpid(),
+		)
+	}
+
+	_ = os.Unsetenv(envListenPID)
+	_ = os.Unsetenv(envListenFDs)
+	_ = os.Unsetenv(envListenFDNames)
+	_ = os.Unsetenv(envHandoffFDs)
+	_ = os.Unsetenv(envHandoffFDNames)
+
+	if err != nil || len(names) == 0 {
+		return nil, err
+	}
+
+	files := make([]*os.File, len(names))
+	for i, name := range names {
+		files[i] = os.NewFile(uintptr(listenFDsStart+i), name)
+	}
+
+	return fileListeners(files, names)
+}
+
+// parseListenEnv returns names of all the passed file descriptors in their order.
+func parseListenEnv(pid, fds, fdnames, sep string, self int) ([]string, error) {
+	if len(fds) == 0 {
+		return nil, nil
+	}
+
+	if p, err := strconv.Atoi(pid); err != nil || p != self {
+		// the variables are addressed to another process (most likely our parent, which
+		// didn't unset them), so the descriptors aren't ours.
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("malformed %s: %q", envListenFDs, fds)
+	}
+
+	var given []string
+	if len(fdnames) > 0 {
+		given = strings.Split(fdnames, sep)
+	}
+
+	names := make([]string, n)
+	for i := range names {
+		names[i] = unnamedListener
+		if i < len(given) && len(given[i]) > 0 {
+			names[i] = given[i]
+		}
+	}
+
+	return names, nil
+}
+
+// fileListeners converts the files into listeners. The files are closed afterward, as
+// listeners hold their own duplicates of the descriptors.
+func fileListeners(files []*os.File, names []string) ([]inheritedListener, error) {
+	ls := make([]inheritedListener, 0, len(files))
+
+	for i, file := range files {
+		l, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			for _, inh := range ls {
+				_ = inh.l.Close()
+			}
+
+			for _, rest := range files[i+1:] {
+				_ = rest.Close()
+			}
+
+			return nil, fmt.Errorf("inherited listener %s: %w", names[i], err)
+		}
+
+		ls = append(ls, inheritedListener{name: names[i], l: l})
+	}
+
+	return ls, nil
+}
+
+// Inherited is a transport over a listener passed by the service manager. The address is
+// the name of the listener (see Inherit).
+type Inherited struct {
+	TCP
+}
+
+func NewInherited() *Inherited {
+	return new(Inherited)
+}
+
+func (i *Inherited) Bind(name string) error {
+	l, err := Inherit(name)
+	if err != nil {
+		return err
+	}
+
+	if err = i.Adopt(l); err != nil {
+		_ = l.Close()
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build freebsd
+
+package transport
+
+// soReusePort is SO_REUSEPORT_LB, which isn't exported by the syscall package. Unlike the plain
+// SO_REUSEPORT, it balances incoming connections between the listeners. Available since
+// FreeBSD 12.0.
+const soReusePort = 0x10000
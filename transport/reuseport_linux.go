@@ -0,0 +1,6 @@
+//go:build linux && !(mips || mipsle || mips64 || mips64le)
+
+package transport
+
+// soReusePort isn't exported by the syscall package on Linux.
+const soReusePort = 0xf
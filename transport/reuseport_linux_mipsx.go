@@ -0,0 +1,7 @@
+//go:build linux && (mips || mipsle || mips64 || mips64le)
+
+package transport
+
+// soReusePort isn't exported by the syscall package on Linux. MIPS is the only
+// architecture having it different.
+const soReusePort = 0x200
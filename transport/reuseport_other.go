@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package transport
+
+import "net"
+
+func listenReusePort(string) (*net.TCPListener, error) {
+	return nil, ErrReusePortUnsupported
+}
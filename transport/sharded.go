@@ -0,0 +1,148 @@
+package transport
+
+import (
+	"errors"
+	"net"
+	"os"
+	"runtime"
+
+	"github.com/indigo-web/indigo/config"
+)
+
+var ErrReusePortUnsupported = errors.New("SO_REUSEPORT isn't supported on this platform")
+
+type ShardedParams struct {
+	// Shards is the number of listeners, each having its own accept loop. Defaults to the
+	// number of CPU cores.
+	Shards int
+	// Pin locks every accept loop to its own OS thread, bound to a distinct CPU core.
+	// Supported only on Linux, ignored on other platforms.
+	Pin bool
+}
+
+// Sharded opens multiple SO_REUSEPORT listeners on the same address, so the kernel balances
+// incoming connections between their independent accept loops. This removes the single accept
+// loop bottleneck on many-core machines. All the shards are managed as a single transport,
+// including the connection admission limits.
+//
+// The balancing is done on Linux, DragonFly and FreeBSD (via SO_REUSEPORT_LB) only. Other BSDs
+// and macOS allow the listeners to share the address as well, however the connections aren't
+// spread across them, so there's no gain over the TCP transport.
+type Sharded struct {
+	params ShardedParams
+	shards []*TCP
+}
+
+func NewSharded(params ShardedParams) *Sharded {
+	if params.Shards <= 0 {
+		params.Shards = runtime.NumCPU()
+	}
+
+	return &Sharded{params: params}
+}
+
+func (s *Sharded) Bind(addr string) error {
+	return s.bind(addr, s.params.Shards)
+}
+
+// bind adds n more shards, listening on the address.
+func (s *Sharded) bind(addr string, n int) error {
+	for range n {
+		l, err := listenReusePort(addr)
+		if err != nil {
+			s.Close()
+			s.shards = nil
+			return err
+		}
+
+		// if the port was chosen by the system, stick all the other shards to the same one
+		addr = l.Addr().String()
+		tcp := newTCP(l)
+		s.shards = append(s.shards, &tcp)
+	}
+
+	return nil
+}
+
+// Files returns duplicates of file descriptors of all the shards' listeners.
+func (s *Sharded) Files() ([]*os.File, error) {
+	files := make([]*os.File, 0, len(s.shards))
+	for _, shard := range s.shards {
+		file, err := shard.File()
+		if err != nil {
+			closeFiles(files)
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// AdoptAll makes the transport serve the listeners, each by its own shard, instead of binding
+// new ones. If there are fewer listeners than shards, the rest is bound to the same address.
+// The listeners are closed on failure.
+func (s *Sharded) AdoptAll(ls []net.Listener) error {
+	for i, l := range ls {
+		var shard TCP
+		if err := shard.Adopt(l); err != nil {
+			for _, rest := range ls[i:] {
+				_ = rest.Close()
+			}
+
+			s.Close()
+			s.shards = nil
+			return err
+		}
+
+		s.shards = append(s.shards, &shard)
+	}
+
+	return s.bind(ls[0].Addr().String(), s.params.Shards-len(ls))
+}
+
+func (s *Sharded) Listen(cfg config.NET, cb func(conn net.Conn)) error {
+	var (
+		admit = newAdmission(cfg.Connections)
+		errch = make(chan error, len(s.shards))
+	)
+
+	for i, shard := range s.shards {
+		go func(cpu int, shard *TCP) {
+			if s.params.Pin {
+				runtime.LockOSThread()
+				defer runtime.UnlockOSThread()
+				_ = pinToCPU(cpu % runtime.NumCPU())
+			}
+
+			errch <- shard.listen(cfg, admit, cb)
+		}(i, shard)
+	}
+
+	// the first returned error stops the rest of shards. Shards are otherwise stopped
+	// only all together, so returned nil means they're all done.
+	err := <-errch
+	s.Stop()
+	drain(errch, len(s.shards)-1)
+
+	return err
+}
+
+func (s *Sharded) Stop() {
+	for _, shard := range s.shards {
+		shard.Stop()
+	}
+}
+
+func (s *Sharded) Close() {
+	for _, shard := range s.shards {
+		shard.Close()
+	}
+}
+
+func (s *Sharded) Wait() {
+	for _, shard := range s.shards {
+		shard.Wait()
+	}
+}
@@ -0,0 +1,49 @@
+package transport
+
+import (
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharded(t *testing.T) {
+	s := NewSharded(ShardedParams{Shards: 4, Pin: true})
+	require.NoError(t, s.Bind("127.0.0.1:0"))
+	require.Len(t, s.shards, 4)
+
+	addr := s.shards[0].l.Addr().String()
+	for _, shard := range s.shards {
+		require.Equal(t, addr, shard.l.Addr().String())
+	}
+
+	var served atomic.Int32
+	cfg := config.Default().NET
+	cfg.Connections.Max = 1
+	cfg.AcceptLoopInterruptPeriod = 50 * time.Millisecond
+	errch := make(chan error)
+	go func() {
+		errch <- s.Listen(cfg, func(conn net.Conn) {
+			served.Add(1)
+			_, _ = conn.Write([]byte("hello"))
+		})
+	}()
+
+	for range 20 {
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		data, err := io.ReadAll(conn)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(data))
+	}
+
+	s.Stop()
+	require.NoError(t, <-errch)
+	s.Wait()
+	s.Close()
+	require.Equal(t, int32(20), served.Load())
+}
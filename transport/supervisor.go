@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"fmt"
 	"net"
 	"sync/atomic"
 
@@ -28,16 +29,19 @@ func NewSupervisor() Supervisor {
 	}
 }
 
+// Add binds the transport to the address. If the listener by the address was handed off by
+// the parent process (see Spawn), it's adopted instead.
 func (s *Supervisor) Add(addr string, transport Transport, cb func(net.Conn)) error {
-	err := transport.Bind(addr)
+	err := bind(addr, transport)
 	if err != nil {
 		s.close()
 		return err
 	}
 
 	s.ts = append(s.ts, boundTransport{
-		cb: cb,
-		t:  transport,
+		addr: addr,
+		cb:   cb,
+		t:    transport,
 	})
 
 	return nil
@@ -102,8 +106,55 @@ func (s *Supervisor) close() {
 }
 
 type boundTransport struct {
-	cb func(conn net.Conn)
-	t  Transport
+	addr string
+	cb   func(conn net.Conn)
+	t    Transport
+}
+
+func bind(addr string, transport Transport) error {
+	if m, ok := transport.(MultiHandoff); ok {
+		return bindMulti(addr, transport, m)
+	}
+
+	h, ok := transport.(Handoff)
+	if !ok {
+		return transport.Bind(addr)
+	}
+
+	l, err := Inherit(addr)
+	if err != nil {
+		return transport.Bind(addr)
+	}
+
+	if err = h.Adopt(l); err != nil {
+		_ = l.Close()
+		return fmt.Errorf("%s: %w", addr, err)
+	}
+
+	return nil
+}
+
+// bindMulti adopts all the listeners handed off by the address, if any.
+func bindMulti(addr string, transport Transport, m MultiHandoff) error {
+	var ls []net.Listener
+	for {
+		l, err := Inherit(addr)
+		if err != nil {
+			break
+		}
+
+		ls = append(ls, l)
+	}
+
+	if len(ls) == 0 {
+		return transport.Bind(addr)
+	}
+
+	if err := m.AdoptAll(ls); err != nil {
+		return fmt.Errorf("%s: %w", addr, err)
+	}
+
+	return nil
 }
 
 func drain(ch <-chan error, n int) {
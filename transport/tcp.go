@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"fmt"
 	"net"
 	"os"
 	"sync"
@@ -49,7 +50,35 @@ func (t *TCP) Bind(addr string) (err error) {
 	return err
 }
 
+// File returns a duplicate of the listener's file descriptor.
+func (t *TCP) File() (*os.File, error) {
+	filer, ok := t.l.(interface {
+		File() (*os.File, error)
+	})
+	if !ok {
+		return nil, ErrHandoffUnsupported
+	}
+
+	return filer.File()
+}
+
+// Adopt makes the transport serve the listener instead of binding a new one.
+func (t *TCP) Adopt(l net.Listener) error {
+	dl, ok := l.(listener)
+	if !ok {
+		return fmt.Errorf("unsupported listener type %T", l)
+	}
+
+	*t = newTCP(dl)
+
+	return nil
+}
+
 func (t *TCP) Listen(cfg config.NET, cb func(conn net.Conn)) error {
+	return t.listen(cfg, newAdmission(cfg.Connections), cb)
+}
+
+func (t *TCP) listen(cfg config.NET, admit *admission, cb func(conn net.Conn)) error {
 	for !t.stop.Load() {
 		err := t.l.SetDeadline(timer.Now().Add(cfg.AcceptLoopInterruptPeriod))
 		if err != nil {
@@ -65,17 +94,28 @@ func (t *TCP) Listen(cfg config.NET, cb func(conn net.Conn)) error {
 			return err
 		}
 
-		go func(conn net.Conn) {
-			t.wg.Add(1)
-			cb(conn)
-			_ = conn.Close()
-			t.wg.Done()
-		}(conn)
+		t.wg.Add(1)
+		go t.serve(admit, conn, cb)
 	}
 
 	return nil
 }
 
+func (t *TCP) serve(admit *admission, conn net.Conn, cb func(conn net.Conn)) {
+	defer t.wg.Done()
+
+	release, err := admit.Admit(conn.RemoteAddr())
+	if err != nil {
+		admit.Reject(conn, err)
+		_ = conn.Close()
+		return
+	}
+
+	cb(conn)
+	_ = conn.Close()
+	release()
+}
+
 func (t *TCP) Stop() {
 	t.stop.Store(true)
 }
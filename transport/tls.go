@@ -2,6 +2,7 @@ package transport
 
 import (
 	"crypto/tls"
+	"fmt"
 	"net"
 )
 
@@ -14,6 +15,11 @@ func NewTLS(cfg *tls.Config) *TLS {
 	return &TLS{cfg: cfg}
 }
 
+// Config returns the TLS configuration the transport is using.
+func (t *TLS) Config() *tls.Config {
+	return t.cfg
+}
+
 func (t *TLS) Bind(addr string) error {
 	tcp, err := bindTCP(addr)
 	if err != nil {
@@ -26,6 +32,19 @@ func (t *TLS) Bind(addr string) error {
 	return nil
 }
 
+// Adopt makes the transport serve the listener instead of binding a new one. The listener
+// must be a plain TCP one, as the encryption is applied on top of it.
+func (t *TLS) Adopt(l net.Listener) error {
+	tcp, ok := l.(*net.TCPListener)
+	if !ok {
+		return fmt.Errorf("unsupported listener type %T", l)
+	}
+
+	t.TCP = newTCP(tlsAdapter{tcp, tls.NewListener(tcp, t.cfg)})
+
+	return nil
+}
+
 type tlsAdapter struct {
 	*net.TCPListener
 	tls net.Listener
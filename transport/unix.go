@@ -0,0 +1,155 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrSocketInUse = errors.New("socket is already in use")
+	ErrNotSocket   = errors.New("file exists and is not a socket")
+)
+
+type UnixParams struct {
+	// Mode sets the permissions of the socket file. Zero leaves them as determined by umask.
+	// Note that in order to connect, clients need the write permission.
+	Mode os.FileMode
+	// Owner and Group change the ownership of the socket file. Both accept either names or
+	// numeric identifiers. Empty values leave the corresponding ownership intact.
+	Owner, Group string
+}
+
+// Unix is a transport over Unix domain sockets. The address is a path to the socket file.
+type Unix struct {
+	TCP
+	params UnixParams
+}
+
+func NewUnix(params UnixParams) *Unix {
+	return &Unix{params: params}
+}
+
+func (u *Unix) Bind(path string) error {
+	if err := removeStale(path); err != nil {
+		return err
+	}
+
+	addr, err := net.ResolveUnixAddr("unix", path)
+	if err != nil {
+		return err
+	}
+
+	l, err := net.ListenUnix("unix", addr)
+	if err != nil {
+		return err
+	}
+
+	if err = u.setup(path); err != nil {
+		// the socket file is removed on close automatically
+		_ = l.Close()
+		return err
+	}
+
+	u.TCP = newTCP(l)
+
+	return nil
+}
+
+// File returns a duplicate of the listener's file descriptor. The socket file is no longer
+// removed on close afterward, as the process receiving the descriptor keeps serving it.
+func (u *Unix) File() (*os.File, error) {
+	if ul, ok := u.l.(*net.UnixListener); ok {
+		ul.SetUnlinkOnClose(false)
+	}
+
+	return u.TCP.File()
+}
+
+func (u *Unix) setup(path string) error {
+	if u.params.Mode != 0 {
+		if err := os.Chmod(path, u.params.Mode); err != nil {
+			return err
+		}
+	}
+
+	if len(u.params.Owner) == 0 && len(u.params.Group) == 0 {
+		return nil
+	}
+
+	uid, err := lookupID(u.params.Owner, func(name string) (string, error) {
+		usr, err := user.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+
+		return usr.Uid, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	gid, err := lookupID(u.params.Group, func(name string) (string, error) {
+		group, err := user.LookupGroup(name)
+		if err != nil {
+			return "", err
+		}
+
+		return group.Gid, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.Chown(path, uid, gid)
+}
+
+// lookupID resolves either a numeric identifier or a name into the identifier. Empty name
+// results in -1, which is interpreted by os.Chown as "leave intact".
+func lookupID(name string, lookup func(string) (string, error)) (int, error) {
+	if len(name) == 0 {
+		return -1, nil
+	}
+
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+
+	id, err := lookup(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(id)
+}
+
+// removeStale removes the socket file left by a previous process, which didn't shut down
+// gracefully. Sockets still accepting connections and files of other types are never touched.
+func removeStale(path string) error {
+	stat, err := os.Lstat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	case stat.Mode()&os.ModeSocket == 0:
+		return fmt.Errorf("%s: %w", path, ErrNotSocket)
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("%s: %w", path, ErrSocketInUse)
+	}
+
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,104 @@
+package transport
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/indigo-web/indigo/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnix(t *testing.T) {
+	t.Run("serve", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "indigo.sock")
+		u := NewUnix(UnixParams{Mode: 0600})
+		require.NoError(t, u.Bind(path))
+
+		stat, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+
+		go func() {
+			_ = u.Listen(config.Default().NET, func(conn net.Conn) {
+				_, _ = conn.Write([]byte("hello"))
+			})
+		}()
+
+		conn, err := net.Dial("unix", path)
+		require.NoError(t, err)
+		data, err := io.ReadAll(conn)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(data))
+
+		u.Stop()
+		u.Wait()
+		u.Close()
+		_, err = os.Stat(path)
+		require.ErrorIs(t, err, os.ErrNotExist, "socket file must be removed on close")
+	})
+
+	t.Run("stale socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "indigo.sock")
+		l, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		l.(*net.UnixListener).SetUnlinkOnClose(false)
+		require.NoError(t, l.Close())
+
+		u := NewUnix(UnixParams{})
+		require.NoError(t, u.Bind(path))
+		u.Close()
+	})
+
+	t.Run("socket in use", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "indigo.sock")
+		l, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		defer l.Close()
+
+		require.ErrorIs(t, NewUnix(UnixParams{}).Bind(path), ErrSocketInUse)
+	})
+
+	t.Run("not a socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "indigo.sock")
+		require.NoError(t, os.WriteFile(path, []byte("precious data"), 0600))
+		require.ErrorIs(t, NewUnix(UnixParams{}).Bind(path), ErrNotSocket)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "precious data", string(data))
+	})
+}
+
+func TestInherited(t *testing.T) {
+	t.Run("environment", func(t *testing.T) {
+		names, err := parseListenEnv("42", "3", "web:", ":", 42)
+		require.NoError(t, err)
+		require.Equal(t, []string{"web", unnamedListener, unnamedListener}, names)
+
+		names, err = parseListenEnv("41", "3", "web", ":", 42)
+		require.NoError(t, err)
+		require.Empty(t, names, "descriptors addressed to another process must be ignored")
+
+		_, err = parseListenEnv("42", "three", "", ":", 42)
+		require.Error(t, err)
+	})
+
+	t.Run("file listeners", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer l.Close()
+
+		file, err := l.(*net.TCPListener).File()
+		require.NoError(t, err)
+
+		ls, err := fileListeners([]*os.File{file}, []string{"web"})
+		require.NoError(t, err)
+		require.Len(t, ls, 1)
+		require.Equal(t, "web", ls[0].name)
+		require.Equal(t, l.Addr().String(), ls[0].l.Addr().String())
+		require.NoError(t, ls[0].l.Close())
+	})
+}
@@ -0,0 +1,89 @@
+package indigo
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"time"
+
+	"github.com/indigo-web/indigo/transport"
+)
+
+// UpgradeTimeout is the maximal duration the new process is given to get ready during
+// a graceful upgrade.
+const UpgradeTimeout = time.Minute
+
+var ErrUpgradeInProgress = errors.New("upgrade is already in progress or done")
+
+// Upgrade gracefully replaces the running process with a new instance of the executable, started
+// with the same arguments. Listeners of all the bound transports are handed off to it, so no
+// connection is refused in between. As soon as the new process is ready, the current one stops
+// accepting new connections and drains existing ones, after which Serve returns. If the new
+// process fails to get ready in time, it's killed and the current one keeps serving.
+//
+// Must be called only while serving. It's safe to call it from within a handler.
+func (a *App) Upgrade() error {
+	if !a.upgrade.busy.CompareAndSwap(false, true) {
+		return ErrUpgradeInProgress
+	}
+
+	files, names, err := a.supervisor.Files()
+	if err == nil {
+		_, err = transport.Spawn(files, names, UpgradeTimeout)
+	}
+
+	if err != nil {
+		a.upgrade.busy.Store(false)
+		return err
+	}
+
+	// draining might take a while and must not block the caller, which might be a handler
+	// (therefore the connection being drained itself.)
+	go a.Stop()
+
+	return nil
+}
+
+// UpgradeOn makes the application perform a graceful upgrade (see Upgrade) on receiving any of
+// the signals. Commonly, syscall.SIGHUP or syscall.SIGUSR2 are used for this purpose.
+func (a *App) UpgradeOn(signals ...os.Signal) *App {
+	a.upgrade.signals = append(a.upgrade.signals, signals...)
+	return a
+}
+
+// OnUpgrade calls the callback after every upgrade attempt triggered by a signal. The error is
+// nil if the new process successfully took over.
+func (a *App) OnUpgrade(cb func(err error)) *App {
+	a.hooks.OnUpgrade = cb
+	return a
+}
+
+type upgrader struct {
+	busy    atomic.Bool
+	signals []os.Signal
+}
+
+// Notify starts listening to the upgrade signals, if any. The returned function stops it.
+func (u *upgrader) Notify(a *App) (stop func()) {
+	if len(u.signals) == 0 {
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, u.signals...)
+
+	go func() {
+		for range ch {
+			err := a.Upgrade()
+			if a.hooks.OnUpgrade != nil {
+				a.hooks.OnUpgrade(err)
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		close(ch)
+	}
+}